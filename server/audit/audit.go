@@ -0,0 +1,207 @@
+// Package audit records the actions taken on multisig accounts in an append-only log.
+//
+// Events of a multisig account form a hash chain: every event stores the hash of the
+// previous one and its own hash covers its content and that link, so changing or
+// removing an event breaks the chain from that point.
+package audit
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/schema"
+)
+
+// Actions recorded in the log.
+const (
+	AccountCreated       = "account_created"
+	AccountDeleted       = "account_deleted"
+	AccountRestored      = "account_restored"
+	AccountPurged        = "account_purged"
+	VisibilityUpdated    = "visibility_updated"
+	RoleGranted          = "role_granted"
+	RoleRevoked          = "role_revoked"
+	TxProposed           = "tx_proposed"
+	TxUpdated            = "tx_updated"
+	TxEdited             = "tx_edited"
+	TxSigned             = "tx_signed"
+	TxResigned           = "tx_resigned"
+	TxRejected           = "tx_rejected"
+	TxDeleted            = "tx_deleted"
+	TxRestored           = "tx_restored"
+	TxPurged             = "tx_purged"
+	TxBroadcast          = "tx_broadcast"
+	TxRequeued           = "tx_requeued"
+	StatusChanged        = "status_changed"
+	SignaturesCleared    = "signatures_cleared"
+	PolicyProposed       = "policy_proposed"
+	PolicyApproved       = "policy_approved"
+	PolicyRejected       = "policy_rejected"
+	AddressAdded         = "address_added"
+	AddressUpdated       = "address_updated"
+	AddressRemoved       = "address_removed"
+	RecipientModeUpdated = "recipient_mode_updated"
+	ScheduleCreated      = "schedule_created"
+	ScheduleUpdated      = "schedule_updated"
+	ScheduleDeleted      = "schedule_deleted"
+)
+
+// SystemActor is the actor of changes made by the server on its own, such as cron jobs.
+const SystemActor = "system"
+
+// Store is a database or a database transaction.
+type Store interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Value encodes the old or new value of an event.
+func Value(v interface{}) json.RawMessage {
+	bz, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	return bz
+}
+
+// Status is the value of a status change.
+func Status(status string) json.RawMessage {
+	return Value(map[string]string{"status": status})
+}
+
+// TxID returns a pointer to a transaction id.
+func TxID(id int) *int {
+	return &id
+}
+
+// Record appends the event to the log of its multisig account. It should run in the
+// database transaction making the change, which it locks the log of the account for.
+func Record(s Store, event schema.AuditEvent) error {
+	if _, err := s.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, event.MultisigAddress); err != nil {
+		return err
+	}
+
+	var prevHash string
+	err := s.QueryRow(`SELECT hash FROM audit_events WHERE multisig_address=$1 ORDER BY id DESC LIMIT 1`,
+		event.MultisigAddress).Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	// timestamps are stored with microsecond precision
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	event.PrevHash = prevHash
+	if event.Actor == "" {
+		event.Actor = SystemActor
+	}
+
+	hash, err := Hash(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Exec(`INSERT INTO audit_events ("multisig_address","tx_id","actor","action","old_value","new_value","created_at","prev_hash","hash")
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`,
+		event.MultisigAddress, event.TxID, event.Actor, event.Action, nullJSON(event.OldValue), nullJSON(event.NewValue),
+		event.CreatedAt, event.PrevHash, hash)
+	return err
+}
+
+// Log records an event in its own database transaction, for changes which are not
+// made in one.
+func Log(db *sql.DB, event schema.AuditEvent) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := Record(tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Hash returns the hash of an event. JSON values are hashed in their canonical form,
+// as the database does not keep the formatting of JSONB values.
+func Hash(event schema.AuditEvent) (string, error) {
+	oldValue, err := canonical(event.OldValue)
+	if err != nil {
+		return "", fmt.Errorf("old value: %w", err)
+	}
+
+	newValue, err := canonical(event.NewValue)
+	if err != nil {
+		return "", fmt.Errorf("new value: %w", err)
+	}
+
+	bz, err := json.Marshal(struct {
+		PrevHash        string          `json:"prev_hash"`
+		MultisigAddress string          `json:"multisig_address"`
+		TxID            *int            `json:"tx_id"`
+		Actor           string          `json:"actor"`
+		Action          string          `json:"action"`
+		OldValue        json.RawMessage `json:"old_value"`
+		NewValue        json.RawMessage `json:"new_value"`
+		CreatedAt       string          `json:"created_at"`
+	}{
+		PrevHash:        event.PrevHash,
+		MultisigAddress: event.MultisigAddress,
+		TxID:            event.TxID,
+		Actor:           event.Actor,
+		Action:          event.Action,
+		OldValue:        oldValue,
+		NewValue:        newValue,
+		CreatedAt:       event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(bz)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks the hash chain of the events of an account, given in the order they
+// were recorded. It returns the id of the first event which does not match, or 0 when
+// the chain is intact.
+func Verify(events []schema.AuditEvent) int64 {
+	prevHash := ""
+	for _, event := range events {
+		if event.PrevHash != prevHash {
+			return event.ID
+		}
+
+		hash, err := Hash(event)
+		if err != nil || hash != event.Hash {
+			return event.ID
+		}
+
+		prevHash = event.Hash
+	}
+
+	return 0
+}
+
+func canonical(v json.RawMessage) (json.RawMessage, error) {
+	if len(v) == 0 {
+		return json.RawMessage("null"), nil
+	}
+
+	return cosmos.SortJSON(v)
+}
+
+func nullJSON(v json.RawMessage) interface{} {
+	if len(v) == 0 {
+		return nil
+	}
+
+	return []byte(v)
+}
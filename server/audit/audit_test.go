@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/schema"
+)
+
+func chain(t *testing.T, n int) []schema.AuditEvent {
+	events := make([]schema.AuditEvent, n)
+	prevHash := ""
+	for i := range events {
+		events[i] = schema.AuditEvent{
+			ID:              int64(i + 1),
+			MultisigAddress: "osmo1multisig",
+			TxID:            TxID(i),
+			Actor:           "osmo1member",
+			Action:          StatusChanged,
+			OldValue:        json.RawMessage(`{"status":"PENDING"}`),
+			NewValue:        json.RawMessage(`{"status":"BROADCASTING","hash":""}`),
+			CreatedAt:       time.Date(2024, 1, 1, 0, 0, i, 1000, time.UTC),
+			PrevHash:        prevHash,
+		}
+
+		hash, err := Hash(events[i])
+		require.NoError(t, err)
+		events[i].Hash = hash
+		prevHash = hash
+	}
+
+	return events
+}
+
+func TestVerify(t *testing.T) {
+	testCases := []struct {
+		name        string
+		malleate    func(events []schema.AuditEvent) []schema.AuditEvent
+		expBrokenAt int64
+	}{
+		{
+			"intact chain",
+			func(events []schema.AuditEvent) []schema.AuditEvent { return events },
+			0,
+		},
+		{
+			"empty chain",
+			func(events []schema.AuditEvent) []schema.AuditEvent { return nil },
+			0,
+		},
+		{
+			"json values reformatted by the database",
+			func(events []schema.AuditEvent) []schema.AuditEvent {
+				events[1].NewValue = json.RawMessage(`{"hash": "", "status": "BROADCASTING"}`)
+				return events
+			},
+			0,
+		},
+		{
+			"modified value",
+			func(events []schema.AuditEvent) []schema.AuditEvent {
+				events[1].NewValue = json.RawMessage(`{"status":"SUCCESS","hash":""}`)
+				return events
+			},
+			2,
+		},
+		{
+			"modified actor",
+			func(events []schema.AuditEvent) []schema.AuditEvent {
+				events[2].Actor = SystemActor
+				return events
+			},
+			3,
+		},
+		{
+			"deleted event",
+			func(events []schema.AuditEvent) []schema.AuditEvent {
+				return append(events[:1], events[2:]...)
+			},
+			3,
+		},
+		{
+			"rehashed event",
+			func(events []schema.AuditEvent) []schema.AuditEvent {
+				events[1].Action = TxDeleted
+				hash, _ := Hash(events[1])
+				events[1].Hash = hash
+				return events
+			},
+			3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			events := tc.malleate(chain(t, 4))
+			require.Equal(t, tc.expBrokenAt, Verify(events))
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package clients
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/vitwit/resolute/server/config"
+)
+
+// Account is the on-chain state of an account needed to build sign docs.
+type Account struct {
+	Address       string
+	AccountNumber uint64
+	Sequence      uint64
+}
+
+type baseAccount struct {
+	Address       string `json:"address"`
+	AccountNumber string `json:"account_number"`
+	Sequence      string `json:"sequence"`
+}
+
+type accountResponse struct {
+	Account struct {
+		baseAccount
+		BaseAccount        *baseAccount `json:"base_account"`
+		BaseVestingAccount *struct {
+			BaseAccount *baseAccount `json:"base_account"`
+		} `json:"base_vesting_account"`
+	} `json:"account"`
+}
+
+// getChainConfig returns the chain details, preferring the cached chain with a
+// healthy rest endpoint.
+func getChainConfig(chainId string) (*config.ChainConfig, error) {
+	if chain := GetChain(chainId); chain != nil && chain.RestURI != "" {
+		return chain, nil
+	}
+
+	for _, chain := range config.GetChainAPIs() {
+		if chain.ChainId == chainId && len(chain.RestURIs) > 0 {
+			if chain.RestURI == "" {
+				chain.RestURI = chain.RestURIs[0]
+			}
+			return chain, nil
+		}
+	}
+
+	return nil, errors.New("unable to get chain details")
+}
+
+// ChainRequest sends a request to the rest endpoint of the chain and returns the
+// response body and status code.
+func ChainRequest(chainId string, method string, path string, body io.Reader) ([]byte, int, error) {
+	cfg, err := config.ParseConfig()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	chain, err := getChainConfig(chainId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequest(method, chain.RestURI+path, body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if chain.SourceEnd == "mintscan" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", cfg.MINTSCAN_TOKEN.Token))
+	}
+
+	if chain.SourceEnd == "numia" {
+		req.Header.Add("Authorization", "Bearer "+cfg.NUMIA_BEARER_TOKEN.Token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	bz, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return bz, resp.StatusCode, nil
+}
+
+// GetAccount fetches the account number and sequence of an address.
+func GetAccount(chainId string, address string) (*Account, error) {
+	bz, status, err := ChainRequest(chainId, http.MethodGet, "/cosmos/auth/v1beta1/accounts/"+address, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch account %s: status %d", address, status)
+	}
+
+	return parseAccount(bz)
+}
+
+func parseAccount(bz []byte) (*Account, error) {
+	var resp accountResponse
+	if err := json.Unmarshal(bz, &resp); err != nil {
+		return nil, err
+	}
+
+	base := &resp.Account.baseAccount
+	if resp.Account.BaseAccount != nil {
+		base = resp.Account.BaseAccount
+	}
+	if v := resp.Account.BaseVestingAccount; v != nil && v.BaseAccount != nil {
+		base = v.BaseAccount
+	}
+
+	if base.Address == "" {
+		return nil, errors.New("account not found")
+	}
+
+	accountNumber, err := strconv.ParseUint(base.AccountNumber, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account number: %w", err)
+	}
+
+	sequence, err := strconv.ParseUint(base.Sequence, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sequence: %w", err)
+	}
+
+	return &Account{
+		Address:       base.Address,
+		AccountNumber: accountNumber,
+		Sequence:      sequence,
+	}, nil
+}
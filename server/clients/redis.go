@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/vitwit/resolute/server/config"
@@ -48,6 +49,37 @@ func GetValue(key string) (string, error) {
 	return val, nil
 }
 
+// SetValueWithExpiry sets a value in Redis which expires after the given duration
+func SetValueWithExpiry(key string, value string, expiry time.Duration) error {
+	return RedisClient.Set(ctx, key, value, expiry).Err()
+}
+
+// PopValue gets a value from Redis and deletes the key atomically
+func PopValue(key string) (string, error) {
+	val, err := RedisClient.GetDel(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return val, nil
+}
+
+// DeleteValue deletes a key from Redis
+func DeleteValue(key string) error {
+	return RedisClient.Del(ctx, key).Err()
+}
+
+// Publish sends a message to the subscribers of a channel on every server
+func Publish(channel string, message []byte) error {
+	return RedisClient.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe subscribes to a channel until the subscription is closed
+func Subscribe(c context.Context, channel string) *redis.PubSub {
+	return RedisClient.Subscribe(c, channel)
+}
+
 func GetChain(chainId string) *config.ChainConfig {
 	data, err := GetValue("chains")
 	if err != nil {
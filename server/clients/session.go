@@ -0,0 +1,122 @@
+package clients
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Session is an authenticated login of an address. The access token is sent in the
+// Authorization header, the refresh token is exchanged for a new session.
+type Session struct {
+	Address          string    `json:"address"`
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+func sessionKey(token string) string {
+	return fmt.Sprintf("auth:session:%s", token)
+}
+
+func refreshKey(token string) string {
+	return fmt.Sprintf("auth:refresh:%s", token)
+}
+
+func newToken() (string, error) {
+	bz := make([]byte, 32)
+	if _, err := rand.Read(bz); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bz), nil
+}
+
+// CreateSession issues a new access and refresh token pair for the address
+func CreateSession(address string, expiry time.Duration, refreshExpiry time.Duration) (*Session, error) {
+	accessToken, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	session := &Session{
+		Address:          address,
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        now.Add(expiry),
+		RefreshExpiresAt: now.Add(refreshExpiry),
+	}
+
+	bz, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := SetValueWithExpiry(sessionKey(accessToken), string(bz), expiry); err != nil {
+		return nil, err
+	}
+
+	if err := SetValueWithExpiry(refreshKey(refreshToken), string(bz), refreshExpiry); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// GetSession returns the session of an access token, or nil if it is unknown or expired
+func GetSession(accessToken string) (*Session, error) {
+	data, err := GetValue(sessionKey(accessToken))
+	if err != nil || data == "" {
+		return nil, err
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// RefreshSession consumes the refresh token, revokes its access token and issues a new session
+func RefreshSession(refreshToken string, expiry time.Duration, refreshExpiry time.Duration) (*Session, error) {
+	data, err := PopValue(refreshKey(refreshToken))
+	if err != nil || data == "" {
+		return nil, err
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, err
+	}
+
+	if err := DeleteValue(sessionKey(session.AccessToken)); err != nil {
+		return nil, err
+	}
+
+	return CreateSession(session.Address, expiry, refreshExpiry)
+}
+
+// RevokeSession invalidates the access token and its refresh token
+func RevokeSession(accessToken string) error {
+	data, err := PopValue(sessionKey(accessToken))
+	if err != nil || data == "" {
+		return err
+	}
+
+	var session Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return err
+	}
+
+	return DeleteValue(refreshKey(session.RefreshToken))
+}
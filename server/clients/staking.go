@@ -0,0 +1,64 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// monikersExpiry is how long validator monikers of a chain are cached.
+const monikersExpiry = time.Hour
+
+type validatorsResponse struct {
+	Validators []struct {
+		OperatorAddress string `json:"operator_address"`
+		Description     struct {
+			Moniker string `json:"moniker"`
+		} `json:"description"`
+	} `json:"validators"`
+}
+
+// GetValidatorMonikers returns the monikers of the validators of the chain by
+// operator address.
+func GetValidatorMonikers(chainId string) (map[string]string, error) {
+	key := "validators:" + chainId
+
+	if RedisClient != nil {
+		if data, err := GetValue(key); err == nil && data != "" {
+			var monikers map[string]string
+			if err := json.Unmarshal([]byte(data), &monikers); err == nil {
+				return monikers, nil
+			}
+		}
+	}
+
+	bz, status, err := ChainRequest(chainId, http.MethodGet, "/cosmos/staking/v1beta1/validators?pagination.limit=1000", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch validators: status %d", status)
+	}
+
+	var resp validatorsResponse
+	if err := json.Unmarshal(bz, &resp); err != nil {
+		return nil, err
+	}
+
+	monikers := make(map[string]string, len(resp.Validators))
+	for _, v := range resp.Validators {
+		monikers[v.OperatorAddress] = v.Description.Moniker
+	}
+
+	if RedisClient != nil {
+		if data, err := json.Marshal(monikers); err == nil {
+			if err := SetValueWithExpiry(key, string(data), monikersExpiry); err != nil {
+				fmt.Println("Error caching validators in Redis:", err)
+			}
+		}
+	}
+
+	return monikers, nil
+}
@@ -0,0 +1,112 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/vitwit/resolute/server/txn_types"
+)
+
+type broadcastTxReq struct {
+	TxBytes string `json:"tx_bytes"`
+	Mode    string `json:"mode"`
+}
+
+type txResponse struct {
+	TxResponse *txn_types.TxResponse `json:"tx_response"`
+}
+
+// BroadcastTx submits signed tx bytes to the chain and returns the check tx result.
+func BroadcastTx(chainId string, txBytes []byte) (*txn_types.TxResponse, error) {
+	reqBz, err := json.Marshal(broadcastTxReq{
+		TxBytes: base64.StdEncoding.EncodeToString(txBytes),
+		Mode:    "BROADCAST_MODE_SYNC",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bz, status, err := ChainRequest(chainId, http.MethodPost, "/cosmos/tx/v1beta1/txs", bytes.NewReader(reqBz))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp txResponse
+	if err := json.Unmarshal(bz, &resp); err != nil || resp.TxResponse == nil {
+		return nil, fmt.Errorf("failed to broadcast transaction: status %d: %s", status, string(bz))
+	}
+
+	return resp.TxResponse, nil
+}
+
+// GetTx fetches an included transaction by hash. It returns nil if the transaction is
+// not found (yet).
+func GetTx(chainId string, hash string) (*txn_types.TxResponse, error) {
+	bz, status, err := ChainRequest(chainId, http.MethodGet, "/cosmos/tx/v1beta1/txs/"+hash, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+
+	var resp txResponse
+	if err := json.Unmarshal(bz, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.TxResponse == nil {
+		if status == http.StatusOK {
+			return nil, fmt.Errorf("invalid response for transaction %s", hash)
+		}
+		// some nodes answer with an internal error for unknown hashes
+		return nil, nil
+	}
+
+	return resp.TxResponse, nil
+}
+
+type simulateTxReq struct {
+	TxBytes string `json:"tx_bytes"`
+}
+
+type simulateTxResponse struct {
+	GasInfo *struct {
+		GasUsed string `json:"gas_used"`
+	} `json:"gas_info"`
+	Message string `json:"message"`
+}
+
+// SimulateTx runs the tx against the chain state and returns the gas it used.
+func SimulateTx(chainId string, txBytes []byte) (uint64, error) {
+	reqBz, err := json.Marshal(simulateTxReq{
+		TxBytes: base64.StdEncoding.EncodeToString(txBytes),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	bz, status, err := ChainRequest(chainId, http.MethodPost, "/cosmos/tx/v1beta1/simulate", bytes.NewReader(reqBz))
+	if err != nil {
+		return 0, err
+	}
+
+	var resp simulateTxResponse
+	if err := json.Unmarshal(bz, &resp); err != nil {
+		return 0, fmt.Errorf("failed to simulate transaction: status %d: %s", status, string(bz))
+	}
+
+	if status != http.StatusOK || resp.GasInfo == nil {
+		if resp.Message != "" {
+			return 0, fmt.Errorf("simulation failed: %s", resp.Message)
+		}
+		return 0, fmt.Errorf("failed to simulate transaction: status %d: %s", status, string(bz))
+	}
+
+	return strconv.ParseUint(resp.GasInfo.GasUsed, 10, 64)
+}
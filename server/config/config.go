@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -18,8 +19,13 @@ type Config struct {
 	NUMIA_BEARER_TOKEN NumiaBearerToken `mapstructure:"numiaBearerToken"`
 	MINTSCAN_TOKEN     MintscanToken    `mapstructure:"mintscanToken"`
 	REDIS_URI          string           `mapstructure:"redisUri"`
+	RETENTION_PERIOD   time.Duration    `mapstructure:"retentionPeriod"`
 }
 
+// DefaultRetentionPeriod is how long deleted multisig accounts and transactions are
+// kept, and can be restored, when the config does not set a retention period.
+const DefaultRetentionPeriod = 30 * 24 * time.Hour
+
 type DBConfig struct {
 	Host         string `yaml:"host"`
 	Port         string `yaml:"port"`
@@ -83,6 +89,7 @@ func ParseConfig() (Config, error) {
 				Token: viper.GetString("production.mintscanToken"),
 			}
 			cfg.REDIS_URI = viper.GetString("production.redisUri")
+			cfg.RETENTION_PERIOD = viper.GetDuration("production.retentionPeriod")
 		}
 
 	case "dev":
@@ -107,22 +114,45 @@ func ParseConfig() (Config, error) {
 				Token: viper.GetString("production.mintscanToken"),
 			}
 			cfg.REDIS_URI = viper.GetString("production.redisUri")
+			cfg.RETENTION_PERIOD = viper.GetDuration("dev.retentionPeriod")
 		}
 
 	default:
 		return cfg, errors.New("active can be either dev or production")
 	}
 
+	if cfg.RETENTION_PERIOD <= 0 {
+		cfg.RETENTION_PERIOD = DefaultRetentionPeriod
+	}
+
 	return cfg, nil
 }
 
 type ChainConfig struct {
-	ChainId     string   `json:"chainId"`
-	RestURIs    []string `json:"restURIs"`
-	RestURI     string   `json:"restURI"`
-	RpcURI      string   `json:"rpcURI"`
-	CheckStatus bool     `json:"checkStatus"`
-	SourceEnd   string   `json:"sourceEnd"`
+	ChainId      string     `json:"chainId"`
+	Bech32Prefix string     `json:"bech32Prefix"`
+	Currencies   []Currency `json:"currencies"`
+	GasPrices    []GasPrice `json:"gasPrices"`
+	// AllowedMessageTypes are supported message types without a validator accepted on the chain
+	AllowedMessageTypes []string `json:"allowedMessageTypes"`
+	RestURIs            []string `json:"restURIs"`
+	RestURI             string   `json:"restURI"`
+	RpcURI              string   `json:"rpcURI"`
+	CheckStatus         bool     `json:"checkStatus"`
+	SourceEnd           string   `json:"sourceEnd"`
+}
+
+// Currency maps a base denom to the denom shown to users
+type Currency struct {
+	Denom        string `json:"denom"`
+	DisplayDenom string `json:"displayDenom"`
+	Decimals     int    `json:"decimals"`
+}
+
+// GasPrice is the price of a unit of gas in a fee denom, as a decimal amount
+type GasPrice struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
 }
 
 func GetChainAPIs() []*ChainConfig {
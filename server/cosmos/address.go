@@ -0,0 +1,73 @@
+package cosmos
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/cosmos/btcutil/bech32"
+)
+
+// DecodeAddress decodes a bech32 address into its human readable prefix and raw bytes.
+func DecodeAddress(address string) (string, []byte, error) {
+	prefix, data, err := bech32.DecodeNoLimit(address)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid bech32 address %s: %w", address, err)
+	}
+
+	bz, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid bech32 address %s: %w", address, err)
+	}
+
+	return prefix, bz, nil
+}
+
+// EncodeAddress encodes raw address bytes into a bech32 address with the given prefix.
+func EncodeAddress(prefix string, bz []byte) (string, error) {
+	data, err := bech32.ConvertBits(bz, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	return bech32.Encode(prefix, data)
+}
+
+// ConvertAddress re-encodes a bech32 address with another chain prefix.
+func ConvertAddress(address string, prefix string) (string, error) {
+	_, bz, err := DecodeAddress(address)
+	if err != nil {
+		return "", err
+	}
+
+	return EncodeAddress(prefix, bz)
+}
+
+// SameAccount reports whether both bech32 addresses encode the same account bytes.
+func SameAccount(a string, b string) bool {
+	_, abz, err := DecodeAddress(a)
+	if err != nil {
+		return false
+	}
+
+	_, bbz, err := DecodeAddress(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(abz, bbz)
+}
+
+// VerifyAddress checks that the given bech32 address belongs to the public key,
+// irrespective of the chain prefix used to encode it.
+func VerifyAddress(pk PubKey, address string) error {
+	_, bz, err := DecodeAddress(address)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(bz, pk.Address()) {
+		return fmt.Errorf("address %s does not match the public key", address)
+	}
+
+	return nil
+}
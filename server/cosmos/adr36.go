@@ -0,0 +1,92 @@
+package cosmos
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// StdFee is the amino JSON representation of a transaction fee.
+type StdFee struct {
+	Amount []Coin `json:"amount"`
+	Gas    string `json:"gas"`
+}
+
+// Coin is the amino JSON representation of sdk.Coin.
+type Coin struct {
+	Amount string `json:"amount"`
+	Denom  string `json:"denom"`
+}
+
+// AminoMsg is a message in its amino JSON form.
+type AminoMsg struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// StdSignDoc is the legacy amino JSON sign doc. Fields are declared in
+// alphabetical order so that the encoded JSON is already sorted.
+type StdSignDoc struct {
+	AccountNumber string     `json:"account_number"`
+	ChainID       string     `json:"chain_id"`
+	Fee           StdFee     `json:"fee"`
+	Memo          string     `json:"memo"`
+	Msgs          []AminoMsg `json:"msgs"`
+	Sequence      string     `json:"sequence"`
+	TimeoutHeight string     `json:"timeout_height,omitempty"`
+}
+
+type msgSignData struct {
+	Data   string `json:"data"`
+	Signer string `json:"signer"`
+}
+
+// ADR36SignBytes returns the bytes a wallet signs for arbitrary data as
+// described in ADR-036 (e.g. keplr's signArbitrary).
+func ADR36SignBytes(signer string, data []byte) ([]byte, error) {
+	doc := StdSignDoc{
+		AccountNumber: "0",
+		ChainID:       "",
+		Fee: StdFee{
+			Amount: []Coin{},
+			Gas:    "0",
+		},
+		Memo: "",
+		Msgs: []AminoMsg{
+			{
+				Type: "sign/MsgSignData",
+				Value: msgSignData{
+					Data:   base64.StdEncoding.EncodeToString(data),
+					Signer: signer,
+				},
+			},
+		},
+		Sequence: "0",
+	}
+
+	return json.Marshal(doc)
+}
+
+// VerifyADR36 verifies that signature is a valid ADR-036 signature of data made
+// by signer with the given public key.
+func VerifyADR36(pk PubKey, signer string, data []byte, signature string) error {
+	if err := VerifyAddress(pk, signer); err != nil {
+		return err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.New("invalid signature encoding")
+	}
+
+	signBytes, err := ADR36SignBytes(signer, data)
+	if err != nil {
+		return err
+	}
+
+	if !pk.VerifySignature(signBytes, sig) {
+		return errors.New("signature verification failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package cosmos
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/stretchr/testify/require"
+)
+
+func signADR36(t *testing.T, key *secp256k1.PrivateKey, signer string, data []byte) string {
+	signBytes, err := ADR36SignBytes(signer, data)
+	require.NoError(t, err)
+
+	hash := sha256.Sum256(signBytes)
+	sig := ecdsa.SignCompact(key, hash[:], true)
+
+	return base64.StdEncoding.EncodeToString(sig[1:])
+}
+
+func TestVerifyADR36(t *testing.T) {
+	key := secp256k1.PrivKeyFromBytes([]byte("resolute-test-private-key-000001"))
+	otherKey := secp256k1.PrivKeyFromBytes([]byte("resolute-test-private-key-000002"))
+
+	pk, err := ParsePubKey(Secp256k1AminoType, base64.StdEncoding.EncodeToString(key.PubKey().SerializeCompressed()))
+	require.NoError(t, err)
+
+	signer, err := EncodeAddress("cosmos", pk.Address())
+	require.NoError(t, err)
+
+	otherPk, err := ParsePubKey(Secp256k1TypeUrl, base64.StdEncoding.EncodeToString(otherKey.PubKey().SerializeCompressed()))
+	require.NoError(t, err)
+
+	otherSigner, err := EncodeAddress("cosmos", otherPk.Address())
+	require.NoError(t, err)
+
+	osmoSigner, err := EncodeAddress("osmo", pk.Address())
+	require.NoError(t, err)
+
+	data := []byte("Verify ownership of your address")
+
+	testCases := []struct {
+		name      string
+		pk        PubKey
+		signer    string
+		data      []byte
+		signature string
+		expErr    bool
+	}{
+		{
+			"valid signature",
+			pk,
+			signer,
+			data,
+			signADR36(t, key, signer, data),
+			false,
+		},
+		{
+			"valid signature with a different chain prefix",
+			pk,
+			osmoSigner,
+			data,
+			signADR36(t, key, osmoSigner, data),
+			false,
+		},
+		{
+			"signature over different data",
+			pk,
+			signer,
+			data,
+			signADR36(t, key, signer, []byte("something else")),
+			true,
+		},
+		{
+			"signature from another key",
+			pk,
+			signer,
+			data,
+			signADR36(t, otherKey, signer, data),
+			true,
+		},
+		{
+			"pubkey does not derive to the signer",
+			otherPk,
+			signer,
+			data,
+			signADR36(t, otherKey, signer, data),
+			true,
+		},
+		{
+			"signer is not the signing address",
+			pk,
+			otherSigner,
+			data,
+			signADR36(t, key, signer, data),
+			true,
+		},
+		{
+			"malformed signature",
+			pk,
+			signer,
+			data,
+			"not-a-signature",
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyADR36(tc.pk, tc.signer, tc.data, tc.signature)
+			if tc.expErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParsePubKey(t *testing.T) {
+	key := secp256k1.PrivKeyFromBytes([]byte("resolute-test-private-key-000001"))
+	value := base64.StdEncoding.EncodeToString(key.PubKey().SerializeCompressed())
+
+	_, err := ParsePubKey("/cosmos.crypto.ed25519.PubKey", value)
+	require.Error(t, err)
+
+	_, err = ParsePubKey(Secp256k1AminoType, base64.StdEncoding.EncodeToString([]byte("short")))
+	require.Error(t, err)
+
+	pk, err := ParsePubKey(Secp256k1AminoType, value)
+	require.NoError(t, err)
+	require.Len(t, pk.Address(), 20)
+
+	ethPk, err := ParsePubKey(EthSecp256k1AminoType, value)
+	require.NoError(t, err)
+	require.Len(t, ethPk.Address(), 20)
+	require.NotEqual(t, pk.Address(), ethPk.Address())
+}
+
+func TestPubKeyAddress(t *testing.T) {
+	// the generator point, i.e. the public key of private key 1
+	value := "Anm+Zn753LusVaBilc6HCwcCm/zbLc4o2VnygVsW+BeY"
+
+	pk, err := ParsePubKey(Secp256k1TypeUrl, value)
+	require.NoError(t, err)
+	require.Equal(t, "751e76e8199196d454941c45d1b3a323f1433bd6", hex.EncodeToString(pk.Address()))
+
+	ethPk, err := ParsePubKey(EthSecp256k1TypeUrl, value)
+	require.NoError(t, err)
+	require.Equal(t, "7e5f4552091a69125d5dfcb7b8c2659029395bdf", hex.EncodeToString(ethPk.Address()))
+}
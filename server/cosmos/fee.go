@@ -0,0 +1,94 @@
+package cosmos
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+var gasPriceRegex = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)([a-zA-Z][a-zA-Z0-9/:._-]{2,127})$`)
+
+// GasPrice is the price of a unit of gas in a denom, as a decimal amount.
+type GasPrice struct {
+	Denom  string
+	Amount string
+}
+
+// ParseGasPrice parses a gas price written as an amount followed by a denom, such as
+// 0.025uatom.
+func ParseGasPrice(s string) (GasPrice, error) {
+	m := gasPriceRegex.FindStringSubmatch(s)
+	if m == nil {
+		return GasPrice{}, fmt.Errorf("invalid gas price %s", s)
+	}
+
+	return GasPrice{Denom: m[2], Amount: m[1]}, nil
+}
+
+// Fee returns the fee for the gas limit, rounded up.
+func (p GasPrice) Fee(gas uint64) (Coin, error) {
+	price, ok := new(big.Rat).SetString(p.Amount)
+	if !ok || price.Sign() < 0 {
+		return Coin{}, fmt.Errorf("invalid gas price %s%s", p.Amount, p.Denom)
+	}
+
+	fee := new(big.Rat).Mul(price, new(big.Rat).SetUint64(gas))
+	amount := new(big.Int).Quo(fee.Num(), fee.Denom())
+	if new(big.Rat).SetInt(amount).Cmp(fee) < 0 {
+		amount.Add(amount, big.NewInt(1))
+	}
+
+	return Coin{Amount: amount.String(), Denom: p.Denom}, nil
+}
+
+// GasPrices returns the gas prices paid by a fee.
+func (f StdFee) GasPrices() []GasPrice {
+	gas, err := strconv.ParseUint(f.Gas, 10, 64)
+	if err != nil || gas == 0 {
+		return nil
+	}
+
+	prices := make([]GasPrice, 0, len(f.Amount))
+	for _, coin := range f.Amount {
+		amount, ok := new(big.Rat).SetString(coin.Amount)
+		if !ok {
+			continue
+		}
+
+		price := new(big.Rat).Quo(amount, new(big.Rat).SetUint64(gas))
+		prices = append(prices, GasPrice{Denom: coin.Denom, Amount: price.FloatString(18)})
+	}
+
+	return prices
+}
+
+// AdjustGas multiplies the simulated gas by the adjustment, rounded up.
+func AdjustGas(gasUsed uint64, adjustment float64) uint64 {
+	adjusted := float64(gasUsed) * adjustment
+	gas := uint64(adjusted)
+	if float64(gas) < adjusted {
+		gas++
+	}
+
+	return gas
+}
+
+// CoversFee reports whether the fee pays at least the amount of the given coin.
+func (f StdFee) CoversFee(min Coin) bool {
+	want, ok := new(big.Int).SetString(min.Amount, 10)
+	if !ok {
+		return false
+	}
+
+	for _, coin := range f.Amount {
+		if coin.Denom != min.Denom {
+			continue
+		}
+
+		have, ok := new(big.Int).SetString(coin.Amount, 10)
+		return ok && have.Cmp(want) >= 0
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+package cosmos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasPriceFee(t *testing.T) {
+	testCases := []struct {
+		name   string
+		price  GasPrice
+		gas    uint64
+		exp    string
+		expErr bool
+	}{
+		{"exact", GasPrice{"uosmo", "0.025"}, 200000, "5000", false},
+		{"rounded up", GasPrice{"uosmo", "0.025"}, 200001, "5001", false},
+		{"integer price", GasPrice{"aevmos", "25000000000"}, 100000, "2500000000000000", false},
+		{"zero price", GasPrice{"umars", "0"}, 100000, "0", false},
+		{"invalid price", GasPrice{"uosmo", "abc"}, 100000, "", true},
+		{"negative price", GasPrice{"uosmo", "-1"}, 100000, "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			coin, err := tc.price.Fee(tc.gas)
+			if tc.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, Coin{Amount: tc.exp, Denom: tc.price.Denom}, coin)
+		})
+	}
+}
+
+func TestParseGasPrice(t *testing.T) {
+	price, err := ParseGasPrice("0.025uatom")
+	require.NoError(t, err)
+	require.Equal(t, GasPrice{Denom: "uatom", Amount: "0.025"}, price)
+
+	price, err = ParseGasPrice("25000000000aevmos")
+	require.NoError(t, err)
+	require.Equal(t, GasPrice{Denom: "aevmos", Amount: "25000000000"}, price)
+
+	for _, s := range []string{"", "uatom", "0.025", "-1uatom", "0.025 uatom", ".5uatom"} {
+		_, err := ParseGasPrice(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestStdFeeGasPrices(t *testing.T) {
+	fee := StdFee{Amount: []Coin{{Amount: "5000", Denom: "uosmo"}}, Gas: "200000"}
+	prices := fee.GasPrices()
+	require.Len(t, prices, 1)
+	require.Equal(t, "uosmo", prices[0].Denom)
+
+	coin, err := prices[0].Fee(200000)
+	require.NoError(t, err)
+	require.Equal(t, "5000", coin.Amount)
+
+	require.Nil(t, StdFee{Amount: fee.Amount, Gas: "0"}.GasPrices())
+}
+
+func TestAdjustGas(t *testing.T) {
+	require.Equal(t, uint64(130000), AdjustGas(100000, 1.3))
+	require.Equal(t, uint64(131), AdjustGas(100, 1.305))
+	require.Equal(t, uint64(0), AdjustGas(0, 1.3))
+}
+
+func TestCoversFee(t *testing.T) {
+	fee := StdFee{Amount: []Coin{{Amount: "5000", Denom: "uosmo"}}, Gas: "200000"}
+
+	require.True(t, fee.CoversFee(Coin{Amount: "5000", Denom: "uosmo"}))
+	require.True(t, fee.CoversFee(Coin{Amount: "4999", Denom: "uosmo"}))
+	require.False(t, fee.CoversFee(Coin{Amount: "5001", Denom: "uosmo"}))
+	require.False(t, fee.CoversFee(Coin{Amount: "1", Denom: "uatom"}))
+}
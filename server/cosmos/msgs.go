@@ -0,0 +1,264 @@
+package cosmos
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Kind is the type of a message field.
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindUint64
+	KindInt64
+	KindEnum
+	KindBool
+	KindTimestamp
+	KindJSON
+	KindMessage
+	KindAny
+)
+
+// Field describes a field of a protobuf message by its cosmjs (camelCase) JSON name.
+// The amino JSON name is the snake_case form of Name.
+type Field struct {
+	Name string
+	// Number is the protobuf field number
+	Number   int
+	Kind     Kind
+	Repeated bool
+	Type     *MessageType
+	Enum     map[string]int32
+	// OmitEmpty drops the field from amino JSON when it holds its default value
+	OmitEmpty bool
+}
+
+// MessageType describes a protobuf message supported by the server.
+type MessageType struct {
+	TypeUrl   string
+	AminoType string
+	Fields    []Field
+	// Validate checks the message when it is proposed. Types without a validator are
+	// only accepted on chains that allow them.
+	Validate Validator
+	// Nested types, such as authorizations, are only accepted packed in a field of
+	// another message and never as messages of a transaction
+	Nested bool
+}
+
+// Msg is a transaction message as stored by the server: a type url and the
+// cosmjs JSON form of the message.
+type Msg struct {
+	TypeUrl string
+	Value   map[string]interface{}
+}
+
+var registry = make(map[string]*MessageType)
+
+// RegisterMessageTypes adds message types to the registry.
+func RegisterMessageTypes(types ...*MessageType) {
+	for _, t := range types {
+		registry[t.TypeUrl] = t
+	}
+}
+
+// GetMessageType returns the registered message type of the type url.
+func GetMessageType(typeUrl string) (*MessageType, bool) {
+	t, ok := registry[typeUrl]
+	return t, ok
+}
+
+// ToAmino converts the message into its legacy amino JSON form.
+func (m Msg) ToAmino() (AminoMsg, error) {
+	t, ok := GetMessageType(m.TypeUrl)
+	if !ok {
+		return AminoMsg{}, fmt.Errorf("unsupported message type %s", m.TypeUrl)
+	}
+
+	if t.AminoType == "" {
+		return AminoMsg{}, fmt.Errorf("message type %s has no amino encoding", m.TypeUrl)
+	}
+
+	value, err := t.toAmino(m.Value)
+	if err != nil {
+		return AminoMsg{}, fmt.Errorf("%s: %w", m.TypeUrl, err)
+	}
+
+	return AminoMsg{Type: t.AminoType, Value: value}, nil
+}
+
+func (t *MessageType) toAmino(value map[string]interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		value = map[string]interface{}{}
+	}
+
+	result := make(map[string]interface{})
+	for _, f := range t.Fields {
+		v, found := lookup(value, f.Name)
+
+		if f.Repeated {
+			var items []interface{}
+			if found {
+				list, ok := v.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("field %s: expected list, got %T", f.Name, v)
+				}
+				items = list
+			}
+
+			if len(items) == 0 && f.OmitEmpty {
+				continue
+			}
+
+			converted := make([]interface{}, 0, len(items))
+			for _, item := range items {
+				av, _, err := f.toAmino(item, true)
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %w", f.Name, err)
+				}
+				converted = append(converted, av)
+			}
+			result[snakeCase(f.Name)] = converted
+			continue
+		}
+
+		av, empty, err := f.toAmino(v, found)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		if empty && (f.OmitEmpty || f.Kind == KindTimestamp) {
+			continue
+		}
+
+		result[snakeCase(f.Name)] = av
+	}
+
+	return result, nil
+}
+
+// toAmino converts a single value of the field, reporting whether it is the default value.
+func (f Field) toAmino(v interface{}, found bool) (interface{}, bool, error) {
+	switch f.Kind {
+	case KindString:
+		if !found {
+			return "", true, nil
+		}
+		s, err := toString(v)
+		return s, s == "", err
+	case KindUint64:
+		if !found {
+			return "0", true, nil
+		}
+		n, err := toUint64(v)
+		return fmt.Sprintf("%d", n), n == 0, err
+	case KindInt64:
+		if !found {
+			return "0", true, nil
+		}
+		n, err := toInt64(v)
+		return fmt.Sprintf("%d", n), n == 0, err
+	case KindEnum:
+		if !found {
+			return 0, true, nil
+		}
+		n, err := f.enumValue(v)
+		return n, n == 0, err
+	case KindBool:
+		if !found {
+			return false, true, nil
+		}
+		b, err := toBool(v)
+		return b, !b, err
+	case KindTimestamp:
+		if !found {
+			return nil, true, nil
+		}
+		ts, err := toTime(v)
+		return ts.UTC().Format(time.RFC3339Nano), false, err
+	case KindJSON:
+		if !found {
+			return nil, true, fmt.Errorf("message is required")
+		}
+		bz, err := toJSONBytes(v)
+		if err != nil {
+			return nil, false, err
+		}
+		return json.RawMessage(bz), false, nil
+	case KindMessage:
+		obj := map[string]interface{}{}
+		if found {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false, fmt.Errorf("expected object, got %T", v)
+			}
+			obj = m
+		}
+		av, err := f.Type.toAmino(obj)
+		return av, !found, err
+	case KindAny:
+		if !found {
+			return nil, true, fmt.Errorf("value is required")
+		}
+		msg, err := toMsg(v)
+		if err != nil {
+			return nil, false, err
+		}
+		am, err := msg.ToAmino()
+		return am, false, err
+	default:
+		return nil, false, fmt.Errorf("unknown field kind %d", f.Kind)
+	}
+}
+
+func (f Field) enumValue(v interface{}) (int32, error) {
+	if s, ok := v.(string); ok {
+		if n, ok := f.Enum[s]; ok {
+			return n, nil
+		}
+	}
+
+	n, err := toInt64(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid enum value %v", v)
+	}
+
+	return int32(n), nil
+}
+
+// ParseMsg decodes a message given in the cosmjs ({"typeUrl": ..., "value": {...}})
+// or REST API ({"@type": ..., ...}) JSON form.
+func ParseMsg(v interface{}) (Msg, error) {
+	return toMsg(v)
+}
+
+// toMsg decodes a nested Any given as {"typeUrl": ..., "value": {...}}.
+func toMsg(v interface{}) (Msg, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return Msg{}, fmt.Errorf("expected object, got %T", v)
+	}
+
+	tv, _ := lookup(obj, "typeUrl")
+	if tv == nil {
+		tv, _ = lookup(obj, "@type")
+	}
+
+	typeUrl, ok := tv.(string)
+	if !ok || typeUrl == "" {
+		return Msg{}, fmt.Errorf("type url is required")
+	}
+
+	value, ok := obj["value"].(map[string]interface{})
+	if !ok {
+		// REST API form: fields are inlined next to @type
+		if _, rest := obj["@type"]; !rest {
+			return Msg{}, fmt.Errorf("%s: encoded values are not supported", typeUrl)
+		}
+		value = obj
+	}
+
+	return Msg{TypeUrl: typeUrl, Value: value}, nil
+}
@@ -0,0 +1,89 @@
+package cosmos
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+)
+
+// MultisigAminoType is the amino name of the legacy amino multisig public key.
+const MultisigAminoType = "tendermint/PubKeyMultisigThreshold"
+
+// MultisigTypeUrl is the protobuf type url of the legacy amino multisig public key.
+const MultisigTypeUrl = "/cosmos.crypto.multisig.LegacyAminoPubKey"
+
+// MultisigPubKey is a k-of-n threshold public key (LegacyAminoPubKey in the cosmos-sdk).
+type MultisigPubKey struct {
+	Threshold uint32
+	PubKeys   []PubKey
+}
+
+// NewMultisigPubKey builds a multisig public key, keeping the order of the keys.
+func NewMultisigPubKey(threshold uint32, pubKeys []PubKey) MultisigPubKey {
+	return MultisigPubKey{Threshold: threshold, PubKeys: pubKeys}
+}
+
+// SortPubKeys orders keys by their address, as done by cosmjs and the cosmos-sdk cli
+// when creating a multisig key.
+func SortPubKeys(pubKeys []PubKey) []PubKey {
+	sorted := make([]PubKey, len(pubKeys))
+	copy(sorted, pubKeys)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Address(), sorted[j].Address()) < 0
+	})
+
+	return sorted
+}
+
+// AminoBytes returns the amino binary encoding of the multisig public key.
+func (m MultisigPubKey) AminoBytes() []byte {
+	bz := aminoPrefix(MultisigAminoType)
+
+	// field 1: threshold
+	bz = append(bz, 0x08)
+	bz = appendUvarint(bz, uint64(m.Threshold))
+
+	// field 2: repeated pubkeys
+	for _, pk := range m.PubKeys {
+		pkbz := pk.AminoBytes()
+		bz = append(bz, 0x12)
+		bz = appendUvarint(bz, uint64(len(pkbz)))
+		bz = append(bz, pkbz...)
+	}
+
+	return bz
+}
+
+// Address returns the raw account address of the multisig public key.
+func (m MultisigPubKey) Address() []byte {
+	sum := sha256.Sum256(m.AminoBytes())
+	return sum[:20]
+}
+
+// aminoPrefix computes the 4 byte prefix amino prepends to registered concrete types.
+func aminoPrefix(name string) []byte {
+	hash := sha256.Sum256([]byte(name))
+	bz := hash[:]
+	for bz[0] == 0x00 {
+		bz = bz[1:]
+	}
+
+	// skip the disambiguation bytes
+	bz = bz[3:]
+	for bz[0] == 0x00 {
+		bz = bz[1:]
+	}
+
+	prefix := make([]byte, 4)
+	copy(prefix, bz[:4])
+
+	return prefix
+}
+
+func appendUvarint(bz []byte, v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, v)
+
+	return append(bz, buf[:n]...)
+}
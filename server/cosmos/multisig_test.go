@@ -0,0 +1,41 @@
+package cosmos
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAminoPrefix(t *testing.T) {
+	require.Equal(t, "eb5ae987", hex.EncodeToString(aminoPrefix(Secp256k1AminoType)))
+	require.Equal(t, "22c1f7e2", hex.EncodeToString(aminoPrefix(MultisigAminoType)))
+}
+
+func TestMultisigPubKey(t *testing.T) {
+	var pubKeys []PubKey
+	for _, secret := range []string{"resolute-test-private-key-000001", "resolute-test-private-key-000002", "resolute-test-private-key-000003"} {
+		key := secp256k1.PrivKeyFromBytes([]byte(secret))
+		pk, err := ParsePubKey(Secp256k1AminoType, base64.StdEncoding.EncodeToString(key.PubKey().SerializeCompressed()))
+		require.NoError(t, err)
+		pubKeys = append(pubKeys, pk)
+	}
+
+	multisig := NewMultisigPubKey(2, pubKeys)
+	bz := multisig.AminoBytes()
+
+	// prefix, threshold and three length prefixed amino pubkeys of 38 bytes each
+	require.Equal(t, "22c1f7e20802", hex.EncodeToString(bz[:6]))
+	require.Len(t, bz, 6+3*(2+38))
+	require.Equal(t, "1226eb5ae98721", hex.EncodeToString(bz[6:13]))
+	require.Len(t, multisig.Address(), 20)
+
+	sorted := SortPubKeys(pubKeys)
+	for i := 1; i < len(sorted); i++ {
+		require.True(t, hex.EncodeToString(sorted[i-1].Address()) < hex.EncodeToString(sorted[i].Address()))
+	}
+
+	require.NotEqual(t, multisig.Address(), NewMultisigPubKey(3, pubKeys).Address())
+}
@@ -0,0 +1,174 @@
+package cosmos
+
+import (
+	"fmt"
+)
+
+// protobuf wire types
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func appendTag(bz []byte, number int, wireType int) []byte {
+	return appendUvarint(bz, uint64(number)<<3|uint64(wireType))
+}
+
+func appendVarintField(bz []byte, number int, v uint64) []byte {
+	bz = appendTag(bz, number, wireVarint)
+	return appendUvarint(bz, v)
+}
+
+func appendBytesField(bz []byte, number int, v []byte) []byte {
+	bz = appendTag(bz, number, wireBytes)
+	bz = appendUvarint(bz, uint64(len(v)))
+	return append(bz, v...)
+}
+
+// anyBytes encodes a google.protobuf.Any.
+func anyBytes(typeUrl string, value []byte) []byte {
+	var bz []byte
+	bz = appendBytesField(bz, 1, []byte(typeUrl))
+	if len(value) > 0 {
+		bz = appendBytesField(bz, 2, value)
+	}
+
+	return bz
+}
+
+// Marshal returns the protobuf encoding of the message value.
+func (m Msg) Marshal() ([]byte, error) {
+	t, ok := GetMessageType(m.TypeUrl)
+	if !ok {
+		return nil, fmt.Errorf("unsupported message type %s", m.TypeUrl)
+	}
+
+	bz, err := t.marshal(m.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m.TypeUrl, err)
+	}
+
+	return bz, nil
+}
+
+// AnyBytes returns the message packed into a protobuf Any.
+func (m Msg) AnyBytes() ([]byte, error) {
+	bz, err := m.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return anyBytes(m.TypeUrl, bz), nil
+}
+
+func (t *MessageType) marshal(value map[string]interface{}) ([]byte, error) {
+	var bz []byte
+	for _, f := range t.Fields {
+		v, found := lookup(value, f.Name)
+		if !found {
+			if f.Kind == KindJSON || (f.Kind == KindAny && !f.Repeated) {
+				return nil, fmt.Errorf("field %s is required", f.Name)
+			}
+			continue
+		}
+
+		var err error
+		if f.Repeated {
+			list, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("field %s: expected list, got %T", f.Name, v)
+			}
+
+			for _, item := range list {
+				if bz, err = f.appendProto(bz, item, true); err != nil {
+					return nil, fmt.Errorf("field %s: %w", f.Name, err)
+				}
+			}
+			continue
+		}
+
+		if bz, err = f.appendProto(bz, v, false); err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+
+	return bz, nil
+}
+
+// appendProto encodes a single value of the field. Default scalar values are skipped
+// unless they are elements of a repeated field.
+func (f Field) appendProto(bz []byte, v interface{}, force bool) ([]byte, error) {
+	switch f.Kind {
+	case KindString:
+		s, err := toString(v)
+		if err != nil || (s == "" && !force) {
+			return bz, err
+		}
+		return appendBytesField(bz, f.Number, []byte(s)), nil
+	case KindUint64:
+		n, err := toUint64(v)
+		if err != nil || (n == 0 && !force) {
+			return bz, err
+		}
+		return appendVarintField(bz, f.Number, n), nil
+	case KindInt64:
+		n, err := toInt64(v)
+		if err != nil || (n == 0 && !force) {
+			return bz, err
+		}
+		return appendVarintField(bz, f.Number, uint64(n)), nil
+	case KindEnum:
+		n, err := f.enumValue(v)
+		if err != nil || (n == 0 && !force) {
+			return bz, err
+		}
+		return appendVarintField(bz, f.Number, uint64(int64(n))), nil
+	case KindBool:
+		b, err := toBool(v)
+		if err != nil || (!b && !force) {
+			return bz, err
+		}
+		return appendVarintField(bz, f.Number, 1), nil
+	case KindTimestamp:
+		ts, err := toTime(v)
+		if err != nil {
+			return bz, err
+		}
+		var tbz []byte
+		if ts.Unix() != 0 {
+			tbz = appendVarintField(tbz, 1, uint64(ts.Unix()))
+		}
+		if ts.Nanosecond() != 0 {
+			tbz = appendVarintField(tbz, 2, uint64(ts.Nanosecond()))
+		}
+		return appendBytesField(bz, f.Number, tbz), nil
+	case KindJSON:
+		jbz, err := toJSONBytes(v)
+		if err != nil {
+			return bz, err
+		}
+		return appendBytesField(bz, f.Number, jbz), nil
+	case KindMessage:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return bz, fmt.Errorf("expected object, got %T", v)
+		}
+		mbz, err := f.Type.marshal(obj)
+		if err != nil {
+			return bz, err
+		}
+		return appendBytesField(bz, f.Number, mbz), nil
+	case KindAny:
+		msg, err := toMsg(v)
+		if err != nil {
+			return bz, err
+		}
+		abz, err := msg.AnyBytes()
+		if err != nil {
+			return bz, err
+		}
+		return appendBytesField(bz, f.Number, abz), nil
+	default:
+		return bz, fmt.Errorf("unknown field kind %d", f.Kind)
+	}
+}
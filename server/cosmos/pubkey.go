@@ -0,0 +1,134 @@
+package cosmos
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/ripemd160" // nolint: staticcheck
+	"golang.org/x/crypto/sha3"
+)
+
+// Supported public key types, both in their amino JSON and protobuf forms.
+const (
+	Secp256k1AminoType    = "tendermint/PubKeySecp256k1"
+	Secp256k1TypeUrl      = "/cosmos.crypto.secp256k1.PubKey"
+	EthSecp256k1AminoType = "ethermint/PubKeyEthSecp256k1"
+	EthSecp256k1TypeUrl   = "/ethermint.crypto.v1.ethsecp256k1.PubKey"
+	InjSecp256k1AminoType = "injective/PubKeyEthSecp256k1"
+	InjSecp256k1TypeUrl   = "/injective.crypto.v1beta1.ethsecp256k1.PubKey"
+)
+
+// PubKey is a compressed secp256k1 public key used either with the cosmos
+// (sha256/ripemd160) or the ethermint (keccak256) address and signing scheme.
+type PubKey struct {
+	Key       []byte
+	Eth       bool
+	TypeUrl   string
+	AminoType string
+}
+
+// ParsePubKey decodes a base64 encoded compressed secp256k1 public key of the given type.
+func ParsePubKey(typeUrl string, value string) (PubKey, error) {
+	pk := PubKey{}
+	switch typeUrl {
+	case Secp256k1AminoType, Secp256k1TypeUrl:
+		pk.TypeUrl, pk.AminoType = Secp256k1TypeUrl, Secp256k1AminoType
+	case EthSecp256k1AminoType, EthSecp256k1TypeUrl:
+		pk.TypeUrl, pk.AminoType, pk.Eth = EthSecp256k1TypeUrl, EthSecp256k1AminoType, true
+	case InjSecp256k1AminoType, InjSecp256k1TypeUrl:
+		pk.TypeUrl, pk.AminoType, pk.Eth = InjSecp256k1TypeUrl, InjSecp256k1AminoType, true
+	default:
+		return PubKey{}, fmt.Errorf("unsupported pubkey type %s", typeUrl)
+	}
+
+	bz, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return PubKey{}, fmt.Errorf("invalid pubkey encoding: %w", err)
+	}
+
+	if len(bz) != secp256k1.PubKeyBytesLenCompressed {
+		return PubKey{}, fmt.Errorf("invalid pubkey length %d", len(bz))
+	}
+
+	if _, err := secp256k1.ParsePubKey(bz); err != nil {
+		return PubKey{}, fmt.Errorf("invalid pubkey: %w", err)
+	}
+
+	pk.Key = bz
+
+	return pk, nil
+}
+
+// Address returns the raw account address derived from the public key.
+func (pk PubKey) Address() []byte {
+	if pk.Eth {
+		key, err := secp256k1.ParsePubKey(pk.Key)
+		if err != nil {
+			return nil
+		}
+
+		return keccak256(key.SerializeUncompressed()[1:])[12:]
+	}
+
+	sha := sha256.Sum256(pk.Key)
+	hasher := ripemd160.New()
+	hasher.Write(sha[:])
+
+	return hasher.Sum(nil)
+}
+
+// AminoBytes returns the amino binary encoding of the public key, which is used to
+// derive the address of legacy amino multisig keys.
+func (pk PubKey) AminoBytes() []byte {
+	bz := aminoPrefix(pk.AminoType)
+	bz = appendUvarint(bz, uint64(len(pk.Key)))
+
+	return append(bz, pk.Key...)
+}
+
+// VerifySignature verifies a 64 byte (r || s) signature over msg. Signatures with
+// a high s value are rejected in the same way the cosmos-sdk does.
+func (pk PubKey) VerifySignature(msg []byte, sig []byte) bool {
+	if pk.Eth && len(sig) == 65 {
+		// drop the recovery id appended by ethereum style signers
+		sig = sig[:64]
+	}
+
+	if len(sig) != 64 {
+		return false
+	}
+
+	key, err := secp256k1.ParsePubKey(pk.Key)
+	if err != nil {
+		return false
+	}
+
+	var r, s secp256k1.ModNScalar
+	if r.SetByteSlice(sig[:32]) || s.SetByteSlice(sig[32:]) {
+		return false
+	}
+
+	if r.IsZero() || s.IsZero() || s.IsOverHalfOrder() {
+		return false
+	}
+
+	var hash []byte
+	if pk.Eth {
+		hash = keccak256(msg)
+	} else {
+		sum := sha256.Sum256(msg)
+		hash = sum[:]
+	}
+
+	return ecdsa.NewSignature(&r, &s).Verify(hash, key)
+}
+
+func keccak256(bz []byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(bz)
+
+	return hasher.Sum(nil)
+}
@@ -0,0 +1,218 @@
+package cosmos
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// AminoSignBytes returns the canonical LEGACY_AMINO_JSON sign bytes of a transaction.
+// A zero timeout height is left out of the sign doc.
+func AminoSignBytes(chainID string, accountNumber, sequence uint64, fee StdFee, memo string, timeoutHeight uint64, msgs []Msg) ([]byte, error) {
+	aminoMsgs := make([]AminoMsg, 0, len(msgs))
+	for _, msg := range msgs {
+		am, err := msg.ToAmino()
+		if err != nil {
+			return nil, err
+		}
+		aminoMsgs = append(aminoMsgs, am)
+	}
+
+	if fee.Amount == nil {
+		fee.Amount = []Coin{}
+	}
+
+	doc := StdSignDoc{
+		AccountNumber: strconv.FormatUint(accountNumber, 10),
+		ChainID:       chainID,
+		Fee:           fee,
+		Memo:          memo,
+		Msgs:          aminoMsgs,
+		Sequence:      strconv.FormatUint(sequence, 10),
+	}
+	if timeoutHeight != 0 {
+		doc.TimeoutHeight = strconv.FormatUint(timeoutHeight, 10)
+	}
+
+	bz, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return SortJSON(bz)
+}
+
+// VerifyAminoSignature verifies a base64 encoded signature over the amino sign doc of a
+// transaction. The transaction may be signed for any of the given sequences; the
+// matching sequence is returned.
+func VerifyAminoSignature(pk PubKey, chainID string, accountNumber uint64, sequences []uint64,
+	fee StdFee, memo string, timeoutHeight uint64, msgs []Msg, signature string) (uint64, error) {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return 0, errors.New("invalid signature encoding")
+	}
+
+	if len(sig) != 64 && !(pk.Eth && len(sig) == 65) {
+		return 0, errors.New("invalid signature length")
+	}
+
+	for _, sequence := range sequences {
+		signBytes, err := AminoSignBytes(chainID, accountNumber, sequence, fee, memo, timeoutHeight, msgs)
+		if err != nil {
+			return 0, err
+		}
+
+		if pk.VerifySignature(signBytes, sig) {
+			return sequence, nil
+		}
+	}
+
+	return 0, errors.New("signature does not match the transaction sign doc")
+}
+
+// SignModeLegacyAminoJSON is the value of SIGN_MODE_LEGACY_AMINO_JSON.
+const SignModeLegacyAminoJSON = 127
+
+// AnyBytes returns the public key packed into a protobuf Any.
+func (pk PubKey) AnyBytes() []byte {
+	return anyBytes(pk.TypeUrl, appendBytesField(nil, 1, pk.Key))
+}
+
+// AnyBytes returns the multisig public key packed into a protobuf Any.
+func (m MultisigPubKey) AnyBytes() []byte {
+	var bz []byte
+	if m.Threshold != 0 {
+		bz = appendVarintField(bz, 1, uint64(m.Threshold))
+	}
+	for _, pk := range m.PubKeys {
+		bz = appendBytesField(bz, 2, pk.AnyBytes())
+	}
+
+	return anyBytes(MultisigTypeUrl, bz)
+}
+
+// compactBitArray encodes a cosmos CompactBitArray with the given bits set.
+func compactBitArray(bits []bool) []byte {
+	elems := make([]byte, (len(bits)+7)/8)
+	for i, set := range bits {
+		if set {
+			elems[i/8] |= 1 << (7 - uint(i%8))
+		}
+	}
+
+	var bz []byte
+	if extra := len(bits) % 8; extra != 0 {
+		bz = appendVarintField(bz, 1, uint64(extra))
+	}
+	if len(elems) > 0 {
+		bz = appendBytesField(bz, 2, elems)
+	}
+
+	return bz
+}
+
+func txBodyBytes(msgs []Msg, memo string, timeoutHeight uint64) ([]byte, error) {
+	var bz []byte
+	for _, msg := range msgs {
+		abz, err := msg.AnyBytes()
+		if err != nil {
+			return nil, err
+		}
+		bz = appendBytesField(bz, 1, abz)
+	}
+
+	if memo != "" {
+		bz = appendBytesField(bz, 2, []byte(memo))
+	}
+	if timeoutHeight != 0 {
+		bz = appendVarintField(bz, 3, timeoutHeight)
+	}
+
+	return bz, nil
+}
+
+func feeBytes(fee StdFee) ([]byte, error) {
+	var bz []byte
+	for _, coin := range fee.Amount {
+		var cbz []byte
+		cbz = appendBytesField(cbz, 1, []byte(coin.Denom))
+		cbz = appendBytesField(cbz, 2, []byte(coin.Amount))
+		bz = appendBytesField(bz, 1, cbz)
+	}
+
+	gas, err := strconv.ParseUint(fee.Gas, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid gas limit")
+	}
+	if gas != 0 {
+		bz = appendVarintField(bz, 2, gas)
+	}
+
+	return bz, nil
+}
+
+// MultisigTxBytes assembles the signed TxRaw of a multisig transaction. signatures
+// holds the amino JSON signature of each key of the multisig in order, or nil for keys
+// that did not sign.
+func MultisigTxBytes(pk MultisigPubKey, sequence uint64, fee StdFee, memo string, timeoutHeight uint64, msgs []Msg, signatures [][]byte) ([]byte, error) {
+	if len(signatures) != len(pk.PubKeys) {
+		return nil, errors.New("signatures do not match the multisig keys")
+	}
+
+	bits := make([]bool, len(signatures))
+	var multiSig, modeInfos []byte
+	var count uint32
+	for i, sig := range signatures {
+		if sig == nil {
+			continue
+		}
+		bits[i] = true
+		count++
+		multiSig = appendBytesField(multiSig, 1, sig)
+
+		single := appendVarintField(nil, 1, SignModeLegacyAminoJSON)
+		modeInfos = appendBytesField(modeInfos, 2, appendBytesField(nil, 1, single))
+	}
+
+	if count < pk.Threshold {
+		return nil, fmt.Errorf("%d of %d required signatures", count, pk.Threshold)
+	}
+
+	body, err := txBodyBytes(msgs, memo, timeoutHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	fbz, err := feeBytes(fee)
+	if err != nil {
+		return nil, err
+	}
+
+	// ModeInfo.Multi{bitarray, mode_infos}
+	multi := appendBytesField(nil, 1, compactBitArray(bits))
+	multi = append(multi, modeInfos...)
+	modeInfo := appendBytesField(nil, 2, multi)
+
+	// SignerInfo{public_key, mode_info, sequence}
+	var signerInfo []byte
+	signerInfo = appendBytesField(signerInfo, 1, pk.AnyBytes())
+	signerInfo = appendBytesField(signerInfo, 2, modeInfo)
+	if sequence != 0 {
+		signerInfo = appendVarintField(signerInfo, 3, sequence)
+	}
+
+	// AuthInfo{signer_infos, fee}
+	var authInfo []byte
+	authInfo = appendBytesField(authInfo, 1, signerInfo)
+	authInfo = appendBytesField(authInfo, 2, fbz)
+
+	// TxRaw{body_bytes, auth_info_bytes, signatures}
+	var txRaw []byte
+	txRaw = appendBytesField(txRaw, 1, body)
+	txRaw = appendBytesField(txRaw, 2, authInfo)
+	txRaw = appendBytesField(txRaw, 3, multiSig)
+
+	return txRaw, nil
+}
@@ -0,0 +1,274 @@
+package cosmos
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeMsg(t *testing.T, typeUrl string, value string) Msg {
+	var v map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(value), &v))
+
+	return Msg{TypeUrl: typeUrl, Value: v}
+}
+
+func TestAminoSignBytes(t *testing.T) {
+	fee := StdFee{Amount: []Coin{{Amount: "2500", Denom: "uosmo"}}, Gas: "250000"}
+
+	testCases := []struct {
+		name   string
+		msg    Msg
+		expMsg string
+		expErr bool
+	}{
+		{
+			"bank send",
+			decodeMsg(t, "/cosmos.bank.v1beta1.MsgSend",
+				`{"fromAddress":"osmo1from","toAddress":"osmo1to","amount":[{"denom":"uosmo","amount":"10"}]}`),
+			`{"type":"cosmos-sdk/MsgSend","value":{"amount":[{"amount":"10","denom":"uosmo"}],"from_address":"osmo1from","to_address":"osmo1to"}}`,
+			false,
+		},
+		{
+			"gov vote with enum name and numeric proposal id",
+			decodeMsg(t, "/cosmos.gov.v1beta1.MsgVote",
+				`{"proposalId":42,"voter":"osmo1voter","option":"VOTE_OPTION_NO_WITH_VETO"}`),
+			`{"type":"cosmos-sdk/MsgVote","value":{"option":4,"proposal_id":"42","voter":"osmo1voter"}}`,
+			false,
+		},
+		{
+			"ibc transfer omits empty fields",
+			decodeMsg(t, "/ibc.applications.transfer.v1.MsgTransfer",
+				`{"sourcePort":"transfer","sourceChannel":"channel-0","token":{"denom":"uosmo","amount":"1"},"sender":"osmo1from","receiver":"cosmos1to","timeoutHeight":{"revisionNumber":"1","revisionHeight":"0"},"timeoutTimestamp":"0"}`),
+			`{"type":"cosmos-sdk/MsgTransfer","value":{"receiver":"cosmos1to","sender":"osmo1from","source_channel":"channel-0","source_port":"transfer","timeout_height":{"revision_number":"1"},"token":{"amount":"1","denom":"uosmo"}}}`,
+			false,
+		},
+		{
+			"contract message given as a byte array",
+			decodeMsg(t, "/cosmwasm.wasm.v1.MsgExecuteContract",
+				`{"sender":"osmo1from","contract":"osmo1contract","msg":{"0":123,"1":34,"2":98,"3":34,"4":58,"5":123,"6":125,"7":125},"funds":[]}`),
+			`{"type":"wasm/MsgExecuteContract","value":{"contract":"osmo1contract","funds":[],"msg":{"b":{}},"sender":"osmo1from"}}`,
+			false,
+		},
+		{
+			"authz exec with nested message",
+			decodeMsg(t, "/cosmos.authz.v1beta1.MsgExec",
+				`{"grantee":"osmo1grantee","msgs":[{"typeUrl":"/cosmos.distribution.v1beta1.MsgWithdrawDelegatorReward","value":{"delegatorAddress":"osmo1from","validatorAddress":"osmovaloper1val"}}]}`),
+			`{"type":"cosmos-sdk/MsgExec","value":{"grantee":"osmo1grantee","msgs":[{"type":"cosmos-sdk/MsgWithdrawDelegationReward","value":{"delegator_address":"osmo1from","validator_address":"osmovaloper1val"}}]}}`,
+			false,
+		},
+		{
+			"unsupported message type",
+			decodeMsg(t, "/cosmos.unknown.v1.MsgUnknown", `{}`),
+			"",
+			true,
+		},
+		{
+			"invalid field type",
+			decodeMsg(t, "/cosmos.bank.v1beta1.MsgSend", `{"fromAddress":1}`),
+			"",
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bz, err := AminoSignBytes("osmosis-1", 7, 3, fee, "memo", 0, []Msg{tc.msg})
+			if tc.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, `{"account_number":"7","chain_id":"osmosis-1","fee":{"amount":[{"amount":"2500","denom":"uosmo"}],"gas":"250000"},"memo":"memo","msgs":[`+
+				tc.expMsg+`],"sequence":"3"}`, string(bz))
+		})
+	}
+}
+
+func TestAminoSignBytesTimeoutHeight(t *testing.T) {
+	fee := StdFee{Amount: []Coin{{Amount: "2500", Denom: "uosmo"}}, Gas: "250000"}
+	msgs := []Msg{decodeMsg(t, "/cosmos.bank.v1beta1.MsgSend",
+		`{"fromAddress":"osmo1from","toAddress":"osmo1to","amount":[{"denom":"uosmo","amount":"10"}]}`)}
+
+	bz, err := AminoSignBytes("osmosis-1", 7, 3, fee, "memo", 1200, msgs)
+	require.NoError(t, err)
+	require.Contains(t, string(bz), `"sequence":"3","timeout_height":"1200"}`)
+
+	bz, err = AminoSignBytes("osmosis-1", 7, 3, fee, "memo", 0, msgs)
+	require.NoError(t, err)
+	require.NotContains(t, string(bz), "timeout_height")
+}
+
+func TestVerifyAminoSignature(t *testing.T) {
+	key := secp256k1.PrivKeyFromBytes([]byte("resolute-test-private-key-000001"))
+	pk, err := ParsePubKey(Secp256k1TypeUrl, base64.StdEncoding.EncodeToString(key.PubKey().SerializeCompressed()))
+	require.NoError(t, err)
+
+	fee := StdFee{Amount: []Coin{{Amount: "2500", Denom: "uosmo"}}, Gas: "250000"}
+	msgs := []Msg{decodeMsg(t, "/cosmos.bank.v1beta1.MsgSend",
+		`{"fromAddress":"osmo1from","toAddress":"osmo1to","amount":[{"denom":"uosmo","amount":"10"}]}`)}
+
+	sign := func(sequence uint64, memo string) string {
+		signBytes, err := AminoSignBytes("osmosis-1", 7, sequence, fee, memo, 0, msgs)
+		require.NoError(t, err)
+
+		hash := sha256.Sum256(signBytes)
+		sig := ecdsa.SignCompact(key, hash[:], true)
+		return base64.StdEncoding.EncodeToString(sig[1:])
+	}
+
+	testCases := []struct {
+		name      string
+		signature string
+		expSeq    uint64
+		expErr    bool
+	}{
+		{"current sequence", sign(3, "memo"), 3, false},
+		{"queued sequence", sign(5, "memo"), 5, false},
+		{"sequence out of range", sign(6, "memo"), 0, true},
+		{"different memo", sign(3, "other"), 0, true},
+		{"invalid encoding", "not base64!", 0, true},
+		{"invalid length", base64.StdEncoding.EncodeToString([]byte("short")), 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			seq, err := VerifyAminoSignature(pk, "osmosis-1", 7, []uint64{3, 4, 5}, fee, "memo", 0, msgs, tc.signature)
+			if tc.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expSeq, seq)
+		})
+	}
+}
+
+type protoValue struct {
+	varint uint64
+	bytes  []byte
+}
+
+// decodeProto splits a protobuf message into its fields.
+func decodeProto(t *testing.T, bz []byte) map[int][]protoValue {
+	fields := make(map[int][]protoValue)
+	for len(bz) > 0 {
+		tag, n := binary.Uvarint(bz)
+		require.Greater(t, n, 0)
+		bz = bz[n:]
+
+		v, n := binary.Uvarint(bz)
+		require.Greater(t, n, 0)
+		bz = bz[n:]
+
+		switch tag & 7 {
+		case 0:
+			fields[int(tag>>3)] = append(fields[int(tag>>3)], protoValue{varint: v})
+		case 2:
+			require.GreaterOrEqual(t, uint64(len(bz)), v)
+			fields[int(tag>>3)] = append(fields[int(tag>>3)], protoValue{bytes: bz[:v]})
+			bz = bz[v:]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+	}
+
+	return fields
+}
+
+func TestCompactBitArray(t *testing.T) {
+	require.Equal(t, []byte{0x08, 0x03, 0x12, 0x01, 0xa0}, compactBitArray([]bool{true, false, true}))
+	require.Equal(t, []byte{0x12, 0x02, 0x80, 0x01}, compactBitArray([]bool{true, false, false, false, false, false, false, false,
+		false, false, false, false, false, false, false, true}))
+}
+
+func TestMsgMarshal(t *testing.T) {
+	msg := decodeMsg(t, "/cosmos.bank.v1beta1.MsgSend",
+		`{"fromAddress":"a","toAddress":"b","amount":[{"denom":"uatom","amount":"10"}]}`)
+	bz, err := msg.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, "0a01611201621a0b0a057561746f6d12023130", hex.EncodeToString(bz))
+
+	vote := decodeMsg(t, "/cosmos.gov.v1beta1.MsgVote", `{"proposalId":"5","voter":"a","option":"VOTE_OPTION_YES"}`)
+	bz, err = vote.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, "08051201611801", hex.EncodeToString(bz))
+}
+
+func TestMultisigTxBytes(t *testing.T) {
+	var keys []PubKey
+	for i := 1; i <= 3; i++ {
+		key := secp256k1.PrivKeyFromBytes([]byte(fmt.Sprintf("resolute-test-private-key-00000%d", i)))
+		pk, err := ParsePubKey(Secp256k1TypeUrl, base64.StdEncoding.EncodeToString(key.PubKey().SerializeCompressed()))
+		require.NoError(t, err)
+		keys = append(keys, pk)
+	}
+
+	multisig := NewMultisigPubKey(2, SortPubKeys(keys))
+	fee := StdFee{Amount: []Coin{{Amount: "2500", Denom: "uosmo"}}, Gas: "250000"}
+	msgs := []Msg{decodeMsg(t, "/cosmos.bank.v1beta1.MsgSend",
+		`{"fromAddress":"osmo1from","toAddress":"osmo1to","amount":[{"denom":"uosmo","amount":"10"}]}`)}
+
+	_, err := MultisigTxBytes(multisig, 3, fee, "memo", 0, msgs, [][]byte{{1}, nil, nil})
+	require.Error(t, err)
+
+	_, err = MultisigTxBytes(multisig, 3, fee, "memo", 0, msgs, [][]byte{{1}, nil})
+	require.Error(t, err)
+
+	bz, err := MultisigTxBytes(multisig, 3, fee, "memo", 0, msgs, [][]byte{{1}, nil, {3}})
+	require.NoError(t, err)
+
+	txRaw := decodeProto(t, bz)
+	body := decodeProto(t, txRaw[1][0].bytes)
+	require.Len(t, body[1], 1)
+	require.Equal(t, "memo", string(body[2][0].bytes))
+	require.Empty(t, body[3])
+
+	msgAny := decodeProto(t, body[1][0].bytes)
+	require.Equal(t, "/cosmos.bank.v1beta1.MsgSend", string(msgAny[1][0].bytes))
+
+	authInfo := decodeProto(t, txRaw[2][0].bytes)
+	signerInfo := decodeProto(t, authInfo[1][0].bytes)
+	require.Equal(t, uint64(3), signerInfo[3][0].varint)
+
+	pkAny := decodeProto(t, signerInfo[1][0].bytes)
+	require.Equal(t, MultisigTypeUrl, string(pkAny[1][0].bytes))
+	legacyPk := decodeProto(t, pkAny[2][0].bytes)
+	require.Equal(t, uint64(2), legacyPk[1][0].varint)
+	require.Len(t, legacyPk[2], 3)
+	for i, pkbz := range legacyPk[2] {
+		key := decodeProto(t, pkbz.bytes)
+		require.Equal(t, Secp256k1TypeUrl, string(key[1][0].bytes))
+		require.Equal(t, multisig.PubKeys[i].Key, decodeProto(t, key[2][0].bytes)[1][0].bytes)
+	}
+
+	multi := decodeProto(t, decodeProto(t, signerInfo[2][0].bytes)[2][0].bytes)
+	require.Equal(t, compactBitArray([]bool{true, false, true}), multi[1][0].bytes)
+	require.Len(t, multi[2], 2)
+	for _, modeInfo := range multi[2] {
+		single := decodeProto(t, decodeProto(t, modeInfo.bytes)[1][0].bytes)
+		require.Equal(t, uint64(SignModeLegacyAminoJSON), single[1][0].varint)
+	}
+
+	feeFields := decodeProto(t, authInfo[2][0].bytes)
+	require.Equal(t, uint64(250000), feeFields[2][0].varint)
+
+	require.Len(t, txRaw[3], 1)
+	multiSig := decodeProto(t, txRaw[3][0].bytes)
+	require.Equal(t, []protoValue{{bytes: []byte{1}}, {bytes: []byte{3}}}, multiSig[1])
+
+	bz, err = MultisigTxBytes(multisig, 3, fee, "memo", 1200, msgs, [][]byte{{1}, nil, {3}})
+	require.NoError(t, err)
+	body = decodeProto(t, decodeProto(t, bz)[1][0].bytes)
+	require.Equal(t, uint64(1200), body[3][0].varint)
+}
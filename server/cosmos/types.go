@@ -0,0 +1,331 @@
+package cosmos
+
+// Message types supported by the server. Types without an amino name and the types
+// marked as nested are only used as fields of other messages, and types without a
+// validator have to be allowed per chain.
+var (
+	CoinType = &MessageType{
+		TypeUrl: "/cosmos.base.v1beta1.Coin",
+		Fields: []Field{
+			{Name: "denom", Number: 1, Kind: KindString},
+			{Name: "amount", Number: 2, Kind: KindString},
+		},
+	}
+
+	InputType = &MessageType{
+		TypeUrl: "/cosmos.bank.v1beta1.Input",
+		Fields: []Field{
+			{Name: "address", Number: 1, Kind: KindString},
+			{Name: "coins", Number: 2, Kind: KindMessage, Type: CoinType, Repeated: true},
+		},
+	}
+
+	OutputType = &MessageType{
+		TypeUrl: "/cosmos.bank.v1beta1.Output",
+		Fields: []Field{
+			{Name: "address", Number: 1, Kind: KindString},
+			{Name: "coins", Number: 2, Kind: KindMessage, Type: CoinType, Repeated: true},
+		},
+	}
+
+	HeightType = &MessageType{
+		TypeUrl: "/ibc.core.client.v1.Height",
+		Fields: []Field{
+			{Name: "revisionNumber", Number: 1, Kind: KindUint64, OmitEmpty: true},
+			{Name: "revisionHeight", Number: 2, Kind: KindUint64, OmitEmpty: true},
+		},
+	}
+
+	MsgSendType = &MessageType{
+		TypeUrl:   "/cosmos.bank.v1beta1.MsgSend",
+		AminoType: "cosmos-sdk/MsgSend",
+		Fields: []Field{
+			{Name: "fromAddress", Number: 1, Kind: KindString},
+			{Name: "toAddress", Number: 2, Kind: KindString},
+			{Name: "amount", Number: 3, Kind: KindMessage, Type: CoinType, Repeated: true},
+		},
+		Validate: validateMsgSend,
+	}
+
+	MsgMultiSendType = &MessageType{
+		TypeUrl:   "/cosmos.bank.v1beta1.MsgMultiSend",
+		AminoType: "cosmos-sdk/MsgMultiSend",
+		Fields: []Field{
+			{Name: "inputs", Number: 1, Kind: KindMessage, Type: InputType, Repeated: true},
+			{Name: "outputs", Number: 2, Kind: KindMessage, Type: OutputType, Repeated: true},
+		},
+		Validate: validateMsgMultiSend,
+	}
+
+	MsgDelegateType = &MessageType{
+		TypeUrl:   "/cosmos.staking.v1beta1.MsgDelegate",
+		AminoType: "cosmos-sdk/MsgDelegate",
+		Fields: []Field{
+			{Name: "delegatorAddress", Number: 1, Kind: KindString},
+			{Name: "validatorAddress", Number: 2, Kind: KindString},
+			{Name: "amount", Number: 3, Kind: KindMessage, Type: CoinType},
+		},
+		Validate: validateDelegation,
+	}
+
+	MsgUndelegateType = &MessageType{
+		TypeUrl:   "/cosmos.staking.v1beta1.MsgUndelegate",
+		AminoType: "cosmos-sdk/MsgUndelegate",
+		Fields: []Field{
+			{Name: "delegatorAddress", Number: 1, Kind: KindString},
+			{Name: "validatorAddress", Number: 2, Kind: KindString},
+			{Name: "amount", Number: 3, Kind: KindMessage, Type: CoinType},
+		},
+		Validate: validateDelegation,
+	}
+
+	MsgBeginRedelegateType = &MessageType{
+		TypeUrl:   "/cosmos.staking.v1beta1.MsgBeginRedelegate",
+		AminoType: "cosmos-sdk/MsgBeginRedelegate",
+		Fields: []Field{
+			{Name: "delegatorAddress", Number: 1, Kind: KindString},
+			{Name: "validatorSrcAddress", Number: 2, Kind: KindString},
+			{Name: "validatorDstAddress", Number: 3, Kind: KindString},
+			{Name: "amount", Number: 4, Kind: KindMessage, Type: CoinType},
+		},
+		Validate: validateMsgBeginRedelegate,
+	}
+
+	MsgWithdrawDelegatorRewardType = &MessageType{
+		TypeUrl:   "/cosmos.distribution.v1beta1.MsgWithdrawDelegatorReward",
+		AminoType: "cosmos-sdk/MsgWithdrawDelegationReward",
+		Fields: []Field{
+			{Name: "delegatorAddress", Number: 1, Kind: KindString},
+			{Name: "validatorAddress", Number: 2, Kind: KindString},
+		},
+		Validate: validateMsgWithdrawDelegatorReward,
+	}
+
+	MsgSetWithdrawAddressType = &MessageType{
+		TypeUrl:   "/cosmos.distribution.v1beta1.MsgSetWithdrawAddress",
+		AminoType: "cosmos-sdk/MsgModifyWithdrawAddress",
+		Fields: []Field{
+			{Name: "delegatorAddress", Number: 1, Kind: KindString},
+			{Name: "withdrawAddress", Number: 2, Kind: KindString},
+		},
+	}
+
+	MsgWithdrawValidatorCommissionType = &MessageType{
+		TypeUrl:   "/cosmos.distribution.v1beta1.MsgWithdrawValidatorCommission",
+		AminoType: "cosmos-sdk/MsgWithdrawValCommission",
+		Fields: []Field{
+			{Name: "validatorAddress", Number: 1, Kind: KindString},
+		},
+	}
+
+	MsgFundCommunityPoolType = &MessageType{
+		TypeUrl:   "/cosmos.distribution.v1beta1.MsgFundCommunityPool",
+		AminoType: "cosmos-sdk/MsgFundCommunityPool",
+		Fields: []Field{
+			{Name: "amount", Number: 1, Kind: KindMessage, Type: CoinType, Repeated: true},
+			{Name: "depositor", Number: 2, Kind: KindString},
+		},
+	}
+
+	voteOptions = map[string]int32{
+		"VOTE_OPTION_UNSPECIFIED":  0,
+		"VOTE_OPTION_YES":          1,
+		"VOTE_OPTION_ABSTAIN":      2,
+		"VOTE_OPTION_NO":           3,
+		"VOTE_OPTION_NO_WITH_VETO": 4,
+	}
+
+	MsgVoteType = &MessageType{
+		TypeUrl:   "/cosmos.gov.v1beta1.MsgVote",
+		AminoType: "cosmos-sdk/MsgVote",
+		Fields: []Field{
+			{Name: "proposalId", Number: 1, Kind: KindUint64},
+			{Name: "voter", Number: 2, Kind: KindString},
+			{Name: "option", Number: 3, Kind: KindEnum, Enum: voteOptions},
+		},
+		Validate: validateMsgVote,
+	}
+
+	MsgDepositType = &MessageType{
+		TypeUrl:   "/cosmos.gov.v1beta1.MsgDeposit",
+		AminoType: "cosmos-sdk/MsgDeposit",
+		Fields: []Field{
+			{Name: "proposalId", Number: 1, Kind: KindUint64},
+			{Name: "depositor", Number: 2, Kind: KindString},
+			{Name: "amount", Number: 3, Kind: KindMessage, Type: CoinType, Repeated: true},
+		},
+		Validate: validateMsgDeposit,
+	}
+
+	MsgVoteV1Type = &MessageType{
+		TypeUrl:   "/cosmos.gov.v1.MsgVote",
+		AminoType: "cosmos-sdk/v1/MsgVote",
+		Fields: []Field{
+			{Name: "proposalId", Number: 1, Kind: KindUint64},
+			{Name: "voter", Number: 2, Kind: KindString},
+			{Name: "option", Number: 3, Kind: KindEnum, Enum: voteOptions},
+			{Name: "metadata", Number: 4, Kind: KindString, OmitEmpty: true},
+		},
+		Validate: validateMsgVote,
+	}
+
+	MsgDepositV1Type = &MessageType{
+		TypeUrl:   "/cosmos.gov.v1.MsgDeposit",
+		AminoType: "cosmos-sdk/v1/MsgDeposit",
+		Fields: []Field{
+			{Name: "proposalId", Number: 1, Kind: KindUint64},
+			{Name: "depositor", Number: 2, Kind: KindString},
+			{Name: "amount", Number: 3, Kind: KindMessage, Type: CoinType, Repeated: true},
+		},
+		Validate: validateMsgDeposit,
+	}
+
+	GenericAuthorizationType = &MessageType{
+		TypeUrl:   "/cosmos.authz.v1beta1.GenericAuthorization",
+		AminoType: "cosmos-sdk/GenericAuthorization",
+		Fields: []Field{
+			{Name: "msg", Number: 1, Kind: KindString},
+		},
+		Validate: validateGenericAuthorization,
+		Nested:   true,
+	}
+
+	SendAuthorizationType = &MessageType{
+		TypeUrl:   "/cosmos.bank.v1beta1.SendAuthorization",
+		AminoType: "cosmos-sdk/SendAuthorization",
+		Fields: []Field{
+			{Name: "spendLimit", Number: 1, Kind: KindMessage, Type: CoinType, Repeated: true},
+			{Name: "allowList", Number: 2, Kind: KindString, Repeated: true, OmitEmpty: true},
+		},
+		Validate: validateSendAuthorization,
+		Nested:   true,
+	}
+
+	GrantType = &MessageType{
+		TypeUrl: "/cosmos.authz.v1beta1.Grant",
+		Fields: []Field{
+			{Name: "authorization", Number: 1, Kind: KindAny},
+			{Name: "expiration", Number: 2, Kind: KindTimestamp, OmitEmpty: true},
+		},
+	}
+
+	MsgGrantType = &MessageType{
+		TypeUrl:   "/cosmos.authz.v1beta1.MsgGrant",
+		AminoType: "cosmos-sdk/MsgGrant",
+		Fields: []Field{
+			{Name: "granter", Number: 1, Kind: KindString},
+			{Name: "grantee", Number: 2, Kind: KindString},
+			{Name: "grant", Number: 3, Kind: KindMessage, Type: GrantType},
+		},
+		Validate: validateMsgGrant,
+	}
+
+	MsgExecType = &MessageType{
+		TypeUrl:   "/cosmos.authz.v1beta1.MsgExec",
+		AminoType: "cosmos-sdk/MsgExec",
+		Fields: []Field{
+			{Name: "grantee", Number: 1, Kind: KindString},
+			{Name: "msgs", Number: 2, Kind: KindAny, Repeated: true},
+		},
+		Validate: validateMsgExec,
+	}
+
+	MsgRevokeType = &MessageType{
+		TypeUrl:   "/cosmos.authz.v1beta1.MsgRevoke",
+		AminoType: "cosmos-sdk/MsgRevoke",
+		Fields: []Field{
+			{Name: "granter", Number: 1, Kind: KindString},
+			{Name: "grantee", Number: 2, Kind: KindString},
+			{Name: "msgTypeUrl", Number: 3, Kind: KindString},
+		},
+		Validate: validateMsgRevoke,
+	}
+
+	BasicAllowanceType = &MessageType{
+		TypeUrl:   "/cosmos.feegrant.v1beta1.BasicAllowance",
+		AminoType: "cosmos-sdk/BasicAllowance",
+		Fields: []Field{
+			{Name: "spendLimit", Number: 1, Kind: KindMessage, Type: CoinType, Repeated: true, OmitEmpty: true},
+			{Name: "expiration", Number: 2, Kind: KindTimestamp, OmitEmpty: true},
+		},
+		Validate: validateBasicAllowance,
+		Nested:   true,
+	}
+
+	MsgGrantAllowanceType = &MessageType{
+		TypeUrl:   "/cosmos.feegrant.v1beta1.MsgGrantAllowance",
+		AminoType: "cosmos-sdk/MsgGrantAllowance",
+		Fields: []Field{
+			{Name: "granter", Number: 1, Kind: KindString},
+			{Name: "grantee", Number: 2, Kind: KindString},
+			{Name: "allowance", Number: 3, Kind: KindAny},
+		},
+		Validate: validateMsgGrantAllowance,
+	}
+
+	MsgRevokeAllowanceType = &MessageType{
+		TypeUrl:   "/cosmos.feegrant.v1beta1.MsgRevokeAllowance",
+		AminoType: "cosmos-sdk/MsgRevokeAllowance",
+		Fields: []Field{
+			{Name: "granter", Number: 1, Kind: KindString},
+			{Name: "grantee", Number: 2, Kind: KindString},
+		},
+		Validate: validateGranterGrantee,
+	}
+
+	MsgTransferType = &MessageType{
+		TypeUrl:   "/ibc.applications.transfer.v1.MsgTransfer",
+		AminoType: "cosmos-sdk/MsgTransfer",
+		Fields: []Field{
+			{Name: "sourcePort", Number: 1, Kind: KindString},
+			{Name: "sourceChannel", Number: 2, Kind: KindString},
+			{Name: "token", Number: 3, Kind: KindMessage, Type: CoinType},
+			{Name: "sender", Number: 4, Kind: KindString},
+			{Name: "receiver", Number: 5, Kind: KindString},
+			{Name: "timeoutHeight", Number: 6, Kind: KindMessage, Type: HeightType},
+			{Name: "timeoutTimestamp", Number: 7, Kind: KindUint64, OmitEmpty: true},
+			{Name: "memo", Number: 8, Kind: KindString, OmitEmpty: true},
+		},
+		Validate: validateMsgTransfer,
+	}
+
+	MsgExecuteContractType = &MessageType{
+		TypeUrl:   "/cosmwasm.wasm.v1.MsgExecuteContract",
+		AminoType: "wasm/MsgExecuteContract",
+		Fields: []Field{
+			{Name: "sender", Number: 1, Kind: KindString},
+			{Name: "contract", Number: 2, Kind: KindString},
+			{Name: "msg", Number: 3, Kind: KindJSON},
+			{Name: "funds", Number: 5, Kind: KindMessage, Type: CoinType, Repeated: true},
+		},
+		Validate: validateMsgExecuteContract,
+	}
+)
+
+func init() {
+	RegisterMessageTypes(
+		MsgSendType,
+		MsgMultiSendType,
+		MsgDelegateType,
+		MsgUndelegateType,
+		MsgBeginRedelegateType,
+		MsgWithdrawDelegatorRewardType,
+		MsgSetWithdrawAddressType,
+		MsgWithdrawValidatorCommissionType,
+		MsgFundCommunityPoolType,
+		MsgVoteType,
+		MsgDepositType,
+		MsgVoteV1Type,
+		MsgDepositV1Type,
+		GenericAuthorizationType,
+		SendAuthorizationType,
+		MsgGrantType,
+		MsgExecType,
+		MsgRevokeType,
+		BasicAllowanceType,
+		MsgGrantAllowanceType,
+		MsgRevokeAllowanceType,
+		MsgTransferType,
+		MsgExecuteContractType,
+	)
+}
@@ -0,0 +1,543 @@
+package cosmos
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+)
+
+// ValidateOptions are the chain specific rules messages are validated against.
+type ValidateOptions struct {
+	// Prefix is the bech32 account prefix of the chain
+	Prefix string
+	// AllowedTypes are registered message types without a validator accepted on the chain
+	AllowedTypes []string
+}
+
+// Validator checks the fields of a message.
+type Validator func(v Value, opts ValidateOptions) error
+
+var denomRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9/:._-]{2,127}$`)
+
+// ValidateMsg checks that the message is supported and valid for the chain.
+func ValidateMsg(msg Msg, opts ValidateOptions) error {
+	return validateMsg(msg, opts, false)
+}
+
+// validateMsg checks a message of a transaction, or a message packed in a field of
+// another message when nested is set.
+func validateMsg(msg Msg, opts ValidateOptions, nested bool) error {
+	t, ok := GetMessageType(msg.TypeUrl)
+	if !ok {
+		return fmt.Errorf("unsupported message type %s", msg.TypeUrl)
+	}
+
+	if t.Nested && !nested {
+		return fmt.Errorf("%s can only be used inside another message", msg.TypeUrl)
+	}
+
+	if t.Validate == nil {
+		for _, allowed := range opts.AllowedTypes {
+			if allowed == msg.TypeUrl {
+				return nil
+			}
+		}
+		return fmt.Errorf("message type %s is not allowed on this chain", msg.TypeUrl)
+	}
+
+	if err := t.Validate(Value(msg.Value), opts); err != nil {
+		return fmt.Errorf("%s: %w", msg.TypeUrl, err)
+	}
+
+	return nil
+}
+
+// Value gives typed access to the fields of a message in its cosmjs JSON form.
+type Value map[string]interface{}
+
+// String returns a required string field.
+func (v Value) String(name string) (string, error) {
+	f, found := lookup(v, name)
+	if !found {
+		return "", fmt.Errorf("%s is required", name)
+	}
+
+	s, err := toString(f)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	if s == "" {
+		return "", fmt.Errorf("%s is required", name)
+	}
+
+	return s, nil
+}
+
+// Uint returns a required positive integer field.
+func (v Value) Uint(name string) (uint64, error) {
+	f, found := lookup(v, name)
+	if !found {
+		return 0, fmt.Errorf("%s is required", name)
+	}
+
+	n, err := toUint64(f)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if n == 0 {
+		return 0, fmt.Errorf("%s is required", name)
+	}
+
+	return n, nil
+}
+
+// Address checks that a required field is a bech32 address with the given prefix.
+func (v Value) Address(name string, prefix string) (string, error) {
+	s, err := v.String(name)
+	if err != nil {
+		return "", err
+	}
+
+	p, _, err := DecodeAddress(s)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	if prefix != "" && p != prefix {
+		return "", fmt.Errorf("%s: expected prefix %s, got %s", name, prefix, p)
+	}
+
+	return s, nil
+}
+
+// Object returns a required nested message.
+func (v Value) Object(name string) (Value, error) {
+	f, found := lookup(v, name)
+	if !found {
+		return nil, fmt.Errorf("%s is required", name)
+	}
+
+	obj, ok := f.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected object, got %T", name, f)
+	}
+
+	return Value(obj), nil
+}
+
+// List returns a repeated field.
+func (v Value) List(name string) ([]interface{}, error) {
+	f, found := lookup(v, name)
+	if !found {
+		return nil, nil
+	}
+
+	list, ok := f.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected list, got %T", name, f)
+	}
+
+	return list, nil
+}
+
+// Coin checks that a required field is a coin with a positive amount.
+func (v Value) Coin(name string) error {
+	coin, err := v.Object(name)
+	if err != nil {
+		return err
+	}
+
+	if err := validateCoin(coin); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Coins checks that a field is a list of coins with positive amounts and distinct denoms.
+func (v Value) Coins(name string, required bool) error {
+	list, err := v.List(name)
+	if err != nil {
+		return err
+	}
+
+	if len(list) == 0 && required {
+		return fmt.Errorf("%s is required", name)
+	}
+
+	denoms := make(map[string]bool)
+	for _, item := range list {
+		coin, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected coin, got %T", name, item)
+		}
+
+		if err := validateCoin(coin); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		denom, _ := Value(coin).String("denom")
+		if denoms[denom] {
+			return fmt.Errorf("%s: duplicate denom %s", name, denom)
+		}
+		denoms[denom] = true
+	}
+
+	return nil
+}
+
+func validateCoin(coin Value) error {
+	denom, err := coin.String("denom")
+	if err != nil {
+		return err
+	}
+
+	if !denomRegex.MatchString(denom) {
+		return fmt.Errorf("invalid denom %s", denom)
+	}
+
+	amount, err := coin.String("amount")
+	if err != nil {
+		return err
+	}
+
+	n, ok := new(big.Int).SetString(amount, 10)
+	if !ok || n.Sign() <= 0 {
+		return fmt.Errorf("amount must be a positive integer, got %s", amount)
+	}
+
+	return nil
+}
+
+// validatorPrefix returns the bech32 prefix of validator operator addresses.
+func validatorPrefix(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+
+	return prefix + "valoper"
+}
+
+// any validates a nested message packed in an Any.
+func (v Value) any(name string, opts ValidateOptions) error {
+	f, found := lookup(v, name)
+	if !found {
+		return fmt.Errorf("%s is required", name)
+	}
+
+	msg, err := toMsg(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return validateMsg(msg, opts, true)
+}
+
+func validateMsgSend(v Value, opts ValidateOptions) error {
+	if _, err := v.Address("fromAddress", opts.Prefix); err != nil {
+		return err
+	}
+
+	if _, err := v.Address("toAddress", opts.Prefix); err != nil {
+		return err
+	}
+
+	return v.Coins("amount", true)
+}
+
+func validateMsgMultiSend(v Value, opts ValidateOptions) error {
+	for _, name := range []string{"inputs", "outputs"} {
+		list, err := v.List(name)
+		if err != nil {
+			return err
+		}
+
+		if len(list) == 0 {
+			return fmt.Errorf("%s is required", name)
+		}
+
+		for _, item := range list {
+			io, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: expected object, got %T", name, item)
+			}
+
+			if _, err := Value(io).Address("address", opts.Prefix); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+
+			if err := Value(io).Coins("coins", true); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateDelegation(v Value, opts ValidateOptions) error {
+	if _, err := v.Address("delegatorAddress", opts.Prefix); err != nil {
+		return err
+	}
+
+	if _, err := v.Address("validatorAddress", validatorPrefix(opts.Prefix)); err != nil {
+		return err
+	}
+
+	return v.Coin("amount")
+}
+
+func validateMsgBeginRedelegate(v Value, opts ValidateOptions) error {
+	if _, err := v.Address("delegatorAddress", opts.Prefix); err != nil {
+		return err
+	}
+
+	src, err := v.Address("validatorSrcAddress", validatorPrefix(opts.Prefix))
+	if err != nil {
+		return err
+	}
+
+	dst, err := v.Address("validatorDstAddress", validatorPrefix(opts.Prefix))
+	if err != nil {
+		return err
+	}
+
+	if src == dst {
+		return errors.New("source and destination validators must differ")
+	}
+
+	return v.Coin("amount")
+}
+
+func validateMsgWithdrawDelegatorReward(v Value, opts ValidateOptions) error {
+	if _, err := v.Address("delegatorAddress", opts.Prefix); err != nil {
+		return err
+	}
+
+	_, err := v.Address("validatorAddress", validatorPrefix(opts.Prefix))
+	return err
+}
+
+func validateMsgVote(v Value, opts ValidateOptions) error {
+	if _, err := v.Uint("proposalId"); err != nil {
+		return err
+	}
+
+	if _, err := v.Address("voter", opts.Prefix); err != nil {
+		return err
+	}
+
+	option, found := lookup(v, "option")
+	if !found {
+		return errors.New("option is required")
+	}
+
+	n, err := Field{Enum: voteOptions}.enumValue(option)
+	if err != nil || n < 1 || n > 4 {
+		return fmt.Errorf("invalid vote option %v", option)
+	}
+
+	return nil
+}
+
+func validateMsgDeposit(v Value, opts ValidateOptions) error {
+	if _, err := v.Uint("proposalId"); err != nil {
+		return err
+	}
+
+	if _, err := v.Address("depositor", opts.Prefix); err != nil {
+		return err
+	}
+
+	return v.Coins("amount", true)
+}
+
+func validateGranterGrantee(v Value, opts ValidateOptions) error {
+	granter, err := v.Address("granter", opts.Prefix)
+	if err != nil {
+		return err
+	}
+
+	grantee, err := v.Address("grantee", opts.Prefix)
+	if err != nil {
+		return err
+	}
+
+	if granter == grantee {
+		return errors.New("granter and grantee must differ")
+	}
+
+	return nil
+}
+
+func validateGenericAuthorization(v Value, opts ValidateOptions) error {
+	_, err := v.String("msg")
+	return err
+}
+
+func validateSendAuthorization(v Value, opts ValidateOptions) error {
+	if err := v.Coins("spendLimit", true); err != nil {
+		return err
+	}
+
+	list, err := v.List("allowList")
+	if err != nil {
+		return err
+	}
+
+	for _, item := range list {
+		s, _ := item.(string)
+		if _, err := (Value{"address": s}).Address("address", opts.Prefix); err != nil {
+			return fmt.Errorf("allowList: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func validateMsgGrant(v Value, opts ValidateOptions) error {
+	if err := validateGranterGrantee(v, opts); err != nil {
+		return err
+	}
+
+	grant, err := v.Object("grant")
+	if err != nil {
+		return err
+	}
+
+	if exp, found := lookup(grant, "expiration"); found {
+		if _, err := toTime(exp); err != nil {
+			return fmt.Errorf("expiration: %w", err)
+		}
+	}
+
+	return grant.any("authorization", opts)
+}
+
+func validateMsgExec(v Value, opts ValidateOptions) error {
+	if _, err := v.Address("grantee", opts.Prefix); err != nil {
+		return err
+	}
+
+	msgs, err := v.List("msgs")
+	if err != nil {
+		return err
+	}
+
+	if len(msgs) == 0 {
+		return errors.New("msgs is required")
+	}
+
+	for _, item := range msgs {
+		msg, err := toMsg(item)
+		if err != nil {
+			return fmt.Errorf("msgs: %w", err)
+		}
+
+		if err := ValidateMsg(msg, opts); err != nil {
+			return fmt.Errorf("msgs: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func validateMsgRevoke(v Value, opts ValidateOptions) error {
+	if err := validateGranterGrantee(v, opts); err != nil {
+		return err
+	}
+
+	_, err := v.String("msgTypeUrl")
+	return err
+}
+
+func validateBasicAllowance(v Value, opts ValidateOptions) error {
+	if err := v.Coins("spendLimit", false); err != nil {
+		return err
+	}
+
+	if exp, found := lookup(v, "expiration"); found {
+		if _, err := toTime(exp); err != nil {
+			return fmt.Errorf("expiration: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func validateMsgGrantAllowance(v Value, opts ValidateOptions) error {
+	if err := validateGranterGrantee(v, opts); err != nil {
+		return err
+	}
+
+	return v.any("allowance", opts)
+}
+
+func validateMsgTransfer(v Value, opts ValidateOptions) error {
+	if _, err := v.String("sourcePort"); err != nil {
+		return err
+	}
+
+	if _, err := v.String("sourceChannel"); err != nil {
+		return err
+	}
+
+	if err := v.Coin("token"); err != nil {
+		return err
+	}
+
+	if _, err := v.Address("sender", opts.Prefix); err != nil {
+		return err
+	}
+
+	// the receiver belongs to the counterparty chain
+	if _, err := v.Address("receiver", ""); err != nil {
+		return err
+	}
+
+	var height, timestamp uint64
+	if h, found := lookup(v, "timeoutHeight"); found {
+		if obj, ok := h.(map[string]interface{}); ok {
+			if rh, found := lookup(obj, "revisionHeight"); found {
+				height, _ = toUint64(rh)
+			}
+		}
+	}
+
+	if ts, found := lookup(v, "timeoutTimestamp"); found {
+		var err error
+		if timestamp, err = toUint64(ts); err != nil {
+			return fmt.Errorf("timeoutTimestamp: %w", err)
+		}
+	}
+
+	if height == 0 && timestamp == 0 {
+		return errors.New("timeoutHeight or timeoutTimestamp is required")
+	}
+
+	return nil
+}
+
+func validateMsgExecuteContract(v Value, opts ValidateOptions) error {
+	if _, err := v.Address("sender", opts.Prefix); err != nil {
+		return err
+	}
+
+	if _, err := v.Address("contract", opts.Prefix); err != nil {
+		return err
+	}
+
+	msg, found := lookup(v, "msg")
+	if !found {
+		return errors.New("msg is required")
+	}
+
+	if _, err := toJSONBytes(msg); err != nil {
+		return fmt.Errorf("msg: %w", err)
+	}
+
+	return v.Coins("funds", false)
+}
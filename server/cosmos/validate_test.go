@@ -0,0 +1,221 @@
+package cosmos
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMsg(t *testing.T) {
+	address := func(prefix string, b byte) string {
+		a, err := EncodeAddress(prefix, bytes.Repeat([]byte{b}, 20))
+		require.NoError(t, err)
+		return a
+	}
+
+	from := address("osmo", 1)
+	to := address("osmo", 2)
+	cosmosAddr := address("cosmos", 2)
+	val1 := address("osmovaloper", 3)
+	val2 := address("osmovaloper", 4)
+
+	opts := ValidateOptions{Prefix: "osmo"}
+
+	testCases := []struct {
+		name    string
+		typeUrl string
+		value   string
+		opts    ValidateOptions
+		expErr  string
+	}{
+		{
+			"valid send",
+			"/cosmos.bank.v1beta1.MsgSend",
+			`{"fromAddress":"` + from + `","toAddress":"` + to + `","amount":[{"denom":"uosmo","amount":"10"}]}`,
+			opts,
+			"",
+		},
+		{
+			"send to another chain prefix",
+			"/cosmos.bank.v1beta1.MsgSend",
+			`{"fromAddress":"` + from + `","toAddress":"` + cosmosAddr + `","amount":[{"denom":"uosmo","amount":"10"}]}`,
+			opts,
+			"toAddress: expected prefix osmo, got cosmos",
+		},
+		{
+			"send zero amount",
+			"/cosmos.bank.v1beta1.MsgSend",
+			`{"fromAddress":"` + from + `","toAddress":"` + to + `","amount":[{"denom":"uosmo","amount":"0"}]}`,
+			opts,
+			"amount: amount must be a positive integer, got 0",
+		},
+		{
+			"send invalid denom",
+			"/cosmos.bank.v1beta1.MsgSend",
+			`{"fromAddress":"` + from + `","toAddress":"` + to + `","amount":[{"denom":"1x","amount":"5"}]}`,
+			opts,
+			"amount: invalid denom 1x",
+		},
+		{
+			"send without amount",
+			"/cosmos.bank.v1beta1.MsgSend",
+			`{"fromAddress":"` + from + `","toAddress":"` + to + `","amount":[]}`,
+			opts,
+			"amount is required",
+		},
+		{
+			"valid delegate",
+			"/cosmos.staking.v1beta1.MsgDelegate",
+			`{"delegatorAddress":"` + from + `","validatorAddress":"` + val1 + `","amount":{"denom":"uosmo","amount":"10"}}`,
+			opts,
+			"",
+		},
+		{
+			"delegate to an account address",
+			"/cosmos.staking.v1beta1.MsgDelegate",
+			`{"delegatorAddress":"` + from + `","validatorAddress":"` + to + `","amount":{"denom":"uosmo","amount":"10"}}`,
+			opts,
+			"validatorAddress: expected prefix osmovaloper, got osmo",
+		},
+		{
+			"redelegate to the same validator",
+			"/cosmos.staking.v1beta1.MsgBeginRedelegate",
+			`{"delegatorAddress":"` + from + `","validatorSrcAddress":"` + val1 + `","validatorDstAddress":"` + val1 + `","amount":{"denom":"uosmo","amount":"10"}}`,
+			opts,
+			"source and destination validators must differ",
+		},
+		{
+			"valid redelegate",
+			"/cosmos.staking.v1beta1.MsgBeginRedelegate",
+			`{"delegatorAddress":"` + from + `","validatorSrcAddress":"` + val1 + `","validatorDstAddress":"` + val2 + `","amount":{"denom":"uosmo","amount":"10"}}`,
+			opts,
+			"",
+		},
+		{
+			"vote with invalid option",
+			"/cosmos.gov.v1beta1.MsgVote",
+			`{"proposalId":"1","voter":"` + from + `","option":7}`,
+			opts,
+			"invalid vote option 7",
+		},
+		{
+			"vote without proposal",
+			"/cosmos.gov.v1.MsgVote",
+			`{"voter":"` + from + `","option":"VOTE_OPTION_YES"}`,
+			opts,
+			"proposalId is required",
+		},
+		{
+			"exec with an invalid nested message",
+			"/cosmos.authz.v1beta1.MsgExec",
+			`{"grantee":"` + from + `","msgs":[{"typeUrl":"/cosmos.bank.v1beta1.MsgSend","value":{"fromAddress":"` + to + `","toAddress":"` + cosmosAddr + `","amount":[{"denom":"uosmo","amount":"1"}]}}]}`,
+			opts,
+			"toAddress: expected prefix osmo, got cosmos",
+		},
+		{
+			"grant generic authorization",
+			"/cosmos.authz.v1beta1.MsgGrant",
+			`{"granter":"` + from + `","grantee":"` + to + `","grant":{"authorization":{"typeUrl":"/cosmos.authz.v1beta1.GenericAuthorization","value":{"msg":"/cosmos.gov.v1beta1.MsgVote"}},"expiration":"2030-01-01T00:00:00Z"}}`,
+			opts,
+			"",
+		},
+		{
+			"grant to self",
+			"/cosmos.authz.v1beta1.MsgGrant",
+			`{"granter":"` + from + `","grantee":"` + from + `","grant":{"authorization":{"typeUrl":"/cosmos.authz.v1beta1.GenericAuthorization","value":{"msg":"/cosmos.gov.v1beta1.MsgVote"}}}}`,
+			opts,
+			"granter and grantee must differ",
+		},
+		{
+			"authorization as a message",
+			"/cosmos.authz.v1beta1.GenericAuthorization",
+			`{"msg":"/cosmos.gov.v1beta1.MsgVote"}`,
+			ValidateOptions{Prefix: "osmo", AllowedTypes: []string{"/cosmos.authz.v1beta1.GenericAuthorization"}},
+			"/cosmos.authz.v1beta1.GenericAuthorization can only be used inside another message",
+		},
+		{
+			"send authorization as a message",
+			"/cosmos.bank.v1beta1.SendAuthorization",
+			`{"spendLimit":[{"denom":"uosmo","amount":"10"}]}`,
+			opts,
+			"/cosmos.bank.v1beta1.SendAuthorization can only be used inside another message",
+		},
+		{
+			"allowance as a message",
+			"/cosmos.feegrant.v1beta1.BasicAllowance",
+			`{"spendLimit":[{"denom":"uosmo","amount":"10"}]}`,
+			opts,
+			"/cosmos.feegrant.v1beta1.BasicAllowance can only be used inside another message",
+		},
+		{
+			"grant allowance",
+			"/cosmos.feegrant.v1beta1.MsgGrantAllowance",
+			`{"granter":"` + from + `","grantee":"` + to + `","allowance":{"typeUrl":"/cosmos.feegrant.v1beta1.BasicAllowance","value":{"spendLimit":[{"denom":"uosmo","amount":"10"}]}}}`,
+			opts,
+			"",
+		},
+		{
+			"exec of an authorization",
+			"/cosmos.authz.v1beta1.MsgExec",
+			`{"grantee":"` + from + `","msgs":[{"typeUrl":"/cosmos.authz.v1beta1.GenericAuthorization","value":{"msg":"/cosmos.gov.v1beta1.MsgVote"}}]}`,
+			opts,
+			"can only be used inside another message",
+		},
+		{
+			"ibc transfer to another chain",
+			"/ibc.applications.transfer.v1.MsgTransfer",
+			`{"sourcePort":"transfer","sourceChannel":"channel-0","token":{"denom":"ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2","amount":"1"},"sender":"` + from + `","receiver":"` + cosmosAddr + `","timeoutTimestamp":"1700000000000000000"}`,
+			opts,
+			"",
+		},
+		{
+			"ibc transfer without timeout",
+			"/ibc.applications.transfer.v1.MsgTransfer",
+			`{"sourcePort":"transfer","sourceChannel":"channel-0","token":{"denom":"uosmo","amount":"1"},"sender":"` + from + `","receiver":"` + cosmosAddr + `"}`,
+			opts,
+			"timeoutHeight or timeoutTimestamp is required",
+		},
+		{
+			"execute contract with invalid message",
+			"/cosmwasm.wasm.v1.MsgExecuteContract",
+			`{"sender":"` + from + `","contract":"` + to + `","msg":"not json","funds":[]}`,
+			opts,
+			"msg: invalid json message",
+		},
+		{
+			"unknown type",
+			"/cosmos.unknown.v1.MsgUnknown",
+			`{}`,
+			opts,
+			"unsupported message type /cosmos.unknown.v1.MsgUnknown",
+		},
+		{
+			"type without validator",
+			"/cosmos.distribution.v1beta1.MsgFundCommunityPool",
+			`{"depositor":"` + from + `","amount":[{"denom":"uosmo","amount":"1"}]}`,
+			opts,
+			"message type /cosmos.distribution.v1beta1.MsgFundCommunityPool is not allowed on this chain",
+		},
+		{
+			"type without validator allowed on the chain",
+			"/cosmos.distribution.v1beta1.MsgFundCommunityPool",
+			`{"depositor":"` + from + `","amount":[{"denom":"uosmo","amount":"1"}]}`,
+			ValidateOptions{Prefix: "osmo", AllowedTypes: []string{"/cosmos.distribution.v1beta1.MsgFundCommunityPool"}},
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateMsg(decodeMsg(t, tc.typeUrl, tc.value), tc.opts)
+			if tc.expErr == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expErr)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package cosmos
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lookup returns the field of a decoded JSON object, accepting both the camelCase
+// names used by cosmjs and the snake_case names used by amino and the REST API.
+func lookup(value map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := value[name]; ok && v != nil {
+		return v, true
+	}
+
+	if v, ok := value[snakeCase(name)]; ok && v != nil {
+		return v, true
+	}
+
+	return nil, false
+}
+
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r + ('a' - 'A'))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+func toString(v interface{}) (string, error) {
+	switch s := v.(type) {
+	case string:
+		return s, nil
+	default:
+		return "", fmt.Errorf("expected string, got %T", v)
+	}
+}
+
+func toUint64(v interface{}) (uint64, error) {
+	switch n := v.(type) {
+	case string:
+		if n == "" {
+			return 0, nil
+		}
+		return strconv.ParseUint(n, 10, 64)
+	case float64:
+		if n < 0 || n != float64(uint64(n)) {
+			return 0, fmt.Errorf("invalid unsigned integer %v", n)
+		}
+		return uint64(n), nil
+	case json.Number:
+		return strconv.ParseUint(n.String(), 10, 64)
+	default:
+		return 0, fmt.Errorf("expected unsigned integer, got %T", v)
+	}
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case string:
+		if n == "" {
+			return 0, nil
+		}
+		return strconv.ParseInt(n, 10, 64)
+	case float64:
+		if n != float64(int64(n)) {
+			return 0, fmt.Errorf("invalid integer %v", n)
+		}
+		return int64(n), nil
+	case json.Number:
+		return strconv.ParseInt(n.String(), 10, 64)
+	default:
+		return 0, fmt.Errorf("expected integer, got %T", v)
+	}
+}
+
+func toBool(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		return strconv.ParseBool(b)
+	default:
+		return false, fmt.Errorf("expected boolean, got %T", v)
+	}
+}
+
+func toTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case string:
+		return time.Parse(time.RFC3339Nano, t)
+	case map[string]interface{}:
+		var seconds, nanos int64
+		var err error
+		if s, ok := lookup(t, "seconds"); ok {
+			if seconds, err = toInt64(s); err != nil {
+				return time.Time{}, err
+			}
+		}
+		if n, ok := lookup(t, "nanos"); ok {
+			if nanos, err = toInt64(n); err != nil {
+				return time.Time{}, err
+			}
+		}
+		return time.Unix(seconds, nanos).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("expected timestamp, got %T", v)
+	}
+}
+
+// ContractMsg returns the JSON bytes of a contract message in any of the forms
+// accepted by toJSONBytes.
+func ContractMsg(v interface{}) ([]byte, error) {
+	return toJSONBytes(v)
+}
+
+// toJSONBytes normalizes a contract message which may be sent as a JSON object, a
+// JSON or base64 string, or a serialized Uint8Array ({"0": 123, ...}).
+func toJSONBytes(v interface{}) ([]byte, error) {
+	switch m := v.(type) {
+	case string:
+		if json.Valid([]byte(m)) {
+			return []byte(m), nil
+		}
+
+		bz, err := base64.StdEncoding.DecodeString(m)
+		if err != nil || !json.Valid(bz) {
+			return nil, fmt.Errorf("invalid json message")
+		}
+		return bz, nil
+	case map[string]interface{}:
+		if isByteMap(m) {
+			bz := make([]byte, len(m))
+			for k, b := range m {
+				i, _ := strconv.Atoi(k)
+				n, err := toUint64(b)
+				if err != nil || n > 255 {
+					return nil, fmt.Errorf("invalid byte array")
+				}
+				bz[i] = byte(n)
+			}
+			if !json.Valid(bz) {
+				return nil, fmt.Errorf("invalid json message")
+			}
+			return bz, nil
+		}
+		return json.Marshal(m)
+	case []interface{}:
+		bz := make([]byte, len(m))
+		for i, b := range m {
+			n, err := toUint64(b)
+			if err != nil || n > 255 {
+				return nil, fmt.Errorf("invalid byte array")
+			}
+			bz[i] = byte(n)
+		}
+		if !json.Valid(bz) {
+			return nil, fmt.Errorf("invalid json message")
+		}
+		return bz, nil
+	default:
+		return nil, fmt.Errorf("expected json message, got %T", v)
+	}
+}
+
+func isByteMap(m map[string]interface{}) bool {
+	if len(m) == 0 {
+		return false
+	}
+
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil {
+			return false
+		}
+		keys = append(keys, i)
+	}
+	sort.Ints(keys)
+
+	return keys[0] == 0 && keys[len(keys)-1] == len(keys)-1
+}
+
+// SortJSON returns the canonical form of a JSON document with sorted object keys, as
+// required by amino JSON sign docs.
+func SortJSON(bz []byte) ([]byte, error) {
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(string(bz)))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
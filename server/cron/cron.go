@@ -19,13 +19,14 @@ const COINGECKO_API = "https://api.coingecko.com/api/v3/simple/price"
 
 // Cron wraps all required parameters to create cron jobs
 type Cron struct {
-	cfg config.Config
-	db  *sql.DB
+	cfg      config.Config
+	db       *sql.DB
+	proposer Proposer
 }
 
 // NewCron sets necessary config and clients to begin jobs
-func NewCron(cfg config.Config, db *sql.DB) *Cron {
-	return &Cron{cfg, db}
+func NewCron(cfg config.Config, db *sql.DB, proposer Proposer) *Cron {
+	return &Cron{cfg, db, proposer}
 }
 
 // Start starts to create cron jobs which fetches chosen asset list information and
@@ -47,6 +48,31 @@ func (c *Cron) Start() error {
 		log.Println("successfully saved chain information list")
 	})
 
+	// Every minute
+	cron.AddFunc("0 * * * * *", func() {
+		go c.TrackBroadcastedTxs()
+	})
+
+	// Every 5 minutes
+	cron.AddFunc("0 */5 * * * *", func() {
+		go c.MarkStaleTxs()
+	})
+
+	// Every minute
+	cron.AddFunc("0 * * * * *", func() {
+		go c.ExpireTxs()
+	})
+
+	// Every minute
+	cron.AddFunc("0 * * * * *", func() {
+		go c.ProposeScheduledTxs()
+	})
+
+	// Every day
+	cron.AddFunc("0 0 0 * * *", func() {
+		go c.PurgeDeleted()
+	})
+
 	go cron.Start()
 
 	return nil
@@ -0,0 +1,76 @@
+package cron
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// ExpireTxs marks the pending and stale transactions past their expiry time as EXPIRED.
+func (c *Cron) ExpireTxs() {
+	rows, err := c.db.Query(`SELECT id FROM transactions WHERE status IN ($1,$2) AND expires_at<=$3 AND deleted_at IS NULL`,
+		model.Pending, model.Stale, time.Now().UTC())
+	if err != nil {
+		utils.ErrorLogger.Printf("failed to fetch expired transactions %s\n", err.Error())
+		return
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			utils.ErrorLogger.Printf("failed to decode expired transaction %s\n", err.Error())
+			continue
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := c.inTx(func(tx *sql.Tx) error {
+			return expireTx(tx, id)
+		}); err != nil {
+			utils.ErrorLogger.Printf("failed to expire transaction %d %s\n", id, err.Error())
+		}
+	}
+}
+
+// expireTx marks a transaction as EXPIRED unless it changed since it was selected.
+// The transactions queued after an expired pending transaction take over its sequence.
+func expireTx(tx *sql.Tx, id int) error {
+	var (
+		address, status string
+		sequence        sql.NullInt64
+	)
+	err := tx.QueryRow(`UPDATE transactions t SET status=$1,last_updated=$2
+	FROM (SELECT id,status,sequence FROM transactions WHERE id=$3 FOR UPDATE) old
+	WHERE t.id=old.id AND t.status IN ($4,$5) AND t.expires_at<=$2 AND t.deleted_at IS NULL
+	RETURNING t.multisig_address,old.status,old.sequence`,
+		model.Expired, time.Now().UTC(), id, model.Pending, model.Stale).Scan(&address, &status, &sequence)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := audit.Record(tx, schema.AuditEvent{
+		MultisigAddress: address,
+		TxID:            audit.TxID(id),
+		Action:          audit.StatusChanged,
+		OldValue:        audit.Status(status),
+		NewValue:        audit.Status(model.Expired),
+	}); err != nil {
+		return err
+	}
+
+	if status == string(model.Pending) && sequence.Valid {
+		return ReleaseSequence(tx, address, uint64(sequence.Int64), audit.SystemActor)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package cron
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// PurgeDeleted permanently removes the multisig accounts and transactions deleted before
+// the retention period. Their audit events are kept.
+func (c *Cron) PurgeDeleted() {
+	cutoff := time.Now().UTC().Add(-c.cfg.RETENTION_PERIOD)
+
+	if err := c.inTx(func(tx *sql.Tx) error {
+		return purgeTxs(tx, cutoff)
+	}); err != nil {
+		utils.ErrorLogger.Printf("failed to purge deleted transactions %s\n", err.Error())
+	}
+
+	rows, err := c.db.Query(`SELECT address FROM multisig_accounts WHERE deleted_at<$1`, cutoff)
+	if err != nil {
+		utils.ErrorLogger.Printf("failed to query deleted multisig accounts %s\n", err.Error())
+		return
+	}
+
+	var addresses []string
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			utils.ErrorLogger.Printf("failed to decode deleted multisig account %s\n", err.Error())
+			continue
+		}
+		addresses = append(addresses, address)
+	}
+	rows.Close()
+
+	for _, address := range addresses {
+		if err := c.inTx(func(tx *sql.Tx) error {
+			return purgeAccount(tx, address, cutoff)
+		}); err != nil {
+			utils.ErrorLogger.Printf("failed to purge multisig account %s %s\n", address, err.Error())
+		}
+	}
+}
+
+func purgeTxs(tx *sql.Tx, cutoff time.Time) error {
+	rows, err := tx.Query(`DELETE FROM transactions WHERE deleted_at<$1 RETURNING id,multisig_address,status`, cutoff)
+	if err != nil {
+		return err
+	}
+
+	var events []schema.AuditEvent
+	for rows.Next() {
+		var (
+			id              int
+			address, status string
+		)
+		if err := rows.Scan(&id, &address, &status); err != nil {
+			rows.Close()
+			return err
+		}
+
+		events = append(events, schema.AuditEvent{
+			MultisigAddress: address,
+			TxID:            audit.TxID(id),
+			Action:          audit.TxPurged,
+			OldValue:        audit.Status(status),
+		})
+	}
+	rows.Close()
+
+	for _, event := range events {
+		if err := audit.Record(tx, event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func purgeAccount(tx *sql.Tx, address string, cutoff time.Time) error {
+	// the account may have been restored in the meantime
+	var locked string
+	err := tx.QueryRow(`SELECT address FROM multisig_accounts WHERE address=$1 AND deleted_at<$2 FOR UPDATE`, address, cutoff).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM transactions WHERE multisig_address=$1`, address)
+	if err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		`DELETE FROM tx_schedules WHERE multisig_address=$1`,
+		`DELETE FROM tx_templates WHERE multisig_address=$1`,
+		`DELETE FROM address_book WHERE multisig_address=$1`,
+		`DELETE FROM policy_changes WHERE multisig_address=$1`,
+		`DELETE FROM multisig_policies WHERE multisig_address=$1`,
+		`DELETE FROM multisig_roles WHERE multisig_address=$1`,
+		`DELETE FROM pubkeys WHERE multisig_address=$1`,
+		`DELETE FROM multisig_accounts WHERE address=$1`,
+	} {
+		if _, err := tx.Exec(query, address); err != nil {
+			return err
+		}
+	}
+
+	txs, _ := result.RowsAffected()
+	return audit.Record(tx, schema.AuditEvent{
+		MultisigAddress: address,
+		Action:          audit.AccountPurged,
+		OldValue:        audit.Value(map[string]interface{}{"transactions": txs}),
+	})
+}
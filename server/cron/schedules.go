@@ -0,0 +1,45 @@
+package cron
+
+import (
+	"time"
+
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// Proposer proposes the transactions of the recurring schedules.
+type Proposer interface {
+	// ProposeScheduled proposes the transaction of a schedule when it is due.
+	ProposeScheduled(scheduleId int) error
+}
+
+// ProposeScheduledTxs proposes the transactions of the due schedules of the multisig
+// accounts which are not deleted.
+func (c *Cron) ProposeScheduledTxs() {
+	if c.proposer == nil {
+		return
+	}
+
+	rows, err := c.db.Query(`SELECT s.id FROM tx_schedules s JOIN multisig_accounts m ON m.address=s.multisig_address
+	WHERE s.active AND s.next_run_at<=$1 AND m.deleted_at IS NULL ORDER BY s.next_run_at`, time.Now().UTC())
+	if err != nil {
+		utils.ErrorLogger.Printf("failed to fetch due schedules %s\n", err.Error())
+		return
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			utils.ErrorLogger.Printf("failed to decode due schedule %s\n", err.Error())
+			continue
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := c.proposer.ProposeScheduled(id); err != nil {
+			utils.ErrorLogger.Printf("failed to propose scheduled transaction %d %s\n", id, err.Error())
+		}
+	}
+}
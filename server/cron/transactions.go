@@ -0,0 +1,258 @@
+package cron
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/clients"
+	"github.com/vitwit/resolute/server/events"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// TrackBroadcastedTxs checks broadcasted transactions on chain and records whether
+// they succeeded once they are included in a block.
+func (c *Cron) TrackBroadcastedTxs() {
+	rows, err := c.db.Query(`SELECT t.id,t.hash,t.last_updated,m.chain_id FROM transactions t
+	JOIN multisig_accounts m ON t.multisig_address = m.address WHERE t.status=$1`, model.Broadcasting)
+	if err != nil {
+		utils.ErrorLogger.Printf("failed to fetch broadcasted transactions %s\n", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	type broadcastedTx struct {
+		id          int
+		hash        sql.NullString
+		lastUpdated time.Time
+		chainId     string
+	}
+
+	var txs []broadcastedTx
+	for rows.Next() {
+		var tx broadcastedTx
+		if err := rows.Scan(&tx.id, &tx.hash, &tx.lastUpdated, &tx.chainId); err != nil {
+			utils.ErrorLogger.Printf("failed to decode broadcasted transaction %s\n", err.Error())
+			continue
+		}
+		txs = append(txs, tx)
+	}
+
+	for _, tx := range txs {
+		expired := time.Since(tx.lastUpdated) > utils.BROADCAST_TIMEOUT
+
+		// the server stopped before recording the hash, so the transaction can be broadcast again
+		if tx.hash.String == "" {
+			if expired {
+				c.updateBroadcastedTx(tx.id, model.Pending, "", false)
+			}
+			continue
+		}
+
+		resp, err := clients.GetTx(tx.chainId, tx.hash.String)
+		if err != nil {
+			utils.ErrorLogger.Printf("failed to fetch transaction %s %s\n", tx.hash.String, err.Error())
+			continue
+		}
+
+		switch {
+		case resp == nil && expired:
+			// the chain never used the sequence of the transaction
+			c.updateBroadcastedTx(tx.id, model.Failed, "transaction was not included in a block", true)
+		case resp == nil:
+			continue
+		case resp.Code == 0:
+			c.updateBroadcastedTx(tx.id, model.Success, "", false)
+		default:
+			c.updateBroadcastedTx(tx.id, model.Failed, resp.RawLog, false)
+		}
+	}
+}
+
+// updateBroadcastedTx records the outcome of a broadcasted transaction. When release is
+// set, the transactions queued after it take over its sequence.
+func (c *Cron) updateBroadcastedTx(id int, status model.STATUS, errMsg string, release bool) {
+	var address string
+	err := c.inTx(func(tx *sql.Tx) error {
+		var sequence sql.NullInt64
+		err := tx.QueryRow(`UPDATE transactions SET status=$1,err_msg=$2,last_updated=$3 WHERE id=$4 AND status=$5 RETURNING multisig_address,sequence`,
+			status, errMsg, time.Now().UTC(), id, model.Broadcasting).Scan(&address, &sequence)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			TxID:            audit.TxID(id),
+			Action:          audit.StatusChanged,
+			OldValue:        audit.Status(model.Broadcasting),
+			NewValue:        audit.Value(map[string]string{"status": string(status), "err_msg": errMsg}),
+		}); err != nil {
+			return err
+		}
+
+		if !release || !sequence.Valid {
+			return nil
+		}
+
+		return ReleaseSequence(tx, address, uint64(sequence.Int64), audit.SystemActor)
+	})
+	if err != nil {
+		utils.ErrorLogger.Printf("failed to update transaction %d %s\n", id, err.Error())
+		return
+	}
+
+	// the transaction changed before it was updated
+	if address == "" {
+		return
+	}
+
+	switch status {
+	case model.Success:
+		events.Publish(events.Event{Type: events.TxConfirmed, MultisigAddress: address, TxID: id, Actor: audit.SystemActor})
+	case model.Failed:
+		events.Publish(events.Event{Type: events.TxFailed, MultisigAddress: address, TxID: id, Actor: audit.SystemActor,
+			Data: events.Data(map[string]string{"err_msg": errMsg})})
+	}
+}
+
+// inTx runs fn in a database transaction, committing it when fn succeeds.
+func (c *Cron) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MarkStaleTxs marks pending transactions whose sequence was used on chain by another
+// transaction, so that members know they have to be signed again.
+func (c *Cron) MarkStaleTxs() {
+	rows, err := c.db.Query(`SELECT DISTINCT t.multisig_address,m.chain_id FROM transactions t
+	JOIN multisig_accounts m ON t.multisig_address = m.address WHERE t.status=$1 AND t.sequence IS NOT NULL`, model.Pending)
+	if err != nil {
+		utils.ErrorLogger.Printf("failed to fetch queued transactions %s\n", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	type queue struct {
+		address string
+		chainId string
+	}
+
+	var queues []queue
+	for rows.Next() {
+		var q queue
+		if err := rows.Scan(&q.address, &q.chainId); err != nil {
+			utils.ErrorLogger.Printf("failed to decode queued transactions %s\n", err.Error())
+			continue
+		}
+		queues = append(queues, q)
+	}
+
+	for _, q := range queues {
+		account, err := clients.GetAccount(q.chainId, q.address)
+		if err != nil {
+			utils.ErrorLogger.Printf("failed to fetch account %s %s\n", q.address, err.Error())
+			continue
+		}
+
+		err = c.inTx(func(tx *sql.Tx) error {
+			return MarkStale(tx, q.address, account.Sequence)
+		})
+		if err != nil {
+			utils.ErrorLogger.Printf("failed to mark stale transactions of %s %s\n", q.address, err.Error())
+		}
+	}
+}
+
+// MarkStale marks the pending transactions of a multisig account whose sequence was
+// already used on chain, recording the status changes in the audit log.
+func MarkStale(tx *sql.Tx, address string, chainSequence uint64) error {
+	rows, err := tx.Query(`UPDATE transactions SET status=$1,last_updated=$2 WHERE multisig_address=$3 AND status=$4 AND sequence<$5 RETURNING id`,
+		model.Stale, time.Now().UTC(), address, model.Pending, chainSequence)
+	if err != nil {
+		return err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			TxID:            audit.TxID(id),
+			Action:          audit.StatusChanged,
+			OldValue:        audit.Status(string(model.Pending)),
+			NewValue:        audit.Status(model.Stale),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ReleaseSequence moves the transactions queued after a removed transaction one place
+// up. Their signatures were made for the previous sequence and are cleared.
+func ReleaseSequence(tx *sql.Tx, address string, sequence uint64, actor string) error {
+	rows, err := tx.Query(`UPDATE transactions t SET sequence=t.sequence-1,signatures='[]'::jsonb,signed_at=NULL,version=t.version+1,last_updated=$1
+	FROM (SELECT id,sequence,signatures FROM transactions WHERE multisig_address=$2 AND status=$3 AND sequence>$4) old
+	WHERE t.id=old.id RETURNING t.id,old.sequence,old.signatures`, time.Now().UTC(), address, model.Pending, sequence)
+	if err != nil {
+		return err
+	}
+
+	type cleared struct {
+		id         int
+		sequence   int64
+		signatures json.RawMessage
+	}
+
+	var txs []cleared
+	for rows.Next() {
+		var t cleared
+		if err := rows.Scan(&t.id, &t.sequence, &t.signatures); err != nil {
+			rows.Close()
+			return err
+		}
+		txs = append(txs, t)
+	}
+	rows.Close()
+
+	for _, t := range txs {
+		if err := audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			TxID:            audit.TxID(t.id),
+			Actor:           actor,
+			Action:          audit.SignaturesCleared,
+			OldValue:        audit.Value(map[string]interface{}{"sequence": t.sequence, "signatures": t.signatures}),
+			NewValue:        audit.Value(map[string]interface{}{"sequence": t.sequence - 1, "signatures": []struct{}{}}),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,149 @@
+// Package events pushes the activity of multisig accounts to the clients watching them.
+//
+// Events are published on a Redis channel per multisig account, so that a client
+// connected to any server replica receives the events of requests served by the
+// others. They are notifications only: an event lost while a client reconnects is
+// found again by fetching the transactions.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/vitwit/resolute/server/clients"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// Types of the events.
+const (
+	TxCreated        = "tx_created"
+	TxSigned         = "tx_signed"
+	ThresholdReached = "threshold_reached"
+	TxBroadcast      = "tx_broadcast"
+	TxConfirmed      = "tx_confirmed"
+	TxFailed         = "tx_failed"
+)
+
+// Event is a change to a transaction of a multisig account.
+type Event struct {
+	Type            string          `json:"type"`
+	MultisigAddress string          `json:"multisig_address"`
+	TxID            int             `json:"tx_id"`
+	Actor           string          `json:"actor,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+var errUnavailable = errors.New("redis is not configured")
+
+// publish and subscribe are replaced in tests.
+var (
+	publish   = clients.Publish
+	subscribe = func(ctx context.Context, channel string) (<-chan []byte, func(), error) {
+		sub := clients.Subscribe(ctx, channel)
+
+		// wait for the subscription, so that no event published afterwards is missed
+		if _, err := sub.Receive(ctx); err != nil {
+			sub.Close()
+			return nil, nil, err
+		}
+
+		out := make(chan []byte)
+		go func() {
+			defer close(out)
+			for msg := range sub.Channel() {
+				select {
+				case out <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		return out, func() { sub.Close() }, nil
+	}
+	available = func() bool { return clients.RedisClient != nil }
+)
+
+// Channel is the Redis channel of the events of a multisig account.
+func Channel(address string) string {
+	return "multisig-events:" + address
+}
+
+// Data encodes the details of an event.
+func Data(v interface{}) json.RawMessage {
+	bz, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	return bz
+}
+
+// Publish sends the event to the clients watching its multisig account. It is called
+// once the change is committed, and failures are logged rather than failing the change.
+func Publish(e Event) {
+	if !available() {
+		return
+	}
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+
+	bz, err := json.Marshal(e)
+	if err != nil {
+		utils.ErrorLogger.Printf("failed to encode %s event of %s %s\n", e.Type, e.MultisigAddress, err.Error())
+		return
+	}
+
+	if err := publish(Channel(e.MultisigAddress), bz); err != nil {
+		utils.ErrorLogger.Printf("failed to publish %s event of %s %s\n", e.Type, e.MultisigAddress, err.Error())
+	}
+}
+
+// Subscribe returns the events of a multisig account published from now on. The
+// channel is closed once the context is done.
+func Subscribe(ctx context.Context, address string) (<-chan Event, error) {
+	if !available() {
+		return nil, errUnavailable
+	}
+
+	msgs, unsubscribe, err := subscribe(ctx, Channel(address))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+
+				var e Event
+				if err := json.Unmarshal(msg, &e); err != nil {
+					utils.ErrorLogger.Printf("failed to decode event of %s %s\n", address, err.Error())
+					continue
+				}
+
+				select {
+				case out <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,84 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroker stands in for Redis pub/sub.
+type fakeBroker struct {
+	mu   sync.Mutex
+	subs map[string][]chan []byte
+}
+
+func useFakeBroker(t *testing.T) *fakeBroker {
+	b := &fakeBroker{subs: make(map[string][]chan []byte)}
+
+	origPublish, origSubscribe, origAvailable := publish, subscribe, available
+	t.Cleanup(func() { publish, subscribe, available = origPublish, origSubscribe, origAvailable })
+
+	available = func() bool { return true }
+	publish = func(channel string, message []byte) error {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for _, ch := range b.subs[channel] {
+			ch <- message
+		}
+		return nil
+	}
+	subscribe = func(ctx context.Context, channel string) (<-chan []byte, func(), error) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		ch := make(chan []byte, 10)
+		b.subs[channel] = append(b.subs[channel], ch)
+		return ch, func() {}, nil
+	}
+
+	return b
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	useFakeBroker(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := Subscribe(ctx, "osmo1multisig")
+	require.NoError(t, err)
+
+	Publish(Event{Type: TxCreated, MultisigAddress: "osmo1other", TxID: 1})
+	Publish(Event{Type: TxSigned, MultisigAddress: "osmo1multisig", TxID: 2, Actor: "osmo1signer", Data: Data(map[string]int{"signatures": 1})})
+
+	select {
+	case e := <-stream:
+		require.Equal(t, TxSigned, e.Type)
+		require.Equal(t, 2, e.TxID)
+		require.Equal(t, "osmo1signer", e.Actor)
+		require.JSONEq(t, `{"signatures":1}`, string(e.Data))
+		require.False(t, e.CreatedAt.IsZero())
+	case <-time.After(time.Second):
+		t.Fatal("event was not received")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-stream:
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("stream was not closed")
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	orig := available
+	t.Cleanup(func() { available = orig })
+	available = func() bool { return false }
+
+	// publishing without Redis is a no-op
+	Publish(Event{Type: TxCreated, MultisigAddress: "osmo1multisig", TxID: 1})
+
+	_, err := Subscribe(context.Background(), "osmo1multisig")
+	require.ErrorIs(t, err, errUnavailable)
+}
@@ -0,0 +1,374 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/policy"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/summary"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+var errDuplicateAddress = errors.New("address is already in the address book")
+
+const addressBookColumns = `id,multisig_address,address,label,chain_id,notes,created_by,created_at,updated_at`
+
+func scanAddressBookEntry(row interface{ Scan(...interface{}) error }) (schema.AddressBookEntry, error) {
+	var entry schema.AddressBookEntry
+	err := row.Scan(
+		&entry.ID,
+		&entry.MultisigAddress,
+		&entry.Address,
+		&entry.Label,
+		&entry.ChainID,
+		&entry.Notes,
+		&entry.CreatedBy,
+		&entry.CreatedAt,
+		&entry.UpdatedAt,
+	)
+
+	return entry, err
+}
+
+// addressBook is the address book of a multisig account.
+type addressBook []schema.AddressBookEntry
+
+// Label returns the label of an address, whatever the prefix it is encoded with, or an
+// empty string when the address is not in the book.
+func (b addressBook) Label(address string) string {
+	for _, entry := range b {
+		if entry.Address == address || cosmos.SameAccount(entry.Address, address) {
+			return entry.Label
+		}
+	}
+
+	return ""
+}
+
+// Unknown returns the recipients of the messages which are not in the book.
+func (b addressBook) Unknown(msgs []cosmos.Msg) []string {
+	var unknown []string
+	seen := make(map[string]bool)
+	for _, t := range policy.Transfers(msgs) {
+		if seen[t.To] || b.Label(t.To) != "" {
+			continue
+		}
+		seen[t.To] = true
+		unknown = append(unknown, t.To)
+	}
+
+	return unknown
+}
+
+func (h *Handler) loadAddressBook(address string) (addressBook, error) {
+	rows, err := h.DB.Query(`SELECT `+addressBookColumns+` FROM address_book WHERE multisig_address=$1 ORDER BY label`, address)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	book := make(addressBook, 0)
+	for rows.Next() {
+		entry, err := scanAddressBookEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		book = append(book, entry)
+	}
+
+	return book, rows.Err()
+}
+
+// labelledChain returns the chain details the messages of a multisig account are
+// summarized with, labelling the addresses of its address book.
+func (h *Handler) labelledChain(address, chainID string) summary.Chain {
+	chain := summaryChain(chainID)
+	if book, err := h.loadAddressBook(address); err == nil && len(book) > 0 {
+		chain.Label = book.Label
+	}
+
+	return chain
+}
+
+// checkRecipients applies the recipient mode of a multisig account to the messages of
+// a transaction. It returns the warnings to store with the transaction, or an error
+// when the mode rejects the transaction.
+func (h *Handler) checkRecipients(address, mode string, msgs []cosmos.Msg) ([]string, int, error) {
+	if mode == "" || mode == model.RecipientModeOff {
+		return nil, http.StatusOK, nil
+	}
+
+	book, err := h.loadAddressBook(address)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	unknown := book.Unknown(msgs)
+	if len(unknown) == 0 {
+		return nil, http.StatusOK, nil
+	}
+
+	if mode == model.RecipientModeEnforce {
+		return nil, http.StatusForbidden, fmt.Errorf("recipients are not in the address book: %s", strings.Join(unknown, ", "))
+	}
+
+	warnings := make([]string, 0, len(unknown))
+	for _, recipient := range unknown {
+		warnings = append(warnings, fmt.Sprintf("recipient %s is not in the address book", recipient))
+	}
+
+	return warnings, http.StatusOK, nil
+}
+
+func (h *Handler) GetAddressBook(c echo.Context) error {
+	address := c.Param("address")
+
+	book, err := h.loadAddressBook(address)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query address book",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   book,
+	})
+}
+
+func (h *Handler) CreateAddressBookEntry(c echo.Context) error {
+	address := c.Param("address")
+
+	req := &model.AddressBookEntryReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	chainID := req.ChainID
+	if chainID == "" {
+		chainID = h.multisigChainID(address)
+	}
+
+	var entry schema.AddressBookEntry
+	err = h.inTx(func(tx *sql.Tx) error {
+		now := time.Now().UTC()
+		entry, err = scanAddressBookEntry(tx.QueryRow(`INSERT INTO address_book ("multisig_address","address","label","chain_id","notes","created_by","created_at","updated_at")
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$7) ON CONFLICT DO NOTHING RETURNING `+addressBookColumns,
+			address, req.Address, strings.TrimSpace(req.Label), chainID, req.Notes, member, now))
+		if err == sql.ErrNoRows {
+			return errDuplicateAddress
+		}
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           member,
+			Action:          audit.AddressAdded,
+			NewValue:        audit.Value(map[string]string{"address": entry.Address, "label": entry.Label, "chain_id": entry.ChainID}),
+		})
+	})
+	if err == errDuplicateAddress {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to add address",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   entry,
+	})
+}
+
+func (h *Handler) UpdateAddressBookEntry(c echo.Context) error {
+	address := c.Param("address")
+	entryId, err := strconv.Atoi(c.Param("entryId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid address book entry id",
+		})
+	}
+
+	req := &model.AddressBookEntryReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	var entry schema.AddressBookEntry
+	err = h.inTx(func(tx *sql.Tx) error {
+		old, err := scanAddressBookEntry(tx.QueryRow(`SELECT `+addressBookColumns+` FROM address_book WHERE id=$1 AND multisig_address=$2 FOR UPDATE`,
+			entryId, address))
+		if err != nil {
+			return err
+		}
+
+		chainID := req.ChainID
+		if chainID == "" {
+			chainID = old.ChainID
+		}
+
+		if req.Address != old.Address {
+			var exists bool
+			if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM address_book WHERE multisig_address=$1 AND address=$2)`,
+				address, req.Address).Scan(&exists); err != nil {
+				return err
+			}
+			if exists {
+				return errDuplicateAddress
+			}
+		}
+
+		entry, err = scanAddressBookEntry(tx.QueryRow(`UPDATE address_book SET address=$1,label=$2,chain_id=$3,notes=$4,updated_at=$5
+		WHERE id=$6 RETURNING `+addressBookColumns, req.Address, strings.TrimSpace(req.Label), chainID, req.Notes, time.Now().UTC(), entryId))
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           member,
+			Action:          audit.AddressUpdated,
+			OldValue:        audit.Value(map[string]string{"address": old.Address, "label": old.Label, "chain_id": old.ChainID}),
+			NewValue:        audit.Value(map[string]string{"address": entry.Address, "label": entry.Label, "chain_id": entry.ChainID}),
+		})
+	})
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "address book entry not found",
+			})
+		case errDuplicateAddress:
+			return c.JSON(http.StatusConflict, model.ErrorResponse{
+				Status:  "error",
+				Message: err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to update address",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   entry,
+	})
+}
+
+func (h *Handler) DeleteAddressBookEntry(c echo.Context) error {
+	address := c.Param("address")
+	entryId, err := strconv.Atoi(c.Param("entryId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid address book entry id",
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	err = h.inTx(func(tx *sql.Tx) error {
+		var entryAddress, label string
+		if err := tx.QueryRow(`DELETE FROM address_book WHERE id=$1 AND multisig_address=$2 RETURNING address,label`, entryId, address).
+			Scan(&entryAddress, &label); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           member,
+			Action:          audit.AddressRemoved,
+			OldValue:        audit.Value(map[string]string{"address": entryAddress, "label": label}),
+		})
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "address book entry not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to remove address",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Message: "address removed",
+	})
+}
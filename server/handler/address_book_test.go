@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/model"
+)
+
+var addressBookRowColumns = []string{"id", "multisig_address", "address", "label", "chain_id", "notes", "created_by", "created_at", "updated_at"}
+
+func TestCreateAddressBookEntry(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	admin := testAddress(t, "osmo", 0x01)
+	recipient := testAddress(t, "osmo", 0x03)
+
+	testCases := []struct {
+		name      string
+		body      string
+		exists    bool
+		expStatus int
+	}{
+		{"new address", `{"address":"` + recipient + `","label":" Payroll ","notes":"monthly salaries"}`, false, http.StatusOK},
+		{"address already in the book", `{"address":"` + recipient + `","label":"Payroll"}`, true, http.StatusConflict},
+		{"empty label", `{"address":"` + recipient + `","label":"  "}`, false, http.StatusBadRequest},
+		{"invalid address", `{"address":"osmo1invalid","label":"Payroll"}`, false, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			if tc.expStatus != http.StatusBadRequest {
+				mock.ExpectQuery("SELECT chain_id FROM multisig_accounts").WithArgs(multisig).WillReturnRows(
+					sqlmock.NewRows([]string{"chain_id"}).AddRow("osmosis-1"))
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows(addressBookRowColumns)
+				if !tc.exists {
+					now := time.Now()
+					rows.AddRow(1, multisig, recipient, "Payroll", "osmosis-1", "monthly salaries", admin, now, now)
+				}
+				mock.ExpectQuery("INSERT INTO address_book").
+					WithArgs(multisig, recipient, "Payroll", "osmosis-1", sqlmock.AnyArg(), admin, sqlmock.AnyArg()).
+					WillReturnRows(rows)
+				if tc.exists {
+					mock.ExpectRollback()
+				} else {
+					expectAudit(mock, multisig)
+					mock.ExpectCommit()
+				}
+			}
+
+			c, rec := commentContext(http.MethodPost, tc.body, []string{"address"}, []string{multisig}, admin)
+			h := &Handler{DB: db}
+			require.NoError(t, h.CreateAddressBookEntry(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCheckRecipients(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	known := testAddress(t, "osmo", 0x03)
+	unknown := testAddress(t, "osmo", 0x04)
+
+	// the book may hold the address with the prefix of another chain
+	knownHub := testAddress(t, "cosmos", 0x03)
+
+	send := func(to string) []cosmos.Msg {
+		var msgs []model.Message
+		require.NoError(t, json.Unmarshal([]byte(`[{"typeUrl":"/cosmos.bank.v1beta1.MsgSend","value":{"fromAddress":"`+multisig+
+			`","toAddress":"`+to+`","amount":[{"denom":"uosmo","amount":"10"}]}}]`), &msgs))
+		return model.Msgs(msgs)
+	}
+
+	testCases := []struct {
+		name        string
+		mode        string
+		to          string
+		expWarnings int
+		expStatus   int
+	}{
+		{"off", model.RecipientModeOff, unknown, 0, http.StatusOK},
+		{"warn about an unknown recipient", model.RecipientModeWarn, unknown, 1, http.StatusOK},
+		{"reject an unknown recipient", model.RecipientModeEnforce, unknown, 0, http.StatusForbidden},
+		{"known recipient", model.RecipientModeEnforce, known, 0, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			if tc.mode != model.RecipientModeOff {
+				now := time.Now()
+				mock.ExpectQuery("SELECT (.+) FROM address_book").WithArgs(multisig).WillReturnRows(
+					sqlmock.NewRows(addressBookRowColumns).AddRow(1, multisig, knownHub, "Payroll", "cosmoshub-4", "", multisig, now, now))
+			}
+
+			h := &Handler{DB: db}
+			warnings, status, err := h.checkRecipients(multisig, tc.mode, send(tc.to))
+			require.Equal(t, tc.expStatus, status)
+			require.Equal(t, tc.expStatus != http.StatusOK, err != nil)
+			require.Len(t, warnings, tc.expWarnings)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAddressBookLabel(t *testing.T) {
+	book := addressBook{{Address: testAddress(t, "osmo", 0x03), Label: "Payroll"}}
+
+	require.Equal(t, "Payroll", book.Label(testAddress(t, "osmo", 0x03)))
+	require.Equal(t, "Payroll", book.Label(testAddress(t, "juno", 0x03)))
+	require.Empty(t, book.Label(testAddress(t, "osmo", 0x04)))
+	require.Empty(t, addressBook{}.Label(testAddress(t, "osmo", 0x03)))
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// auditActor returns the authenticated address in the form used by the multisig account.
+func auditActor(c echo.Context, address string) string {
+	if member, err := utils.GetAuthMemberAddress(c, address); err == nil {
+		return member
+	}
+
+	return utils.GetAuthAddress(c)
+}
+
+// AuditChain is the result of verifying the hash chain of the audit log.
+type AuditChain struct {
+	Valid  bool   `json:"valid"`
+	Length int    `json:"length"`
+	Head   string `json:"head"`
+	// BrokenAt is the id of the first event which does not match the chain
+	BrokenAt int64 `json:"broken_at,omitempty"`
+}
+
+type AuditLogResponse struct {
+	Events []schema.AuditEvent `json:"events"`
+	Chain  AuditChain          `json:"chain"`
+}
+
+const auditEventColumns = `id,multisig_address,tx_id,actor,action,old_value,new_value,created_at,prev_hash,hash`
+
+func scanAuditEvents(rows *sql.Rows) ([]schema.AuditEvent, error) {
+	defer rows.Close()
+
+	events := make([]schema.AuditEvent, 0)
+	for rows.Next() {
+		var (
+			event    schema.AuditEvent
+			txID     sql.NullInt64
+			oldValue []byte
+			newValue []byte
+		)
+		if err := rows.Scan(&event.ID, &event.MultisigAddress, &txID, &event.Actor, &event.Action, &oldValue, &newValue,
+			&event.CreatedAt, &event.PrevHash, &event.Hash); err != nil {
+			return nil, err
+		}
+
+		if txID.Valid {
+			event.TxID = audit.TxID(int(txID.Int64))
+		}
+		event.OldValue = oldValue
+		event.NewValue = newValue
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// GetAuditEvents returns the audit log of a multisig account, newest first, along with
+// the verification of its hash chain.
+// Events can be filtered by action, actor, tx_id and a from/to time range (RFC 3339).
+func (h *Handler) GetAuditEvents(c echo.Context) error {
+	address := c.Param("address")
+	page, limit, _, err := utils.ParsePaginationParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Log:     err.Error(),
+		})
+	}
+
+	actor := c.QueryParam("actor")
+	if actor != "" {
+		if prefix, _, err := cosmos.DecodeAddress(address); err == nil {
+			if member, err := cosmos.ConvertAddress(actor, prefix); err == nil {
+				actor = member
+			}
+		}
+	}
+
+	var txID *int
+	if v := c.QueryParam("tx_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Status:  "error",
+				Message: "invalid tx_id",
+				Log:     err.Error(),
+			})
+		}
+		txID = &id
+	}
+
+	var from, to *time.Time
+	for _, p := range []struct {
+		name  string
+		value **time.Time
+	}{{"from", &from}, {"to", &to}} {
+		v := c.QueryParam(p.name)
+		if v == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Status:  "error",
+				Message: "invalid " + p.name + " time, expected RFC 3339",
+				Log:     err.Error(),
+			})
+		}
+		*p.value = &t
+	}
+
+	rows, err := h.DB.Query(`SELECT `+auditEventColumns+` FROM audit_events WHERE multisig_address=$1
+	AND ($2='' OR action=$2) AND ($3='' OR actor=$3) AND ($4::integer IS NULL OR tx_id=$4)
+	AND ($5::timestamptz IS NULL OR created_at>=$5) AND ($6::timestamptz IS NULL OR created_at<=$6)
+	ORDER BY id DESC LIMIT $7 OFFSET $8`,
+		address, c.QueryParam("action"), actor, txID, from, to, limit, (page-1)*limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query audit events",
+			Log:     err.Error(),
+		})
+	}
+
+	events, err := scanAuditEvents(rows)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode audit events",
+			Log:     err.Error(),
+		})
+	}
+
+	// the chain is verified from the first event, whatever the filters
+	rows, err = h.DB.Query(`SELECT `+auditEventColumns+` FROM audit_events WHERE multisig_address=$1 ORDER BY id`, address)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query audit events",
+			Log:     err.Error(),
+		})
+	}
+
+	chain, err := scanAuditEvents(rows)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode audit events",
+			Log:     err.Error(),
+		})
+	}
+
+	result := AuditChain{Length: len(chain)}
+	result.BrokenAt = audit.Verify(chain)
+	result.Valid = result.BrokenAt == 0
+	if len(chain) > 0 {
+		result.Head = chain[len(chain)-1].Hash
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data: AuditLogResponse{
+			Events: events,
+			Chain:  result,
+		},
+	})
+}
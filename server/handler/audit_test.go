@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+)
+
+// expectAudit expects an event to be recorded in the empty audit log of the account.
+func expectAudit(mock sqlmock.Sqlmock, address string) {
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(address).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT hash FROM audit_events").WithArgs(address).WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+	mock.ExpectExec("INSERT INTO audit_events").WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectRelease expects the transactions with the given ids to move up the queue.
+func expectRelease(mock sqlmock.Sqlmock, address string, sequence uint64, ids ...int) {
+	rows := sqlmock.NewRows([]string{"id", "sequence", "signatures"})
+	for i, id := range ids {
+		rows.AddRow(id, int64(sequence)+int64(i)+1, []byte("[]"))
+	}
+	mock.ExpectQuery("UPDATE transactions t SET sequence").
+		WithArgs(sqlmock.AnyArg(), address, model.Pending, sequence).WillReturnRows(rows)
+	for range ids {
+		expectAudit(mock, address)
+	}
+}
+
+func auditChain(t *testing.T, address string, n int) []schema.AuditEvent {
+	events := make([]schema.AuditEvent, n)
+	prevHash := ""
+	for i := range events {
+		events[i] = schema.AuditEvent{
+			ID:              int64(i + 1),
+			MultisigAddress: address,
+			TxID:            audit.TxID(1),
+			Actor:           "osmo1member",
+			Action:          audit.TxSigned,
+			NewValue:        json.RawMessage(`{"signer":"osmo1member"}`),
+			CreatedAt:       time.Date(2024, 1, 1, 0, i, 0, 0, time.UTC),
+			PrevHash:        prevHash,
+		}
+
+		hash, err := audit.Hash(events[i])
+		require.NoError(t, err)
+		events[i].Hash = hash
+		prevHash = hash
+	}
+
+	return events
+}
+
+func TestGetAuditEvents(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	columns := []string{"id", "multisig_address", "tx_id", "actor", "action", "old_value", "new_value", "created_at", "prev_hash", "hash"}
+
+	testCases := []struct {
+		name      string
+		query     string
+		tamper    bool
+		expStatus int
+		expChain  AuditChain
+	}{
+		{"intact chain", "", false, http.StatusOK, AuditChain{Valid: true, Length: 3}},
+		{"tampered event", "", true, http.StatusOK, AuditChain{Valid: false, Length: 3, BrokenAt: 2}},
+		{"filtered by action", "?action=tx_signed&tx_id=1", false, http.StatusOK, AuditChain{Valid: true, Length: 3}},
+		{"invalid tx id", "?tx_id=abc", false, http.StatusBadRequest, AuditChain{}},
+		{"invalid time", "?from=yesterday", false, http.StatusBadRequest, AuditChain{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			events := auditChain(t, multisig, 3)
+			if tc.tamper {
+				events[1].Actor = "osmo1other"
+			}
+
+			rows := func() *sqlmock.Rows {
+				rows := sqlmock.NewRows(columns)
+				for _, e := range events {
+					rows.AddRow(e.ID, e.MultisigAddress, int64(*e.TxID), e.Actor, e.Action, []byte(e.OldValue), []byte(e.NewValue),
+						e.CreatedAt, e.PrevHash, e.Hash)
+				}
+				return rows
+			}
+
+			if tc.expStatus == http.StatusOK {
+				mock.ExpectQuery("SELECT (.+) FROM audit_events WHERE multisig_address=\\$1 AND").WillReturnRows(rows())
+				mock.ExpectQuery("SELECT (.+) FROM audit_events WHERE multisig_address=\\$1 ORDER BY id").
+					WithArgs(multisig).WillReturnRows(rows())
+			}
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/"+tc.query, nil), rec)
+			c.SetParamNames("address")
+			c.SetParamValues(multisig)
+
+			h := &Handler{DB: db}
+			require.NoError(t, h.GetAuditEvents(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			if tc.expStatus == http.StatusOK {
+				var resp struct {
+					Data AuditLogResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp.Data.Events, 3)
+				tc.expChain.Head = events[2].Hash
+				require.Equal(t, tc.expChain, resp.Data.Chain)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/clients"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+const challengeNoncePrefix = "Nonce: "
+
+type ChallengeResponse struct {
+	Address   string    `json:"address"`
+	Nonce     string    `json:"nonce"`
+	Salt      int64     `json:"salt"`
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// challengeKey is keyed by the nonce as well, so that requesting a challenge does not
+// replace the challenges already issued to the address.
+func challengeKey(address, nonce string) string {
+	return fmt.Sprintf("auth:challenge:%s:%s", address, nonce)
+}
+
+// challengeNonce returns the nonce of a signed challenge message.
+func challengeNonce(message string) string {
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, challengeNoncePrefix) {
+			return strings.TrimSpace(strings.TrimPrefix(line, challengeNoncePrefix))
+		}
+	}
+
+	return ""
+}
+
+// GetChallenge issues a single use login challenge for the address. The wallet has to
+// sign the returned message (ADR-036) and submit it to CreateUserSignature, along with
+// the returned salt, before it expires.
+func (h *Handler) GetChallenge(c echo.Context) error {
+	address := c.Param("address")
+
+	if _, _, err := cosmos.DecodeAddress(address); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid address",
+			Log:     err.Error(),
+		})
+	}
+
+	bz := make([]byte, 40)
+	if _, err := rand.Read(bz); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to generate challenge",
+			Log:     err.Error(),
+		})
+	}
+
+	now := time.Now().UTC()
+	nonce := hex.EncodeToString(bz[:32])
+	// the salt stored with the user is signed along with the challenge
+	salt := int64(binary.BigEndian.Uint64(bz[32:])>>1) | 1
+	message := fmt.Sprintf("Resolute wants you to sign in with your account:\n%s\n\n%s%s\n%s%d\nIssued At: %s",
+		address, challengeNoncePrefix, nonce, model.ChallengeSaltPrefix, salt, now.Format(time.RFC3339))
+
+	if err := clients.SetValueWithExpiry(challengeKey(address, nonce), message, utils.CHALLENGE_EXPIRY); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to store challenge",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data: ChallengeResponse{
+			Address:   address,
+			Nonce:     nonce,
+			Salt:      salt,
+			Message:   message,
+			ExpiresAt: now.Add(utils.CHALLENGE_EXPIRY),
+		},
+	})
+}
+
+// consumeChallenge deletes the challenge of the address with the nonce of the signed
+// message and reports whether it matches the message.
+func consumeChallenge(address string, message string) (bool, error) {
+	nonce := challengeNonce(message)
+	if nonce == "" {
+		return false, nil
+	}
+
+	challenge, err := clients.PopValue(challengeKey(address, nonce))
+	if err != nil {
+		return false, err
+	}
+
+	return challenge != "" && challenge == message, nil
+}
+
+type RefreshSessionReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshSession exchanges a refresh token for a new access and refresh token pair.
+func (h *Handler) RefreshSession(c echo.Context) error {
+	req := &RefreshSessionReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if req.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "refresh token is required",
+		})
+	}
+
+	session, err := clients.RefreshSession(req.RefreshToken, utils.SESSION_EXPIRY, utils.REFRESH_EXPIRY)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to refresh session",
+			Log:     err.Error(),
+		})
+	}
+
+	if session == nil {
+		return c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Status:  "Unauthorized",
+			Message: "refresh token is invalid or expired",
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   session,
+	})
+}
+
+// Logout revokes the access token of the request along with its refresh token.
+func (h *Handler) Logout(c echo.Context) error {
+	if err := clients.RevokeSession(utils.GetBearerToken(c)); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to revoke session",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Message: "logged out",
+	})
+}
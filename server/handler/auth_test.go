@@ -0,0 +1,14 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChallengeNonce(t *testing.T) {
+	message := "Resolute wants you to sign in with your account:\nosmo1signer\n\nNonce: 0a1b2c\nIssued At: 2024-01-02T03:04:05Z"
+	require.Equal(t, "0a1b2c", challengeNonce(message))
+	require.Equal(t, "", challengeNonce("Resolute wants you to sign in with your account:\nosmo1signer"))
+	require.NotEqual(t, challengeKey("osmo1signer", "0a1b2c"), challengeKey("osmo1signer", "3d4e5f"))
+}
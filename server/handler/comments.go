@@ -0,0 +1,363 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// CommentEdit is a previous version of an edited comment.
+type CommentEdit struct {
+	Body        string    `json:"body"`
+	Attachments []string  `json:"attachments"`
+	EditedAt    time.Time `json:"edited_at"`
+}
+
+var errNotCommentAuthor = errors.New("only the author can edit the comment")
+
+const commentColumns = `id,tx_id,multisig_address,author,body,attachments,edits,created_at,updated_at`
+
+func scanComment(row interface{ Scan(...interface{}) error }) (schema.Comment, error) {
+	var comment schema.Comment
+	err := row.Scan(
+		&comment.ID,
+		&comment.TxID,
+		&comment.MultisigAddress,
+		&comment.Author,
+		&comment.Body,
+		&comment.Attachments,
+		&comment.Edits,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	)
+
+	return comment, err
+}
+
+// parseCommentParams returns the transaction id and, when the route has one, the comment id.
+func parseCommentParams(c echo.Context) (int, int, error) {
+	txId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, 0, errors.New("invalid transaction id")
+	}
+
+	if c.Param("commentId") == "" {
+		return txId, 0, nil
+	}
+
+	commentId, err := strconv.Atoi(c.Param("commentId"))
+	if err != nil {
+		return 0, 0, errors.New("invalid comment id")
+	}
+
+	return txId, commentId, nil
+}
+
+// GetComments returns the comments of a transaction, oldest first.
+func (h *Handler) GetComments(c echo.Context) error {
+	address := c.Param("address")
+	txId, _, err := parseCommentParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	page, limit, _, err := utils.ParsePaginationParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Log:     err.Error(),
+		})
+	}
+
+	rows, err := h.DB.Query(`SELECT `+commentColumns+` FROM tx_comments WHERE tx_id=$1 AND multisig_address=$2
+	ORDER BY id ASC LIMIT $3 OFFSET $4`, txId, address, limit, (page-1)*limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query comments",
+			Log:     err.Error(),
+		})
+	}
+	defer rows.Close()
+
+	comments := make([]schema.Comment, 0)
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to decode comments",
+				Log:     err.Error(),
+			})
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   comments,
+	})
+}
+
+// CreateComment adds a comment of a member to a transaction.
+func (h *Handler) CreateComment(c echo.Context) error {
+	address := c.Param("address")
+	txId, _, err := parseCommentParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	req := &model.CommentReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	author, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	if req.Attachments == nil {
+		req.Attachments = []string{}
+	}
+	attachments, err := json.Marshal(req.Attachments)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid attachments",
+			Log:     err.Error(),
+		})
+	}
+
+	var exists bool
+	if err := h.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM transactions WHERE id=$1 AND multisig_address=$2 AND deleted_at IS NULL)`,
+		txId, address).Scan(&exists); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if !exists {
+		return c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Status:  "error",
+			Message: "transaction not found",
+		})
+	}
+
+	now := time.Now().UTC()
+	comment, err := scanComment(h.DB.QueryRow(`INSERT INTO tx_comments ("tx_id","multisig_address","author","body","attachments","created_at","updated_at")
+	VALUES ($1,$2,$3,$4,$5,$6,$6) RETURNING `+commentColumns, txId, address, author, req.Body, attachments, now))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to store comment",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   comment,
+	})
+}
+
+// UpdateComment changes a comment of its author, keeping the previous version in the
+// edit history.
+func (h *Handler) UpdateComment(c echo.Context) error {
+	address := c.Param("address")
+	txId, commentId, err := parseCommentParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	req := &model.CommentReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	if req.Attachments == nil {
+		req.Attachments = []string{}
+	}
+
+	var comment schema.Comment
+	err = h.inTx(func(tx *sql.Tx) error {
+		old, err := scanComment(tx.QueryRow(`SELECT `+commentColumns+` FROM tx_comments WHERE id=$1 AND tx_id=$2 AND multisig_address=$3 FOR UPDATE`,
+			commentId, txId, address))
+		if err != nil {
+			return err
+		}
+
+		if old.Author != member {
+			return errNotCommentAuthor
+		}
+
+		var edits []CommentEdit
+		if err := json.Unmarshal(old.Edits, &edits); err != nil {
+			return err
+		}
+
+		var oldAttachments []string
+		if err := json.Unmarshal(old.Attachments, &oldAttachments); err != nil {
+			return err
+		}
+
+		edits = append(edits, CommentEdit{
+			Body:        old.Body,
+			Attachments: oldAttachments,
+			EditedAt:    old.UpdatedAt,
+		})
+
+		editsbz, err := json.Marshal(edits)
+		if err != nil {
+			return err
+		}
+
+		attachments, err := json.Marshal(req.Attachments)
+		if err != nil {
+			return err
+		}
+
+		comment, err = scanComment(tx.QueryRow(`UPDATE tx_comments SET body=$1,attachments=$2,edits=$3,updated_at=$4 WHERE id=$5
+		RETURNING `+commentColumns, req.Body, attachments, editsbz, time.Now().UTC(), commentId))
+		return err
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "comment not found",
+			})
+		}
+		if err == errNotCommentAuthor {
+			return c.JSON(http.StatusForbidden, model.ErrorResponse{
+				Status:  "error",
+				Message: err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to update comment",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   comment,
+	})
+}
+
+// DeleteComment removes a comment. Authors can delete their comments and admins any
+// comment of the account.
+func (h *Handler) DeleteComment(c echo.Context) error {
+	address := c.Param("address")
+	txId, commentId, err := parseCommentParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	var author string
+	var isAdmin bool
+	err = h.DB.QueryRow(`SELECT c.author, EXISTS(SELECT 1 FROM multisig_roles r WHERE r.multisig_address=c.multisig_address AND r.address=$4 AND r.role=$5)
+	FROM tx_comments c WHERE c.id=$1 AND c.tx_id=$2 AND c.multisig_address=$3`, commentId, txId, address, member, model.RoleAdmin).Scan(&author, &isAdmin)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "comment not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query comment",
+			Log:     err.Error(),
+		})
+	}
+
+	if author != member && !isAdmin {
+		return c.JSON(http.StatusForbidden, model.ErrorResponse{
+			Status:  "error",
+			Message: "only the author or an admin can delete the comment",
+		})
+	}
+
+	if _, err := h.DB.Exec(`DELETE FROM tx_comments WHERE id=$1`, commentId); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to delete comment",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "comment deleted",
+	})
+}
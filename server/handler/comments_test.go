@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+var commentRowColumns = []string{"id", "tx_id", "multisig_address", "author", "body", "attachments", "edits", "created_at", "updated_at"}
+
+func commentContext(method, body string, params []string, values []string, member string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames(params...)
+	c.SetParamValues(values...)
+	c.Set(utils.AUTH_ADDRESS_KEY, member)
+
+	return c, rec
+}
+
+func TestCreateComment(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	member := testAddress(t, "osmo", 0x01)
+
+	testCases := []struct {
+		name      string
+		body      string
+		txExists  bool
+		expStatus int
+	}{
+		{"comment with attachments", `{"body":"fee looks high","attachments":["https://example.com/quote.pdf"]}`, true, http.StatusOK},
+		{"empty comment", `{"body":"  "}`, true, http.StatusBadRequest},
+		{"invalid attachment link", `{"body":"see file","attachments":["javascript:alert(1)"]}`, true, http.StatusBadRequest},
+		{"missing transaction", `{"body":"hello"}`, false, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			if tc.expStatus != http.StatusBadRequest {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(1, multisig).WillReturnRows(
+					sqlmock.NewRows([]string{"exists"}).AddRow(tc.txExists))
+			}
+			if tc.expStatus == http.StatusOK {
+				now := time.Now()
+				mock.ExpectQuery("INSERT INTO tx_comments").
+					WithArgs(1, multisig, member, "fee looks high", []byte(`["https://example.com/quote.pdf"]`), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows(commentRowColumns).
+						AddRow(1, 1, multisig, member, "fee looks high", []byte(`["https://example.com/quote.pdf"]`), []byte("[]"), now, now))
+			}
+
+			c, rec := commentContext(http.MethodPost, tc.body, []string{"address", "id"}, []string{multisig, "1"}, member)
+			h := &Handler{DB: db}
+			require.NoError(t, h.CreateComment(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUpdateComment(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	author := testAddress(t, "osmo", 0x01)
+	other := testAddress(t, "osmo", 0x02)
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		member    string
+		expStatus int
+	}{
+		{"author edits the comment", author, http.StatusOK},
+		{"other member", other, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT id,tx_id").WithArgs(2, 1, multisig).WillReturnRows(sqlmock.NewRows(commentRowColumns).
+				AddRow(2, 1, multisig, author, "first", []byte(`[]`), []byte(`[]`), createdAt, createdAt))
+			if tc.expStatus == http.StatusOK {
+				edits := []byte(`[{"body":"first","attachments":[],"edited_at":"2024-01-01T00:00:00Z"}]`)
+				mock.ExpectQuery("UPDATE tx_comments").WithArgs("second", []byte("[]"), edits, sqlmock.AnyArg(), 2).
+					WillReturnRows(sqlmock.NewRows(commentRowColumns).
+						AddRow(2, 1, multisig, author, "second", []byte(`[]`), edits, createdAt, time.Now()))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			c, rec := commentContext(http.MethodPut, `{"body":"second"}`, []string{"address", "id", "commentId"},
+				[]string{multisig, "1", "2"}, tc.member)
+			h := &Handler{DB: db}
+			require.NoError(t, h.UpdateComment(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	author := testAddress(t, "osmo", 0x01)
+	other := testAddress(t, "osmo", 0x02)
+
+	testCases := []struct {
+		name      string
+		member    string
+		isAdmin   bool
+		expStatus int
+	}{
+		{"author deletes the comment", author, false, http.StatusOK},
+		{"admin deletes the comment", other, true, http.StatusOK},
+		{"other member", other, false, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT c.author").WithArgs(2, 1, multisig, tc.member, model.RoleAdmin).WillReturnRows(
+				sqlmock.NewRows([]string{"author", "is_admin"}).AddRow(author, tc.isAdmin))
+			if tc.expStatus == http.StatusOK {
+				mock.ExpectExec("DELETE FROM tx_comments").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			c, rec := commentContext(http.MethodDelete, "", []string{"address", "id", "commentId"},
+				[]string{multisig, "1", "2"}, tc.member)
+			h := &Handler{DB: db}
+			require.NoError(t, h.DeleteComment(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
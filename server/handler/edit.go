@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// EditTransaction lets the proposer of a pending transaction change its messages, fee,
+// memo and title. Signatures are made for the old content, so a signed transaction is
+// edited only when the request clears them.
+func (h *Handler) EditTransaction(c echo.Context) error {
+	address := c.Param("address")
+	txId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid transaction id",
+		})
+	}
+
+	req := &model.EditTransactionRequest{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Log:     err.Error(),
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	var (
+		chainID, status, memo, title string
+		recipientMode                string
+		threshold, version           int
+		createdBy                    sql.NullString
+		messagesJSON, feeJSON        []byte
+		signaturesJSON               []byte
+	)
+	err = h.DB.QueryRow(`SELECT m.chain_id,m.threshold,m.recipient_mode,t.status,t.created_by,t.messages,t.fee,COALESCE(t.memo,''),COALESCE(t.title,''),t.signatures,t.version
+	FROM transactions t JOIN multisig_accounts m ON t.multisig_address = m.address WHERE t.id=$1 AND t.multisig_address=$2 AND t.deleted_at IS NULL`,
+		txId, address).Scan(&chainID, &threshold, &recipientMode, &status, &createdBy, &messagesJSON, &feeJSON, &memo, &title, &signaturesJSON, &version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "transaction not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if !createdBy.Valid || createdBy.String != member {
+		return c.JSON(http.StatusForbidden, model.ErrorResponse{
+			Status:  "error",
+			Message: "only the proposer can edit the transaction",
+		})
+	}
+
+	if status != string(model.Pending) {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("cannot edit a %s transaction", status),
+		})
+	}
+
+	if version != req.Version {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: errTxChanged.Error(),
+			Log:     fmt.Sprintf("version %d, expected %d", version, req.Version),
+		})
+	}
+
+	var signatures []Signature
+	if err := json.Unmarshal(signaturesJSON, &signatures); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode signatures",
+			Log:     err.Error(),
+		})
+	}
+
+	if len(signatures) > 0 && !req.ClearSignatures {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: "the transaction is already signed, set clear_signatures to edit it and clear the signatures",
+		})
+	}
+
+	messages := req.Messages
+	if messages == nil {
+		if err := json.Unmarshal(messagesJSON, &messages); err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to decode messages",
+				Log:     err.Error(),
+			})
+		}
+	}
+
+	var fee model.Fees
+	if req.Fee != nil {
+		fee = *req.Fee
+	} else if err := json.Unmarshal(feeJSON, &fee); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode fee",
+			Log:     err.Error(),
+		})
+	}
+
+	newMemo, newTitle := memo, title
+	if req.Memo != nil {
+		newMemo = *req.Memo
+	}
+	if req.Title != nil {
+		newTitle = *req.Title
+	}
+
+	if err := model.ValidateMessages(messages, validateOptions(address, chainID)); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Log:     err.Error(),
+		})
+	}
+
+	if req.Messages != nil {
+		result, err := h.evaluatePolicies(address, chainID, threshold, model.Msgs(messages), 0)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to evaluate the policies of the multisig account",
+				Log:     err.Error(),
+			})
+		}
+
+		if err := result.Err(); err != nil {
+			return c.JSON(http.StatusForbidden, model.ErrorResponse{
+				Status:  "error",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	msgsbz, err := json.Marshal(messages)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode messages: invalid messages",
+			Log:     err.Error(),
+		})
+	}
+
+	feebz, err := json.Marshal(fee)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode fee: invalid fee",
+			Log:     err.Error(),
+		})
+	}
+
+	// the stored warnings are replaced, so the recipients are checked even when the
+	// messages are unchanged
+	recipientWarnings, code, err := h.checkRecipients(address, recipientMode, model.Msgs(messages))
+	if err != nil {
+		return c.JSON(code, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	sim, simErr := h.simulate(address, chainID, threshold, messages, newMemo, fee)
+	warningsbz, err := json.Marshal(append(feeWarnings(sim, simErr, fee), recipientWarnings...))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to update transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	// only the changed fields are recorded
+	oldValue, newValue := map[string]interface{}{}, map[string]interface{}{}
+	if req.Messages != nil {
+		oldValue["messages"], newValue["messages"] = json.RawMessage(messagesJSON), json.RawMessage(msgsbz)
+	}
+	if req.Fee != nil {
+		oldValue["fee"], newValue["fee"] = json.RawMessage(feeJSON), json.RawMessage(feebz)
+	}
+	if req.Memo != nil {
+		oldValue["memo"], newValue["memo"] = memo, newMemo
+	}
+	if req.Title != nil {
+		oldValue["title"], newValue["title"] = title, newTitle
+	}
+	oldValue["version"], newValue["version"] = version, version+1
+
+	err = h.inTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`UPDATE transactions SET messages=$1,fee=$2,memo=$3,title=$4,warnings=$5,signatures='[]'::jsonb,signed_at=NULL,
+		version=version+1,last_updated=$6 WHERE id=$7 AND multisig_address=$8 AND version=$9 AND status=$10 AND deleted_at IS NULL`,
+			msgsbz, feebz, newMemo, newTitle, warningsbz, time.Now().UTC(), txId, address, version, model.Pending)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := result.RowsAffected(); n == 0 {
+			return errTxChanged
+		}
+
+		if len(signatures) > 0 {
+			if err := audit.Record(tx, schema.AuditEvent{
+				MultisigAddress: address,
+				TxID:            audit.TxID(txId),
+				Actor:           member,
+				Action:          audit.SignaturesCleared,
+				OldValue:        audit.Value(map[string]interface{}{"signatures": signatures}),
+				NewValue:        audit.Value(map[string]interface{}{"signatures": []Signature{}}),
+			}); err != nil {
+				return err
+			}
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			TxID:            audit.TxID(txId),
+			Actor:           member,
+			Action:          audit.TxEdited,
+			OldValue:        audit.Value(oldValue),
+			NewValue:        audit.Value(newValue),
+		})
+	})
+	if err == errTxChanged {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to update transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   map[string]int{"version": version + 1},
+	})
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/clients"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// useNetworks runs the test from the server directory, where the chain configs are read from.
+func useNetworks(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(".."))
+	t.Cleanup(func() { require.NoError(t, os.Chdir(wd)) })
+}
+
+func TestEditTransaction(t *testing.T) {
+	useNetworks(t)
+	f := newTxFixture(t)
+	mockAccount(t)
+	simulateTx = func(chainId string, txBytes []byte) (uint64, error) { return 100000, nil }
+	t.Cleanup(func() { simulateTx = clients.SimulateTx })
+
+	proposer := f.members[0]
+	signed := `[{"address":"` + f.members[1] + `","signature":"c2ln"}]`
+
+	testCases := []struct {
+		name       string
+		body       string
+		createdBy  string
+		status     string
+		signatures string
+		updated    bool
+		expStatus  int
+	}{
+		{"memo of an unsigned transaction", `{"memo":"fixed","version":2}`, proposer, "PENDING", "[]", true, http.StatusOK},
+		{"messages and memo of an unsigned transaction", `{"messages":` + f.messages + `,"memo":"fixed","version":2}`, proposer, "PENDING", "[]", true, http.StatusOK},
+		{"signed transaction", `{"memo":"fixed","version":2}`, proposer, "PENDING", signed, false, http.StatusConflict},
+		{"signed transaction clearing the signatures", `{"memo":"fixed","version":2,"clear_signatures":true}`, proposer, "PENDING", signed, true, http.StatusOK},
+		{"outdated version", `{"memo":"fixed","version":1}`, proposer, "PENDING", "[]", false, http.StatusConflict},
+		{"changed concurrently", `{"memo":"fixed","version":2}`, proposer, "PENDING", "[]", false, http.StatusConflict},
+		{"another member", `{"memo":"fixed","version":2}`, f.members[1], "PENDING", "[]", false, http.StatusForbidden},
+		{"broadcasted transaction", `{"memo":"fixed","version":2}`, proposer, "BROADCASTING", "[]", false, http.StatusConflict},
+		{"invalid message", `{"messages":[{"typeUrl":"/cosmos.bank.v1beta1.MsgSend","value":{}}],"version":2}`, proposer, "PENDING", "[]", false, http.StatusBadRequest},
+		{"empty messages", `{"messages":[],"version":2}`, proposer, "PENDING", "[]", false, http.StatusBadRequest},
+		{"missing version", `{"memo":"fixed"}`, proposer, "PENDING", "[]", false, http.StatusBadRequest},
+		{"recipient missing from the address book", `{"messages":` + f.messages + `,"version":2}`, proposer, "PENDING", "[]", false, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mode := model.RecipientModeOff
+			if tc.expStatus == http.StatusForbidden && tc.createdBy == proposer {
+				mode = model.RecipientModeEnforce
+			}
+
+			valid := !strings.Contains(tc.body, `"messages":[]`) && strings.Contains(tc.body, "version")
+			if valid {
+				mock.ExpectQuery("SELECT m.chain_id,m.threshold,m.recipient_mode,t.status").WithArgs(1, f.multisig).WillReturnRows(
+					sqlmock.NewRows([]string{"chain_id", "threshold", "recipient_mode", "status", "created_by", "messages", "fee", "memo", "title", "signatures", "version"}).
+						AddRow("osmosis-1", 2, mode, tc.status, tc.createdBy, []byte(f.messages), []byte(f.fee), "", "", []byte(tc.signatures), 2))
+			}
+			if mode == model.RecipientModeEnforce {
+				expectPolicies(t, mock, f.multisig, nil)
+				mock.ExpectQuery("SELECT (.+) FROM address_book").WithArgs(f.multisig).WillReturnRows(sqlmock.NewRows(addressBookRowColumns))
+			}
+			if tc.updated || tc.name == "changed concurrently" {
+				if strings.Contains(tc.body, `"messages"`) {
+					expectPolicies(t, mock, f.multisig, nil)
+				}
+				f.expectPubkeys(mock)
+				updated := int64(1)
+				if !tc.updated {
+					updated = 0
+				}
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE transactions SET messages").
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "fixed", "", sqlmock.AnyArg(), sqlmock.AnyArg(), 1, f.multisig, 2, model.Pending).
+					WillReturnResult(sqlmock.NewResult(0, updated))
+				if tc.updated {
+					if tc.signatures != "[]" {
+						expectAudit(mock, f.multisig)
+					}
+					expectAudit(mock, f.multisig)
+					mock.ExpectCommit()
+				} else {
+					mock.ExpectRollback()
+				}
+			}
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("address", "id")
+			c.SetParamValues(f.multisig, "1")
+			c.Set(utils.AUTH_ADDRESS_KEY, proposer)
+
+			h := &Handler{DB: db}
+			require.NoError(t, h.EditTransaction(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
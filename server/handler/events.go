@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/events"
+	"github.com/vitwit/resolute/server/model"
+)
+
+// eventsHeartbeat is how often an idle event stream sends a comment, so that proxies
+// keep the connection open.
+var eventsHeartbeat = 25 * time.Second
+
+// subscribeEvents is replaced in tests.
+var subscribeEvents = events.Subscribe
+
+// StreamEvents streams the events of a multisig account as Server-Sent Events, until
+// the client disconnects. Clients fetch the transactions again after reconnecting to
+// catch up with the events they missed.
+func (h *Handler) StreamEvents(c echo.Context) error {
+	address := c.Param("address")
+	ctx := c.Request().Context()
+
+	stream, err := subscribeEvents(ctx, address)
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Status:  "error",
+			Message: "event stream is unavailable",
+			Log:     err.Error(),
+		})
+	}
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	w.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-stream:
+			if !ok {
+				return nil
+			}
+
+			bz, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, bz)
+			w.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		}
+	}
+}
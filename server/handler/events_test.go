@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/events"
+)
+
+func TestStreamEvents(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+
+	testCases := []struct {
+		name      string
+		subErr    error
+		expStatus int
+		expBody   []string
+	}{
+		{
+			"events of the multisig",
+			nil,
+			http.StatusOK,
+			[]string{": connected\n\n", "event: tx_created\ndata: {\"type\":\"tx_created\"", "event: threshold_reached\n", ": ping\n\n"},
+		},
+		{"redis unavailable", errors.New("redis is not configured"), http.StatusServiceUnavailable, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := make(chan events.Event)
+			subscribeEvents = func(ctx context.Context, address string) (<-chan events.Event, error) {
+				require.Equal(t, multisig, address)
+				return stream, tc.subErr
+			}
+			eventsHeartbeat = 10 * time.Millisecond
+			t.Cleanup(func() {
+				subscribeEvents = events.Subscribe
+				eventsHeartbeat = 25 * time.Second
+			})
+
+			if tc.subErr == nil {
+				go func() {
+					stream <- events.Event{Type: events.TxCreated, MultisigAddress: multisig, TxID: 1}
+					stream <- events.Event{Type: events.ThresholdReached, MultisigAddress: multisig, TxID: 1}
+					time.Sleep(50 * time.Millisecond)
+					close(stream)
+				}()
+			}
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+			c.SetParamNames("address")
+			c.SetParamValues(multisig)
+
+			h := &Handler{}
+			require.NoError(t, h.StreamEvents(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+
+			body := rec.Body.String()
+			for _, s := range tc.expBody {
+				require.True(t, strings.Contains(body, s), body)
+			}
+			if tc.expStatus == http.StatusOK {
+				require.Equal(t, "text/event-stream", rec.Header().Get(echo.HeaderContentType))
+			}
+		})
+	}
+}
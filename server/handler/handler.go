@@ -1,10 +1,15 @@
 package handler
 
-import "database/sql"
+import (
+	"database/sql"
+	"time"
+)
 
 type (
 	// wrapper for database instance
 	Handler struct {
 		DB *sql.DB
+		// RetentionPeriod is how long deleted accounts and transactions can be restored
+		RetentionPeriod time.Duration
 	}
 )
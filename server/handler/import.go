@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/config"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// importMsg returns the MsgSend of a row of an imported CSV file, checking the recipient
+// against the prefix of the chain and the denom against its currencies.
+func importMsg(address string, row model.ImportRow, prefix string, chain *config.ChainConfig) (model.Message, error) {
+	msg := model.Message{
+		TypeUrl: cosmos.MsgSendType.TypeUrl,
+		Value: map[string]interface{}{
+			"fromAddress": address,
+			"toAddress":   row.Address,
+			"amount":      []interface{}{map[string]interface{}{"denom": row.Denom, "amount": row.Amount}},
+		},
+	}
+
+	v := cosmos.Value(msg.Value)
+	if _, err := v.Address("toAddress", prefix); err != nil {
+		return msg, err
+	}
+	if err := v.Coins("amount", true); err != nil {
+		return msg, err
+	}
+
+	// tokens from other chains and the chain's own token factory are not listed
+	if chain == nil || len(chain.Currencies) == 0 || strings.HasPrefix(row.Denom, "ibc/") || strings.HasPrefix(row.Denom, "factory/") {
+		return msg, nil
+	}
+
+	for _, currency := range chain.Currencies {
+		if currency.Denom == row.Denom {
+			return msg, nil
+		}
+		if strings.EqualFold(currency.DisplayDenom, row.Denom) {
+			return msg, fmt.Errorf("amount must be in the base denom %s rather than %s", currency.Denom, row.Denom)
+		}
+	}
+
+	return msg, fmt.Errorf("denom %s is not a currency of %s", row.Denom, chain.ChainId)
+}
+
+// importBatch is the messages of a transaction of an import.
+type importBatch struct {
+	Memo     string
+	Messages []model.Message
+}
+
+// importBatches groups the messages by the memo of their rows, in the order the memos
+// first appear, and splits the groups into transactions of at most perTx messages.
+func importBatches(rows []model.ImportRow, msgs []model.Message, perTx int) []importBatch {
+	var memos []string
+	groups := make(map[string][]model.Message)
+	for i, row := range rows {
+		if _, ok := groups[row.Memo]; !ok {
+			memos = append(memos, row.Memo)
+		}
+		groups[row.Memo] = append(groups[row.Memo], msgs[i])
+	}
+
+	var batches []importBatch
+	for _, memo := range memos {
+		group := groups[memo]
+		for start := 0; start < len(group); start += perTx {
+			end := start + perTx
+			if end > len(group) {
+				end = len(group)
+			}
+			batches = append(batches, importBatch{Memo: memo, Messages: group[start:end]})
+		}
+	}
+
+	return batches
+}
+
+// ImportTransactions proposes the payments of a CSV file, split into as many pending
+// transactions as the message and gas budgets require. Nothing is proposed when a row
+// is invalid.
+func (h *Handler) ImportTransactions(c echo.Context) error {
+	address := c.Param("address")
+
+	req := &model.ImportTxReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	proposer, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid proposer address",
+			Log:     err.Error(),
+		})
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "csv file is required",
+			Log:     err.Error(),
+		})
+	}
+
+	if fh.Size > model.MaxImportFileSize {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("csv file cannot be larger than %d bytes", model.MaxImportFileSize),
+		})
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to read csv file",
+			Log:     err.Error(),
+		})
+	}
+	defer f.Close()
+
+	rows, rowErrors, err := model.ParseImportCSV(io.LimitReader(f, model.MaxImportFileSize))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	chainID := h.multisigChainID(address)
+	if chainID == "" {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("invalid multisig account address %s: not found", address),
+		})
+	}
+
+	// chains missing from the networks file are checked against their prefix only
+	chain, _ := utils.GetChainAPIs(chainID)
+
+	total := len(rows) + len(rowErrors)
+	prefix, _, _ := cosmos.DecodeAddress(address)
+	msgs := make([]model.Message, 0, len(rows))
+	for _, row := range rows {
+		msg, err := importMsg(address, row, prefix, chain)
+		if err != nil {
+			rowErrors = append(rowErrors, model.ImportRowError{Row: row.Row, Error: err.Error()})
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if len(rowErrors) > 0 {
+		sort.Slice(rowErrors, func(i, j int) bool { return rowErrors[i].Row < rowErrors[j].Row })
+		return c.JSON(http.StatusBadRequest, model.ImportErrorResponse{
+			ErrorResponse: model.ErrorResponse{
+				Status:  "error",
+				Message: fmt.Sprintf("%d of %d rows are invalid", len(rowErrors), total),
+			},
+			Errors: rowErrors,
+		})
+	}
+
+	var gasPrice cosmos.GasPrice
+	switch {
+	case req.GasPrice != "":
+		if gasPrice, err = cosmos.ParseGasPrice(req.GasPrice); err != nil {
+			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Status:  "error",
+				Message: err.Error(),
+			})
+		}
+	case chain != nil && len(chain.GasPrices) > 0:
+		gasPrice = cosmos.GasPrice{Denom: chain.GasPrices[0].Denom, Amount: chain.GasPrices[0].Amount}
+	default:
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("gas_price is required, %s has no default gas price", chainID),
+		})
+	}
+
+	title := req.Title
+	if title == "" {
+		title = fmt.Sprintf("Payments to %d recipients", len(msgs))
+	}
+
+	batches := importBatches(rows, msgs, req.MessagesPerTx())
+	ids := make([]int, 0, len(batches))
+	for i, batch := range batches {
+		gas := req.GasPerMessage * uint64(len(batch.Messages))
+		fee, err := gasPrice.Fee(gas)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Status:  "error",
+				Message: err.Error(),
+			})
+		}
+
+		txReq := &model.CreateTransactionRequest{
+			ChainId:  chainID,
+			Messages: batch.Messages,
+			Fee: model.Fees{
+				Amount: []model.Fee{{Denom: fee.Denom, Amount: fee.Amount}},
+				Gas:    strconv.FormatUint(gas, 10),
+			},
+			Memo:  batch.Memo,
+			Title: title,
+		}
+		if len(batches) > 1 {
+			txReq.Title = fmt.Sprintf("%s (%d/%d)", title, i+1, len(batches))
+		}
+
+		id, err := h.proposeTransaction(address, proposer, txReq)
+		if err != nil {
+			status, resp := proposalResponse(err)
+			resp.Message = fmt.Sprintf("transaction %d of %d: %s", i+1, len(batches), resp.Message)
+			return c.JSON(status, model.ImportErrorResponse{ErrorResponse: resp, Created: ids})
+		}
+		ids = append(ids, id)
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Data:    ids,
+		Message: "transactions created",
+		Count:   len(ids),
+	})
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// importContext returns an echo context uploading the CSV with the form fields.
+func importContext(t *testing.T, multisig, member, csv string, fields map[string]string) (echo.Context, *httptest.ResponseRecorder) {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for k, v := range fields {
+		require.NoError(t, w.WriteField(k, v))
+	}
+	if csv != "" {
+		fw, err := w.CreateFormFile("file", "payments.csv")
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(csv))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("address")
+	c.SetParamValues(multisig)
+	c.Set(utils.AUTH_ADDRESS_KEY, member)
+
+	return c, rec
+}
+
+func TestImportTransactions(t *testing.T) {
+	useNetworks(t)
+	multisig := testAddress(t, "osmo", 0x0f)
+	proposer := testAddress(t, "osmo", 0x01)
+	recipient := testAddress(t, "osmo", 0x03)
+
+	testCases := []struct {
+		name      string
+		csv       string
+		fields    map[string]string
+		expStatus int
+		expRows   []int
+	}{
+		{
+			"invalid rows",
+			"address,amount,denom,memo\n" +
+				recipient + ",100,uosmo,March\n" +
+				testAddress(t, "juno", 0x03) + ",100,ujuno\n" +
+				recipient + ",1.5,OSMO\n" +
+				recipient + ",100,uatom\n" +
+				recipient + ",100\n" +
+				recipient + ",-5,uosmo\n",
+			nil,
+			http.StatusBadRequest,
+			[]int{3, 4, 5, 6, 7},
+		},
+		{"missing file", "", nil, http.StatusBadRequest, nil},
+		{"empty file", "address,amount,denom\n", nil, http.StatusBadRequest, nil},
+		{"gas budget below a message", recipient + ",100,uosmo\n", map[string]string{"max_gas": "1000"}, http.StatusBadRequest, nil},
+		{"invalid gas price", recipient + ",100,uosmo\n", map[string]string{"gas_price": "uosmo"}, http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			if tc.expRows != nil || tc.fields["gas_price"] != "" {
+				mock.ExpectQuery("SELECT chain_id FROM multisig_accounts").WithArgs(multisig).WillReturnRows(
+					sqlmock.NewRows([]string{"chain_id"}).AddRow("osmosis-1"))
+			}
+
+			c, rec := importContext(t, multisig, proposer, tc.csv, tc.fields)
+			h := &Handler{DB: db}
+			require.NoError(t, h.ImportTransactions(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			if tc.expRows != nil {
+				var resp model.ImportErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				rows := make([]int, 0, len(resp.Errors))
+				for _, e := range resp.Errors {
+					rows = append(rows, e.Row)
+				}
+				require.Equal(t, tc.expRows, rows)
+			}
+		})
+	}
+}
+
+func TestImportBatches(t *testing.T) {
+	memos := []string{"March", "", "March", "April", "March", "March"}
+	rows := make([]model.ImportRow, 0, len(memos))
+	msgs := make([]model.Message, 0, len(memos))
+	for i, memo := range memos {
+		rows = append(rows, model.ImportRow{Row: i + 1, Memo: memo})
+		msgs = append(msgs, model.Message{Value: map[string]interface{}{"row": i + 1}})
+	}
+
+	batches := importBatches(rows, msgs, 2)
+	var got [][]interface{}
+	for _, batch := range batches {
+		var batchRows []interface{}
+		for _, msg := range batch.Messages {
+			require.Equal(t, memos[msg.Value["row"].(int)-1], batch.Memo)
+			batchRows = append(batchRows, msg.Value["row"])
+		}
+		got = append(got, batchRows)
+	}
+
+	require.Equal(t, [][]interface{}{{1, 3}, {5, 6}, {2}, {4}}, got)
+}
+
+func TestImportTxReqMessagesPerTx(t *testing.T) {
+	req := model.ImportTxReq{}
+	require.NoError(t, req.Validate())
+	require.Equal(t, model.DefaultImportMaxMessages, req.MessagesPerTx())
+
+	req = model.ImportTxReq{MaxMessages: 50, GasPerMessage: 100000, MaxGas: 2500000}
+	require.NoError(t, req.Validate())
+	require.Equal(t, 25, req.MessagesPerTx())
+
+	req = model.ImportTxReq{MaxMessages: model.MaxImportMessages + 1}
+	require.Error(t, req.Validate())
+}
@@ -10,39 +10,69 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/cosmos"
 	"github.com/vitwit/resolute/server/model"
 	"github.com/vitwit/resolute/server/schema"
 	"github.com/vitwit/resolute/server/utils"
 )
 
 func (h *Handler) CreateMultisigAccount(c echo.Context) error {
-	ctx := context.Background()
+	account := &model.CreateAccountReq{}
+	if err := c.Bind(account); err != nil {
+		return err
+	}
 
-	tx, err := h.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+	if err := account.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Status:  "error",
-			Message: "failed to initialize transaction",
-			Log:     err.Error(),
+			Message: err.Error(),
 		})
 	}
 
-	account := &model.CreateAccountReq{}
-	if err := c.Bind(account); err != nil {
-		return err
+	if !cosmos.SameAccount(account.CreatedBy, utils.GetAuthAddress(c)) {
+		return c.JSON(http.StatusForbidden, model.ErrorResponse{
+			Status:  "error",
+			Message: "createdBy does not match the authenticated address",
+		})
 	}
 
-	if err := account.Validate(); err != nil {
+	chain, err := utils.GetChainAPIs(account.ChainId)
+	if err != nil || chain.Bech32Prefix == "" {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("chain %s is not supported", account.ChainId),
+		})
+	}
+
+	if err := account.VerifyAddress(chain.Bech32Prefix); err != nil {
 		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Status:  "error",
 			Message: err.Error(),
 		})
 	}
 
+	ctx := context.Background()
+
+	tx, err := h.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to initialize transaction",
+			Log:     err.Error(),
+		})
+	}
+	defer tx.Rollback()
+
+	visibility := account.Visibility
+	if visibility == "" {
+		visibility = model.VisibilityPrivate
+	}
+
 	_, err = tx.ExecContext(ctx, `INSERT INTO "multisig_accounts"("address","name","pubkey_type","threshold","chain_id",
-	"created_by","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7)`,
+	"created_by","created_at","visibility") VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
 		account.Address, account.Name, account.Pubkeys[0].Pubkey.TypeUrl, account.Threshold, account.ChainId, account.CreatedBy,
-		time.Now().UTC(),
+		time.Now().UTC(), visibility,
 	)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key") {
@@ -90,6 +120,38 @@ func (h *Handler) CreateMultisigAccount(c echo.Context) error {
 		}
 	}
 
+	if err := insertDefaultRoles(ctx, tx, account); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to store roles",
+			Log:     err.Error(),
+		})
+	}
+
+	members := make([]string, 0, len(account.Pubkeys))
+	for _, pubkey := range account.Pubkeys {
+		members = append(members, pubkey.Address)
+	}
+
+	if err := audit.Record(tx, schema.AuditEvent{
+		MultisigAddress: account.Address,
+		Actor:           auditActor(c, account.Address),
+		Action:          audit.AccountCreated,
+		NewValue: audit.Value(map[string]interface{}{
+			"name":       account.Name,
+			"threshold":  account.Threshold,
+			"chain_id":   account.ChainId,
+			"pubkeys":    members,
+			"visibility": visibility,
+		}),
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to create multisig account",
+			Log:     err.Error(),
+		})
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
@@ -105,6 +167,38 @@ func (h *Handler) CreateMultisigAccount(c echo.Context) error {
 	})
 }
 
+// insertDefaultRoles makes the creator the admin of the account and lets every
+// pubkey member propose and sign transactions.
+func insertDefaultRoles(ctx context.Context, tx *sql.Tx, account *model.CreateAccountReq) error {
+	prefix, _, err := cosmos.DecodeAddress(account.Address)
+	if err != nil {
+		return err
+	}
+
+	createdBy, err := cosmos.ConvertAddress(account.CreatedBy, prefix)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	query := `INSERT INTO "multisig_roles"("multisig_address","address","role","granted_by","created_at")
+	VALUES ($1,$2,$3,$4,$5) ON CONFLICT DO NOTHING`
+
+	if _, err := tx.ExecContext(ctx, query, account.Address, createdBy, model.RoleAdmin, createdBy, now); err != nil {
+		return err
+	}
+
+	for _, pubkey := range account.Pubkeys {
+		for _, role := range []string{model.RoleProposer, model.RoleSigner} {
+			if _, err := tx.ExecContext(ctx, query, account.Address, pubkey.Address, role, createdBy, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 type AccountsResponse struct {
 	Accounts    []schema.MultisigAccount `json:"accounts"`
 	Total       int                      `json:"total"`
@@ -128,10 +222,23 @@ func (h *Handler) GetMultisigAccounts(c echo.Context) error {
 		})
 	}
 
+	includeDeleted, err := utils.ParseIncludeDeleted(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid include_deleted",
+			Log:     err.Error(),
+		})
+	}
+
+	// only the owner of the address can see its private accounts
+	owner := cosmos.SameAccount(address, utils.GetAuthAddress(c))
+
 	rows, err := h.DB.Query(`SELECT ma.address,ma.threshold,ma.chain_id,ma.pubkey_type,ma.created_at,
-	ma.name,ma.created_by FROM pubkeys as pk INNER JOIN 
-	multisig_accounts as ma ON pk.multisig_address=ma.address WHERE 
-	pk.address=$1 ORDER BY ma.created_at ASC LIMIT $2 OFFSET $3`, address, limit, (page-1)*limit)
+	ma.name,ma.created_by,ma.visibility,ma.deleted_at FROM multisig_accounts as ma WHERE ma.address IN 
+	(SELECT multisig_address FROM pubkeys WHERE address=$1 UNION SELECT multisig_address FROM multisig_roles WHERE address=$1)
+	AND (ma.visibility=$4 OR $5) AND (ma.deleted_at IS NULL OR $6) ORDER BY ma.created_at ASC LIMIT $2 OFFSET $3`,
+		address, limit, (page-1)*limit, model.VisibilityPublic, owner, includeDeleted)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Status:  "error",
@@ -152,6 +259,8 @@ func (h *Handler) GetMultisigAccounts(c echo.Context) error {
 			&account.CreatedAt,
 			&account.Name,
 			&account.CreatedBy,
+			&account.Visibility,
+			&account.DeletedAt,
 		); err != nil {
 			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 				Status:  "error",
@@ -188,7 +297,7 @@ func (h *Handler) GetMultisigAccounts(c echo.Context) error {
 
 	txCounts := make(map[string]int)
 	for _, ac := range accounts {
-		rows, err := h.DB.Query(`SELECT count(*) from transactions where multisig_address=$1 and status=$2`, ac.Address, model.Pending)
+		rows, err := h.DB.Query(`SELECT count(*) from transactions where multisig_address=$1 and status=$2 and deleted_at IS NULL`, ac.Address, model.Pending)
 		if err != nil {
 			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
 				Status:  "error",
@@ -229,7 +338,7 @@ type MultisigAccountResponse struct {
 func (h *Handler) GetMultisigAccount(c echo.Context) error {
 	address := c.Param("address")
 
-	row := h.DB.QueryRow(`SELECT address,threshold,chain_id,pubkey_type,created_at,name,created_by FROM
+	row := h.DB.QueryRow(`SELECT address,threshold,chain_id,pubkey_type,created_at,name,created_by,visibility,deleted_at,recipient_mode FROM
 	 multisig_accounts WHERE address=$1`, address)
 	if row.Err() != nil {
 		if sql.ErrNoRows == row.Err() {
@@ -255,6 +364,9 @@ func (h *Handler) GetMultisigAccount(c echo.Context) error {
 		&account.CreatedAt,
 		&account.Name,
 		&account.CreatedBy,
+		&account.Visibility,
+		&account.DeletedAt,
+		&account.RecipientMode,
 	); err != nil {
 		if sql.ErrNoRows.Error() == err.Error() {
 			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
@@ -306,7 +418,7 @@ func (h *Handler) GetMultisigAccount(c echo.Context) error {
 func (h *Handler) DeleteMultisigAccount(c echo.Context) error {
 	address := c.Param("address")
 
-	row := h.DB.QueryRow(`SELECT address,threshold,chain_id,pubkey_type,created_at,name,created_by FROM
+	row := h.DB.QueryRow(`SELECT address,threshold,chain_id,pubkey_type,created_at,name,created_by,deleted_at FROM
 	 multisig_accounts WHERE address=$1`, address)
 	if row.Err() != nil {
 		if sql.ErrNoRows == row.Err() {
@@ -333,6 +445,7 @@ func (h *Handler) DeleteMultisigAccount(c echo.Context) error {
 		&account.CreatedAt,
 		&account.Name,
 		&account.CreatedBy,
+		&account.DeletedAt,
 	); err != nil {
 		if sql.ErrNoRows.Error() == err.Error() {
 			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
@@ -349,54 +462,214 @@ func (h *Handler) DeleteMultisigAccount(c echo.Context) error {
 		})
 	}
 
-	ctx := context.Background()
-
-	tx, err := h.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+	if account.DeletedAt != nil {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
 			Status:  "error",
-			Message: "failed to initialize transaction",
-			Log:     err.Error(),
+			Message: "multisig account is already deleted",
 		})
 	}
 
-	_, err = tx.ExecContext(ctx, `DELETE from transactions WHERE multisig_address=$1`, address)
+	// The account is archived: it is left out of listings and read-only until it is
+	// restored or purged at the end of the retention period.
+	deletedAt := time.Now().UTC()
+	err := h.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`UPDATE multisig_accounts SET deleted_at=$1 WHERE address=$2`, deletedAt, address); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           auditActor(c, address),
+			Action:          audit.AccountDeleted,
+			OldValue: audit.Value(map[string]interface{}{
+				"name":       account.Name,
+				"threshold":  account.Threshold,
+				"chain_id":   account.ChainID,
+				"created_by": account.CreatedBy,
+			}),
+			NewValue: audit.Value(map[string]interface{}{"deleted_at": deletedAt}),
+		})
+	})
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Status:  "error",
-			Message: "failed to delete transactions for multisig account",
+			Message: "failed to delete multisig account",
 			Log:     fmt.Sprintf("address: %s, error: %s", address, err.Error()),
 		})
 	}
 
-	_, err = tx.ExecContext(ctx, `DELETE from pubkeys WHERE multisig_address=$1`, address)
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "multisig account deleted",
+		Message: fmt.Sprintf("the account can be restored until %s", deletedAt.Add(h.RetentionPeriod).Format(time.RFC3339)),
+	})
+}
+
+// RestoreMultisigAccount brings back a deleted multisig account within the retention period.
+func (h *Handler) RestoreMultisigAccount(c echo.Context) error {
+	address := c.Param("address")
+
+	var (
+		deletedAt *time.Time
+		gone      bool
+	)
+	err := h.inTx(func(tx *sql.Tx) error {
+		if err := tx.QueryRow(`SELECT deleted_at FROM multisig_accounts WHERE address=$1 FOR UPDATE`, address).Scan(&deletedAt); err != nil {
+			return err
+		}
+
+		if deletedAt == nil {
+			return nil
+		}
+
+		if !utils.Restorable(*deletedAt, h.RetentionPeriod) {
+			gone = true
+			return nil
+		}
+
+		if _, err := tx.Exec(`UPDATE multisig_accounts SET deleted_at=NULL WHERE address=$1`, address); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           auditActor(c, address),
+			Action:          audit.AccountRestored,
+			OldValue:        audit.Value(map[string]interface{}{"deleted_at": deletedAt}),
+		})
+	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: fmt.Sprintf("no accounts with address %s", address),
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Status:  "error",
-			Message: "failed to delete public keys for multisig account",
-			Log:     fmt.Sprintf("address: %s, error: %s", address, err.Error()),
+			Message: "failed to restore multisig account",
+			Log:     err.Error(),
+		})
+	}
+
+	if deletedAt == nil {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: "multisig account is not deleted",
+		})
+	}
+
+	if gone {
+		return c.JSON(http.StatusGone, model.ErrorResponse{
+			Status:  "error",
+			Message: "the retention period of the multisig account is over",
 		})
 	}
 
-	_, err = tx.ExecContext(ctx, `DELETE from multisig_accounts WHERE address=$1`, address)
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "multisig account restored",
+	})
+}
+
+func (h *Handler) UpdateMultisigVisibility(c echo.Context) error {
+	address := c.Param("address")
+
+	req := &model.UpdateVisibilityReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	err := h.inTx(func(tx *sql.Tx) error {
+		var visibility string
+		if err := tx.QueryRow(`SELECT visibility FROM multisig_accounts WHERE address=$1 FOR UPDATE`, address).Scan(&visibility); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE multisig_accounts SET visibility=$1 WHERE address=$2`, req.Visibility, address); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           auditActor(c, address),
+			Action:          audit.VisibilityUpdated,
+			OldValue:        audit.Value(map[string]string{"visibility": visibility}),
+			NewValue:        audit.Value(map[string]string{"visibility": req.Visibility}),
+		})
+	})
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Status:  "error",
-			Message: "failed to delete multisig account",
-			Log:     fmt.Sprintf("address: %s, error: %s", address, err.Error()),
+			Message: "failed to update visibility",
+			Log:     err.Error(),
 		})
 	}
 
-	err = tx.Commit()
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Message: "visibility updated",
+	})
+}
+
+// UpdateRecipientMode sets how transactions sending to addresses missing from the
+// address book of the multisig account are handled.
+func (h *Handler) UpdateRecipientMode(c echo.Context) error {
+	address := c.Param("address")
+
+	req := &model.UpdateRecipientModeReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	err := h.inTx(func(tx *sql.Tx) error {
+		var mode string
+		if err := tx.QueryRow(`SELECT recipient_mode FROM multisig_accounts WHERE address=$1 FOR UPDATE`, address).Scan(&mode); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE multisig_accounts SET recipient_mode=$1 WHERE address=$2`, req.Mode, address); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           auditActor(c, address),
+			Action:          audit.RecipientModeUpdated,
+			OldValue:        audit.Value(map[string]string{"recipient_mode": mode}),
+			NewValue:        audit.Value(map[string]string{"recipient_mode": req.Mode}),
+		})
+	})
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Status:  "error",
-			Message: "failed to commit database transactions",
+			Message: "failed to update recipient mode",
 			Log:     err.Error(),
 		})
 	}
 
 	return c.JSON(http.StatusOK, model.SuccessResponse{
-		Status: "multisig account deleted",
+		Status:  "success",
+		Message: "recipient mode updated",
 	})
 }
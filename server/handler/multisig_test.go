@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+func TestRestoreMultisigAccount(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	admin := testAddress(t, "osmo", 0x01)
+
+	testCases := []struct {
+		name      string
+		found     bool
+		deletedAt driver.Value
+		expStatus int
+	}{
+		{"deleted account", true, time.Now().Add(-time.Hour), http.StatusOK},
+		{"account not deleted", true, nil, http.StatusConflict},
+		{"retention period over", true, time.Now().Add(-48 * time.Hour), http.StatusGone},
+		{"missing account", false, nil, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			rows := sqlmock.NewRows([]string{"deleted_at"})
+			if tc.found {
+				rows.AddRow(tc.deletedAt)
+			}
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT deleted_at FROM multisig_accounts").WithArgs(multisig).WillReturnRows(rows)
+			switch tc.expStatus {
+			case http.StatusOK:
+				mock.ExpectExec("UPDATE multisig_accounts SET deleted_at=NULL").WithArgs(multisig).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, multisig)
+				mock.ExpectCommit()
+			case http.StatusNotFound:
+				mock.ExpectRollback()
+			default:
+				mock.ExpectCommit()
+			}
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+			c.SetParamNames("address")
+			c.SetParamValues(multisig)
+			c.Set(utils.AUTH_ADDRESS_KEY, admin)
+
+			h := &Handler{DB: db, RetentionPeriod: 24 * time.Hour}
+			require.NoError(t, h.RestoreMultisigAccount(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,475 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/policy"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/summary"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+var (
+	errPolicyChangeDecided = errors.New("the policy change was already decided")
+	errSelfApproval        = errors.New("a policy change must be approved by another admin")
+)
+
+// outflowWindow is the period the daily outflow of a multisig account is computed over.
+const outflowWindow = 24 * time.Hour
+
+// loadPolicies returns the active policies of a multisig account and the details they
+// are evaluated with.
+func (h *Handler) loadPolicies(address, chainID string, threshold int) ([]policy.Policy, policy.Account, error) {
+	account := policy.Account{Threshold: threshold}
+
+	rows, err := h.DB.Query(`SELECT id,type,params FROM multisig_policies WHERE multisig_address=$1 ORDER BY id`, address)
+	if err != nil {
+		return nil, account, err
+	}
+	defer rows.Close()
+
+	var policies []policy.Policy
+	outflow := false
+	for rows.Next() {
+		var (
+			p      policy.Policy
+			params []byte
+		)
+		if err := rows.Scan(&p.ID, &p.Type, &params); err != nil {
+			return nil, account, err
+		}
+		if err := json.Unmarshal(params, &p.Params); err != nil {
+			return nil, account, fmt.Errorf("invalid stored policy %d: %w", p.ID, err)
+		}
+
+		outflow = outflow || p.Type == policy.DailyOutflow
+		policies = append(policies, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, account, err
+	}
+	rows.Close()
+
+	if len(policies) == 0 {
+		return nil, account, nil
+	}
+
+	account.Chain = summaryChain(chainID)
+
+	if account.Members, err = h.multisigMembers(address); err != nil {
+		return nil, account, err
+	}
+
+	if outflow {
+		if account.Outflow, err = h.recentOutflow(address); err != nil {
+			return nil, account, err
+		}
+	}
+
+	return policies, account, nil
+}
+
+func (h *Handler) multisigMembers(address string) ([]string, error) {
+	rows, err := h.DB.Query(`SELECT address FROM pubkeys WHERE multisig_address=$1`, address)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []string
+	for rows.Next() {
+		var member string
+		if err := rows.Scan(&member); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// recentOutflow returns the amounts sent by the transactions of the multisig account
+// broadcast in the outflow window, by denom.
+func (h *Handler) recentOutflow(address string) (map[string]*big.Int, error) {
+	rows, err := h.DB.Query(`SELECT messages FROM transactions WHERE multisig_address=$1 AND status IN ($2,$3) AND last_updated>$4`,
+		address, model.Success, model.Broadcasting, time.Now().UTC().Add(-outflowWindow))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []policy.Transfer
+	for rows.Next() {
+		var messages []byte
+		if err := rows.Scan(&messages); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, policy.Transfers(summary.DecodeJSON(messages))...)
+	}
+
+	return policy.Totals(transfers), rows.Err()
+}
+
+// evaluatePolicies evaluates the active policies of a multisig account against the
+// messages of a transaction with the given number of signatures.
+func (h *Handler) evaluatePolicies(address, chainID string, threshold int, msgs []cosmos.Msg, signatures int) (policy.Result, error) {
+	policies, account, err := h.loadPolicies(address, chainID, threshold)
+	if err != nil {
+		return policy.Result{}, err
+	}
+
+	return policy.Evaluate(policies, msgs, signatures, account), nil
+}
+
+// policyStatus evaluates the policies against a stored transaction, showing the
+// requirements it still has to meet. It is nil for transactions no longer waiting for
+// signatures, or when the account has no policies.
+func policyStatus(policies []policy.Policy, account policy.Account, status string, messages, signatures []byte) *policy.Result {
+	if len(policies) == 0 || (status != string(model.Pending) && status != string(model.Stale)) {
+		return nil
+	}
+
+	var sigs []Signature
+	_ = json.Unmarshal(signatures, &sigs)
+
+	result := policy.Evaluate(policies, summary.DecodeJSON(messages), len(sigs), account)
+	return &result
+}
+
+type PoliciesResponse struct {
+	Policies []schema.MultisigPolicy `json:"policies"`
+	Changes  []schema.PolicyChange   `json:"changes"`
+}
+
+const policyChangeColumns = `id,multisig_address,action,policy_id,type,params,status,proposed_by,decided_by,created_at,decided_at`
+
+func scanPolicyChange(row interface{ Scan(...interface{}) error }) (schema.PolicyChange, error) {
+	var change schema.PolicyChange
+	err := row.Scan(
+		&change.ID,
+		&change.MultisigAddress,
+		&change.Action,
+		&change.PolicyID,
+		&change.Type,
+		&change.Params,
+		&change.Status,
+		&change.ProposedBy,
+		&change.DecidedBy,
+		&change.CreatedAt,
+		&change.DecidedAt,
+	)
+
+	return change, err
+}
+
+// GetPolicies returns the active policies of a multisig account and the changes waiting
+// for approval.
+func (h *Handler) GetPolicies(c echo.Context) error {
+	address := c.Param("address")
+
+	rows, err := h.DB.Query(`SELECT id,multisig_address,type,params,created_by,approved_by,created_at FROM multisig_policies
+	WHERE multisig_address=$1 ORDER BY id`, address)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query policies",
+			Log:     err.Error(),
+		})
+	}
+	defer rows.Close()
+
+	resp := PoliciesResponse{
+		Policies: make([]schema.MultisigPolicy, 0),
+		Changes:  make([]schema.PolicyChange, 0),
+	}
+	for rows.Next() {
+		var p schema.MultisigPolicy
+		if err := rows.Scan(&p.ID, &p.MultisigAddress, &p.Type, &p.Params, &p.CreatedBy, &p.ApprovedBy, &p.CreatedAt); err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to decode policies",
+				Log:     err.Error(),
+			})
+		}
+		resp.Policies = append(resp.Policies, p)
+	}
+	rows.Close()
+
+	rows, err = h.DB.Query(`SELECT `+policyChangeColumns+` FROM policy_changes WHERE multisig_address=$1 AND status=$2 ORDER BY id`,
+		address, model.PolicyChangePending)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query policy changes",
+			Log:     err.Error(),
+		})
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		change, err := scanPolicyChange(rows)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to decode policy changes",
+				Log:     err.Error(),
+			})
+		}
+		resp.Changes = append(resp.Changes, change)
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   resp,
+	})
+}
+
+// ProposePolicyChange proposes to add or remove a policy. The change takes effect once
+// another admin approves it.
+func (h *Handler) ProposePolicyChange(c echo.Context) error {
+	address := c.Param("address")
+
+	req := &model.PolicyChangeReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	proposer, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	var policyID *int
+	if req.Action == model.PolicyRemove {
+		var policyType string
+		var params []byte
+		err := h.DB.QueryRow(`SELECT type,params FROM multisig_policies WHERE id=$1 AND multisig_address=$2`, req.PolicyID, address).
+			Scan(&policyType, &params)
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "policy not found",
+			})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to query policy",
+				Log:     err.Error(),
+			})
+		}
+
+		// the change shows the policy it removes
+		req.Type = policyType
+		if err := json.Unmarshal(params, &req.Params); err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to decode policy",
+				Log:     err.Error(),
+			})
+		}
+		policyID = &req.PolicyID
+	}
+
+	params, err := json.Marshal(req.Params)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid params",
+			Log:     err.Error(),
+		})
+	}
+
+	var change schema.PolicyChange
+	err = h.inTx(func(tx *sql.Tx) error {
+		change, err = scanPolicyChange(tx.QueryRow(`INSERT INTO policy_changes ("multisig_address","action","policy_id","type","params","status","proposed_by","created_at")
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING `+policyChangeColumns,
+			address, req.Action, policyID, req.Type, params, model.PolicyChangePending, proposer, time.Now().UTC()))
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           proposer,
+			Action:          audit.PolicyProposed,
+			NewValue: audit.Value(map[string]interface{}{
+				"change_id": change.ID,
+				"action":    req.Action,
+				"policy_id": policyID,
+				"type":      req.Type,
+				"params":    json.RawMessage(params),
+			}),
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to propose policy change",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Data:    change,
+		Message: "policy change waiting for the approval of another admin",
+	})
+}
+
+// ApprovePolicyChange applies a pending policy change. It can not be approved by the
+// admin who proposed it.
+func (h *Handler) ApprovePolicyChange(c echo.Context) error {
+	return h.decidePolicyChange(c, true)
+}
+
+// RejectPolicyChange discards a pending policy change.
+func (h *Handler) RejectPolicyChange(c echo.Context) error {
+	return h.decidePolicyChange(c, false)
+}
+
+func (h *Handler) decidePolicyChange(c echo.Context, approve bool) error {
+	address := c.Param("address")
+	changeId, err := strconv.Atoi(c.Param("changeId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid policy change id",
+		})
+	}
+
+	admin, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	status, action := model.PolicyChangeRejected, audit.PolicyRejected
+	if approve {
+		status, action = model.PolicyChangeApproved, audit.PolicyApproved
+	}
+
+	var change schema.PolicyChange
+	err = h.inTx(func(tx *sql.Tx) error {
+		change, err = scanPolicyChange(tx.QueryRow(`SELECT `+policyChangeColumns+` FROM policy_changes WHERE id=$1 AND multisig_address=$2 FOR UPDATE`,
+			changeId, address))
+		if err != nil {
+			return err
+		}
+
+		if change.Status != model.PolicyChangePending {
+			return errPolicyChangeDecided
+		}
+
+		if approve {
+			if change.ProposedBy == admin {
+				return errSelfApproval
+			}
+
+			if err := applyPolicyChange(tx, &change, admin); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UTC()
+		if _, err := tx.Exec(`UPDATE policy_changes SET status=$1,policy_id=$2,decided_by=$3,decided_at=$4 WHERE id=$5`,
+			status, change.PolicyID, admin, now, change.ID); err != nil {
+			return err
+		}
+		change.Status, change.DecidedBy, change.DecidedAt = status, &admin, &now
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           admin,
+			Action:          action,
+			NewValue: audit.Value(map[string]interface{}{
+				"change_id": change.ID,
+				"action":    change.Action,
+				"policy_id": change.PolicyID,
+				"type":      change.Type,
+				"params":    change.Params,
+			}),
+		})
+	})
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "policy change not found",
+			})
+		case errPolicyChangeDecided:
+			return c.JSON(http.StatusConflict, model.ErrorResponse{
+				Status:  "error",
+				Message: err.Error(),
+			})
+		case errSelfApproval:
+			return c.JSON(http.StatusForbidden, model.ErrorResponse{
+				Status:  "error",
+				Message: err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to update policy change",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   change,
+	})
+}
+
+// applyPolicyChange adds or removes the policy of an approved change, recording the id
+// of an added policy in the change.
+func applyPolicyChange(tx *sql.Tx, change *schema.PolicyChange, approver string) error {
+	if change.Action == model.PolicyRemove {
+		if change.PolicyID == nil {
+			return fmt.Errorf("policy change %d has no policy", change.ID)
+		}
+
+		_, err := tx.Exec(`DELETE FROM multisig_policies WHERE id=$1 AND multisig_address=$2`, *change.PolicyID, change.MultisigAddress)
+		return err
+	}
+
+	var id int
+	if err := tx.QueryRow(`INSERT INTO multisig_policies ("multisig_address","type","params","created_by","approved_by","created_at")
+	VALUES ($1,$2,$3,$4,$5,$6) RETURNING id`, change.MultisigAddress, change.Type, []byte(change.Params), change.ProposedBy,
+		approver, time.Now().UTC()).Scan(&id); err != nil {
+		return err
+	}
+	change.PolicyID = &id
+
+	return nil
+}
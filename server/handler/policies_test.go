@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/policy"
+)
+
+var policyChangeRowColumns = []string{"id", "multisig_address", "action", "policy_id", "type", "params", "status", "proposed_by", "decided_by", "created_at", "decided_at"}
+
+// expectPolicies expects the policies of the multisig to be loaded, along with the
+// members of the multisig when there are any.
+func expectPolicies(t *testing.T, mock sqlmock.Sqlmock, address string, members []string, policies ...policy.Policy) {
+	rows := sqlmock.NewRows([]string{"id", "type", "params"})
+	for _, p := range policies {
+		params, err := json.Marshal(p.Params)
+		require.NoError(t, err)
+		rows.AddRow(p.ID, p.Type, params)
+	}
+	mock.ExpectQuery("SELECT id,type,params FROM multisig_policies").WithArgs(address).WillReturnRows(rows)
+	if len(policies) == 0 {
+		return
+	}
+
+	memberRows := sqlmock.NewRows([]string{"address"})
+	for _, member := range members {
+		memberRows.AddRow(member)
+	}
+	mock.ExpectQuery("SELECT address FROM pubkeys").WithArgs(address).WillReturnRows(memberRows)
+}
+
+func TestProposePolicyChange(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	admin := testAddress(t, "osmo", 0x01)
+	recipient := testAddress(t, "osmo", 0x03)
+
+	testCases := []struct {
+		name      string
+		body      string
+		exists    bool
+		expStatus int
+	}{
+		{"add an allowlist", `{"action":"add","type":"recipient_allowlist","params":{"addresses":["` + recipient + `"]}}`, false, http.StatusOK},
+		{"add a large send without amount", `{"action":"add","type":"large_send","params":{"denom":"uosmo"}}`, false, http.StatusBadRequest},
+		{"unknown policy type", `{"action":"add","type":"anything","params":{}}`, false, http.StatusBadRequest},
+		{"remove a policy", `{"action":"remove","policy_id":4}`, true, http.StatusOK},
+		{"remove a missing policy", `{"action":"remove","policy_id":4}`, false, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			remove := tc.expStatus != http.StatusBadRequest && tc.name != "add an allowlist"
+			if remove {
+				rows := sqlmock.NewRows([]string{"type", "params"})
+				if tc.exists {
+					rows.AddRow(policy.DailyOutflow, []byte(`{"denom":"uosmo","amount":"1000"}`))
+				}
+				mock.ExpectQuery("SELECT type,params FROM multisig_policies").WithArgs(4, multisig).WillReturnRows(rows)
+			}
+			if tc.expStatus == http.StatusOK {
+				action, policyType, policyID := model.PolicyAdd, policy.RecipientAllowlist, interface{}(nil)
+				if remove {
+					action, policyType, policyID = model.PolicyRemove, policy.DailyOutflow, 4
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO policy_changes").
+					WithArgs(multisig, action, sqlmock.AnyArg(), policyType, sqlmock.AnyArg(), model.PolicyChangePending, admin, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows(policyChangeRowColumns).
+						AddRow(1, multisig, action, policyID, policyType, []byte("{}"), model.PolicyChangePending, admin, nil, time.Now(), nil))
+				expectAudit(mock, multisig)
+				mock.ExpectCommit()
+			}
+
+			c, rec := commentContext(http.MethodPost, tc.body, []string{"address"}, []string{multisig}, admin)
+			h := &Handler{DB: db}
+			require.NoError(t, h.ProposePolicyChange(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDecidePolicyChange(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	proposer := testAddress(t, "osmo", 0x01)
+	other := testAddress(t, "osmo", 0x02)
+
+	testCases := []struct {
+		name      string
+		approve   bool
+		action    string
+		status    string
+		admin     string
+		expStatus int
+	}{
+		{"approve an added policy", true, model.PolicyAdd, model.PolicyChangePending, other, http.StatusOK},
+		{"approve a removed policy", true, model.PolicyRemove, model.PolicyChangePending, other, http.StatusOK},
+		{"approve own change", true, model.PolicyAdd, model.PolicyChangePending, proposer, http.StatusForbidden},
+		{"approve a decided change", true, model.PolicyAdd, model.PolicyChangeRejected, other, http.StatusConflict},
+		{"reject own change", false, model.PolicyAdd, model.PolicyChangePending, proposer, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			var policyID interface{}
+			if tc.action == model.PolicyRemove {
+				policyID = 4
+			}
+			params := []byte(`{"denom":"uosmo","amount":"1000"}`)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT (.+) FROM policy_changes (.+) FOR UPDATE").WithArgs(2, multisig).WillReturnRows(
+				sqlmock.NewRows(policyChangeRowColumns).
+					AddRow(2, multisig, tc.action, policyID, policy.DailyOutflow, params, tc.status, proposer, nil, time.Now(), nil))
+			if tc.expStatus == http.StatusOK {
+				status := model.PolicyChangeRejected
+				if tc.approve {
+					status = model.PolicyChangeApproved
+					if tc.action == model.PolicyAdd {
+						mock.ExpectQuery("INSERT INTO multisig_policies").
+							WithArgs(multisig, policy.DailyOutflow, params, proposer, tc.admin, sqlmock.AnyArg()).
+							WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+						policyID = 5
+					} else {
+						mock.ExpectExec("DELETE FROM multisig_policies").WithArgs(4, multisig).WillReturnResult(sqlmock.NewResult(0, 1))
+					}
+				}
+				mock.ExpectExec("UPDATE policy_changes SET status").
+					WithArgs(status, sqlmock.AnyArg(), tc.admin, sqlmock.AnyArg(), 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, multisig)
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			c, rec := commentContext(http.MethodPost, "", []string{"address", "changeId"}, []string{multisig, "2"}, tc.admin)
+			h := &Handler{DB: db}
+			if tc.approve {
+				require.NoError(t, h.ApprovePolicyChange(c))
+			} else {
+				require.NoError(t, h.RejectPolicyChange(c))
+			}
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetTxBytesPolicies(t *testing.T) {
+	useNetworks(t)
+	f := newTxFixture(t)
+	mockAccount(t)
+
+	testCases := []struct {
+		name      string
+		allowed   string
+		expStatus int
+	}{
+		{"recipient on the allowlist", "", http.StatusOK},
+		{"recipient not on the allowlist", testAddress(t, "osmo", 0x03), http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			allowed := tc.allowed
+			if allowed == "" {
+				allowed = f.members[0]
+			}
+
+			f.expectAssemble(t, mock, []Signature{f.sign(t, 0, 3), f.sign(t, 1, 3)}, int64(3), false)
+			expectPolicies(t, mock, f.multisig, f.members, policy.Policy{
+				ID:     1,
+				Type:   policy.RecipientAllowlist,
+				Params: policy.Params{Addresses: []string{allowed}},
+			})
+			if tc.expStatus == http.StatusOK {
+				f.expectPubkeys(mock)
+			}
+
+			c, rec := commentContext(http.MethodGet, "", []string{"address", "id"}, []string{f.multisig, "1"}, f.members[0])
+			h := &Handler{DB: db}
+			require.NoError(t, h.GetTxBytes(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
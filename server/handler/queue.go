@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/cron"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+)
+
+// Transactions of a multisig form a queue ordered by the sequence they are signed for.
+// PENDING and BROADCASTING transactions hold their sequence; the next transaction is
+// queued at the following sequence.
+
+var errStaleTx = errors.New("the sequence of the transaction was used by another transaction, it must be signed again")
+
+var errTxChanged = errors.New("the transaction was changed in the meantime, reload it and try again")
+
+var errTxBroadcasting = errors.New("transaction is being broadcasted")
+
+var errTxNotPending = errors.New("only pending transactions can be updated")
+
+var errTxHashMismatch = errors.New("the hash is not a transaction of the multisig account at the sequence of the transaction")
+
+// expired reports whether a transaction is past its expiry time. The cron job marks
+// such transactions as EXPIRED, until then they are refused by signing and broadcast.
+func expired(expiresAt *time.Time) bool {
+	return expiresAt != nil && !time.Now().Before(*expiresAt)
+}
+
+func errExpiredTx(expiresAt *time.Time) error {
+	return fmt.Errorf("the transaction expired at %s", expiresAt.UTC().Format(time.RFC3339))
+}
+
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// nextSequence returns the sequence of a transaction added at the end of the queue.
+// The multisig account row should be locked by the caller.
+func nextSequence(q queryer, address string, chainSequence uint64) (uint64, error) {
+	var last sql.NullInt64
+	if err := q.QueryRow(`SELECT MAX(sequence) FROM transactions WHERE multisig_address=$1 AND status IN ($2,$3)`,
+		address, model.Pending, model.Broadcasting).Scan(&last); err != nil {
+		return 0, err
+	}
+
+	if last.Valid && uint64(last.Int64)+1 > chainSequence {
+		return uint64(last.Int64) + 1, nil
+	}
+
+	return chainSequence, nil
+}
+
+// RequeueTransaction adds a stale transaction back at the end of the queue, clearing
+// its signatures.
+func (h *Handler) RequeueTransaction(c echo.Context) error {
+	address := c.Param("address")
+	txId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid transaction id",
+		})
+	}
+
+	var chainID, status string
+	if err := h.DB.QueryRow(`SELECT m.chain_id,t.status FROM transactions t JOIN multisig_accounts m ON t.multisig_address = m.address
+	WHERE t.id=$1 AND t.multisig_address=$2 AND t.deleted_at IS NULL`, txId, address).Scan(&chainID, &status); err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "transaction not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if status != model.Stale {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: "only stale transactions can be queued again",
+		})
+	}
+
+	account, err := getAccount(chainID, address)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to fetch the multisig account from the chain",
+			Log:     err.Error(),
+		})
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to requeue transaction",
+			Log:     err.Error(),
+		})
+	}
+	defer tx.Rollback()
+
+	sequence, err := lockQueue(tx, address, account.Sequence)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to requeue transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if _, err := tx.Exec(`UPDATE transactions SET status=$1,signatures='[]'::jsonb,signed_at=NULL,account_number=$2,sequence=$3,version=version+1,last_updated=$4
+	WHERE id=$5 AND multisig_address=$6`, model.Pending, account.AccountNumber, sequence, time.Now().UTC(), txId, address); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to requeue transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := audit.Record(tx, schema.AuditEvent{
+		MultisigAddress: address,
+		TxID:            audit.TxID(txId),
+		Actor:           auditActor(c, address),
+		Action:          audit.TxRequeued,
+		OldValue:        audit.Status(model.Stale),
+		NewValue:        audit.Value(map[string]interface{}{"status": model.Pending, "sequence": sequence}),
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to requeue transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to requeue transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Message: fmt.Sprintf("transaction queued at sequence %d", sequence),
+	})
+}
+
+// inTx runs fn in a database transaction, committing it when fn succeeds.
+func (h *Handler) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockQueue locks the multisig account for the rest of the database transaction, marks
+// transactions with used sequences as stale and returns the next sequence of the queue.
+func lockQueue(tx *sql.Tx, address string, chainSequence uint64) (uint64, error) {
+	var locked string
+	if err := tx.QueryRow(`SELECT address FROM multisig_accounts WHERE address=$1 FOR UPDATE`, address).Scan(&locked); err != nil {
+		return 0, err
+	}
+
+	if err := cron.MarkStale(tx, address, chainSequence); err != nil {
+		return 0, err
+	}
+
+	return nextSequence(tx, address, chainSequence)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/model"
+)
+
+func TestNextSequence(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+
+	testCases := []struct {
+		name          string
+		last          driver.Value
+		chainSequence uint64
+		exp           uint64
+	}{
+		{"empty queue", nil, 3, 3},
+		{"after the last queued transaction", int64(5), 3, 6},
+		{"queue behind the chain", int64(2), 5, 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT MAX\\(sequence\\)").WithArgs(multisig, model.Pending, model.Broadcasting).
+				WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(tc.last))
+
+			sequence, err := nextSequence(db, multisig, tc.chainSequence)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, sequence)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/vitwit/resolute/server/clients"
 	"github.com/vitwit/resolute/server/config"
 	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/summary"
 	"github.com/vitwit/resolute/server/txn_types"
 	"github.com/vitwit/resolute/server/utils"
 )
@@ -316,7 +317,10 @@ func getNetworkRecentTransactions(chainId string, module string, address string)
 		}
 
 		client := &http.Client{}
-		resp, _ := client.Do(req)
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
 		defer resp.Body.Close()
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
@@ -341,6 +345,7 @@ func (a ByTimestamp) Less(i, j int) bool { return a[i].Timestamp.After(a[j].Time
 
 func GetParsedTransactions(txns txn_types.TransactionResponses, chainId string) ([]txn_types.ParsedTxn, error) {
 	layout := "2006-01-02T15:04:05Z"
+	chain := summaryChain(chainId)
 	parsedTxns := []txn_types.ParsedTxn{}
 	for i := 0; i < len(txns.TxResponses); i++ {
 		txn_response := txns.TxResponses[i]
@@ -357,6 +362,7 @@ func GetParsedTransactions(txns txn_types.TransactionResponses, chainId string)
 			Memo:      txn.Body.Memo,
 			Messages:  txn.Body.Messages,
 			ChainId:   chainId,
+			Summaries: summary.Summarize(summary.Decode(txn.Body.Messages), chain),
 		}
 		parsedTxns = append(parsedTxns, parsedTxn)
 	}
@@ -383,6 +389,7 @@ func GetParsedTransaction(txns txn_types.SingleTxnRes, chainId string) (txn_type
 		Memo:      txn.Body.Memo,
 		Messages:  txn.Body.Messages,
 		ChainId:   chainId,
+		Summaries: summary.Summarize(summary.Decode(txn.Body.Messages), summaryChain(chainId)),
 	}
 
 	return parsedTxn, nil
@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/cron"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// Rejection records a member voting against a transaction.
+type Rejection struct {
+	Address    string    `json:"address"`
+	Reason     string    `json:"reason"`
+	RejectedAt time.Time `json:"rejected_at"`
+}
+
+type RejectTxReq struct {
+	Reason string `json:"reason"`
+}
+
+// maxRejectionReason is the maximum length of a rejection reason.
+const maxRejectionReason = 500
+
+// thresholdUnreachable reports whether a transaction can no longer collect enough
+// signatures once the members who rejected it are left out.
+func thresholdUnreachable(pubkeys int, rejections int, threshold int) bool {
+	return pubkeys-rejections < threshold
+}
+
+// RejectTransaction records the rejection of a pending transaction by a member of the
+// multisig account. The transaction is REJECTED once the threshold can not be reached.
+func (h *Handler) RejectTransaction(c echo.Context) error {
+	address := c.Param("address")
+	txId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid transaction id",
+		})
+	}
+
+	req := &RejectTxReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	if len(req.Reason) > maxRejectionReason {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("reason cannot be longer than %d characters", maxRejectionReason),
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	var threshold, pubkeys int
+	var isMember bool
+	if err := h.DB.QueryRow(`SELECT m.threshold, COUNT(p.address), COALESCE(BOOL_OR(p.address=$2), false) FROM multisig_accounts m
+	JOIN pubkeys p ON p.multisig_address = m.address WHERE m.address=$1 GROUP BY m.threshold`, address, member).Scan(&threshold, &pubkeys, &isMember); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query multisig account",
+			Log:     err.Error(),
+		})
+	}
+
+	if !isMember {
+		return c.JSON(http.StatusForbidden, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("%s is not a signer of the multisig account", member),
+		})
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to reject transaction",
+			Log:     err.Error(),
+		})
+	}
+	defer tx.Rollback()
+
+	var (
+		status                  string
+		signaturesJSON, rejJSON []byte
+		sequence                sql.NullInt64
+		expiresAt               *time.Time
+	)
+	err = tx.QueryRow(`SELECT status,signatures,rejections,sequence,expires_at FROM transactions WHERE id=$1 AND multisig_address=$2 AND deleted_at IS NULL FOR UPDATE`,
+		txId, address).Scan(&status, &signaturesJSON, &rejJSON, &sequence, &expiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "transaction not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if status != string(model.Pending) && status != model.Stale {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("cannot reject a %s transaction", status),
+		})
+	}
+
+	if expired(expiresAt) {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: errExpiredTx(expiresAt).Error(),
+		})
+	}
+
+	var signatures []Signature
+	if err := json.Unmarshal(signaturesJSON, &signatures); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode signatures",
+			Log:     err.Error(),
+		})
+	}
+
+	for _, sig := range signatures {
+		if sig.Address == member {
+			return c.JSON(http.StatusConflict, model.ErrorResponse{
+				Status:  "error",
+				Message: "you already signed the transaction",
+			})
+		}
+	}
+
+	var rejections []Rejection
+	if err := json.Unmarshal(rejJSON, &rejections); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode rejections",
+			Log:     err.Error(),
+		})
+	}
+
+	for _, r := range rejections {
+		if r.Address == member {
+			return c.JSON(http.StatusConflict, model.ErrorResponse{
+				Status:  "error",
+				Message: "you already rejected the transaction",
+			})
+		}
+	}
+
+	now := time.Now().UTC()
+	rejections = append(rejections, Rejection{
+		Address:    member,
+		Reason:     req.Reason,
+		RejectedAt: now,
+	})
+
+	bz, err := json.Marshal(rejections)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to reject transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	newStatus := status
+	if thresholdUnreachable(pubkeys, len(rejections), threshold) {
+		newStatus = model.Rejected
+	}
+
+	if _, err := tx.Exec(`UPDATE transactions SET rejections=$1,status=$2,last_updated=$3 WHERE id=$4`,
+		bz, newStatus, now, txId); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to reject transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := audit.Record(tx, schema.AuditEvent{
+		MultisigAddress: address,
+		TxID:            audit.TxID(txId),
+		Actor:           member,
+		Action:          audit.TxRejected,
+		OldValue:        audit.Status(status),
+		NewValue:        audit.Value(map[string]string{"status": newStatus, "reason": req.Reason}),
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to reject transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	// A rejected transaction is never broadcast, the transactions queued after it take over its sequence
+	if newStatus == model.Rejected && status == string(model.Pending) && sequence.Valid {
+		if err := cron.ReleaseSequence(tx, address, uint64(sequence.Int64), member); err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to reject transaction",
+				Log:     err.Error(),
+			})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to reject transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   map[string]string{"status": newStatus},
+	})
+}
+
+// CancelTransaction lets the proposer of a pending transaction withdraw it.
+func (h *Handler) CancelTransaction(c echo.Context) error {
+	address := c.Param("address")
+	txId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid transaction id",
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to cancel transaction",
+			Log:     err.Error(),
+		})
+	}
+	defer tx.Rollback()
+
+	var (
+		status    string
+		createdBy sql.NullString
+		sequence  sql.NullInt64
+	)
+	err = tx.QueryRow(`SELECT status,created_by,sequence FROM transactions WHERE id=$1 AND multisig_address=$2 AND deleted_at IS NULL FOR UPDATE`,
+		txId, address).Scan(&status, &createdBy, &sequence)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "transaction not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if !createdBy.Valid || createdBy.String != member {
+		return c.JSON(http.StatusForbidden, model.ErrorResponse{
+			Status:  "error",
+			Message: "only the proposer can cancel the transaction",
+		})
+	}
+
+	if status != string(model.Pending) && status != model.Stale {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("cannot cancel a %s transaction", status),
+		})
+	}
+
+	if _, err := tx.Exec(`UPDATE transactions SET status=$1,last_updated=$2 WHERE id=$3`,
+		model.Cancelled, time.Now().UTC(), txId); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to cancel transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := audit.Record(tx, schema.AuditEvent{
+		MultisigAddress: address,
+		TxID:            audit.TxID(txId),
+		Actor:           member,
+		Action:          audit.StatusChanged,
+		OldValue:        audit.Status(status),
+		NewValue:        audit.Status(model.Cancelled),
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to cancel transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	// Transactions queued after a pending transaction take over its sequence
+	if status == string(model.Pending) && sequence.Valid {
+		if err := cron.ReleaseSequence(tx, address, uint64(sequence.Int64), member); err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to cancel transaction",
+				Log:     err.Error(),
+			})
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to cancel transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "transaction cancelled",
+	})
+}
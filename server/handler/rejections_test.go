@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+func TestRejectTransaction(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	member := testAddress(t, "osmo", 0x01)
+	other := testAddress(t, "osmo", 0x02)
+
+	testCases := []struct {
+		name       string
+		threshold  int
+		isMember   bool
+		status     string
+		signatures string
+		rejections string
+		expStatus  int
+		expTxState string
+		expiresAt  driver.Value
+	}{
+		{"rejection below the threshold", 1, true, "PENDING", "[]", "[]", http.StatusOK, "PENDING", nil},
+		{"rejection making the threshold unreachable", 2, true, "PENDING", "[]", "[]", http.StatusOK, "REJECTED", nil},
+		{"rejection of a stale transaction", 2, true, "STALE", "[]", "[]", http.StatusOK, "REJECTED", nil},
+		{"not a member", 1, false, "PENDING", "[]", "[]", http.StatusForbidden, "", nil},
+		{"transaction being broadcasted", 1, true, "BROADCASTING", "[]", "[]", http.StatusConflict, "", nil},
+		{"member already signed", 1, true, "PENDING", `[{"address":"` + member + `","signature":"c2ln"}]`, "[]", http.StatusConflict, "", nil},
+		{"member already rejected", 1, true, "PENDING", "[]", `[{"address":"` + member + `","reason":"","rejected_at":"2024-01-01T00:00:00Z"}]`, http.StatusConflict, "", nil},
+		{"expired transaction", 2, true, "PENDING", "[]", "[]", http.StatusConflict, "", time.Now().Add(-time.Minute)},
+		{"rejection after another member", 2, true, "PENDING", "[]", `[{"address":"` + other + `","reason":"","rejected_at":"2024-01-01T00:00:00Z"}]`, http.StatusOK, "REJECTED", nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT m.threshold").WithArgs(multisig, member).WillReturnRows(
+				sqlmock.NewRows([]string{"threshold", "count", "is_member"}).AddRow(tc.threshold, 2, tc.isMember))
+			if tc.isMember {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT status,signatures,rejections,sequence,expires_at FROM transactions").WithArgs(1, multisig).WillReturnRows(
+					sqlmock.NewRows([]string{"status", "signatures", "rejections", "sequence", "expires_at"}).
+						AddRow(tc.status, []byte(tc.signatures), []byte(tc.rejections), int64(4), tc.expiresAt))
+			}
+			if tc.expStatus == http.StatusOK {
+				mock.ExpectExec("UPDATE transactions SET rejections").
+					WithArgs(sqlmock.AnyArg(), tc.expTxState, sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, multisig)
+				if tc.expTxState == model.Rejected && tc.status == "PENDING" {
+					expectRelease(mock, multisig, 4, 2)
+				}
+				mock.ExpectCommit()
+			} else if tc.isMember {
+				mock.ExpectRollback()
+			}
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"reason":"wrong recipient"}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("address", "id")
+			c.SetParamValues(multisig, "1")
+			c.Set(utils.AUTH_ADDRESS_KEY, member)
+
+			h := &Handler{DB: db}
+			require.NoError(t, h.RejectTransaction(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCancelTransaction(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	proposer := testAddress(t, "osmo", 0x01)
+	other := testAddress(t, "osmo", 0x02)
+
+	testCases := []struct {
+		name      string
+		createdBy driver.Value
+		status    string
+		expStatus int
+	}{
+		{"proposer cancels a pending transaction", proposer, "PENDING", http.StatusOK},
+		{"proposer cancels a stale transaction", proposer, "STALE", http.StatusOK},
+		{"another member", other, "PENDING", http.StatusForbidden},
+		{"transaction without a proposer", nil, "PENDING", http.StatusForbidden},
+		{"transaction being broadcasted", proposer, "BROADCASTING", http.StatusConflict},
+		{"executed transaction", proposer, "SUCCESS", http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT status,created_by,sequence FROM transactions").WithArgs(1, multisig).WillReturnRows(
+				sqlmock.NewRows([]string{"status", "created_by", "sequence"}).AddRow(tc.status, tc.createdBy, int64(4)))
+			if tc.expStatus == http.StatusOK {
+				mock.ExpectExec("UPDATE transactions SET status").
+					WithArgs(model.Cancelled, sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, multisig)
+				if tc.status == "PENDING" {
+					expectRelease(mock, multisig, 4, 2)
+				}
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("address", "id")
+			c.SetParamValues(multisig, "1")
+			c.Set(utils.AUTH_ADDRESS_KEY, proposer)
+
+			h := &Handler{DB: db}
+			require.NoError(t, h.CancelTransaction(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+var errLastAdmin = errors.New("cannot revoke the last admin of the multisig")
+
+func (h *Handler) GetMultisigRoles(c echo.Context) error {
+	address := c.Param("address")
+
+	rows, err := h.DB.Query(`SELECT multisig_address,address,role,granted_by,created_at FROM multisig_roles
+	WHERE multisig_address=$1 ORDER BY created_at ASC`, address)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query roles",
+			Log:     err.Error(),
+		})
+	}
+	defer rows.Close()
+
+	roles := make([]schema.MultisigRole, 0)
+	for rows.Next() {
+		var role schema.MultisigRole
+		if err := rows.Scan(
+			&role.MultisigAddress,
+			&role.Address,
+			&role.Role,
+			&role.GrantedBy,
+			&role.CreatedAt,
+		); err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to decode roles",
+				Log:     err.Error(),
+			})
+		}
+
+		roles = append(roles, role)
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   roles,
+	})
+}
+
+func (h *Handler) GrantMultisigRole(c echo.Context) error {
+	address := c.Param("address")
+
+	req := &model.GrantRoleReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	prefix, _, err := cosmos.DecodeAddress(address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid multisig address",
+			Log:     err.Error(),
+		})
+	}
+
+	member, err := cosmos.ConvertAddress(req.Address, prefix)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid address",
+			Log:     err.Error(),
+		})
+	}
+
+	// only the keys of the multisig can produce signatures
+	if req.Role == model.RoleSigner {
+		var pubkeyAddress string
+		err := h.DB.QueryRow(`SELECT address FROM pubkeys WHERE address=$1 AND multisig_address=$2`, member, address).Scan(&pubkeyAddress)
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Status:  "error",
+				Message: "signer role can only be granted to a pubkey of the multisig",
+			})
+		} else if err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to query pubkeys",
+				Log:     err.Error(),
+			})
+		}
+	}
+
+	grantedBy, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Status:  "Unauthorized",
+			Message: "Unauthorized access",
+			Log:     err.Error(),
+		})
+	}
+
+	err = h.inTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`INSERT INTO "multisig_roles"("multisig_address","address","role","granted_by","created_at")
+		VALUES ($1,$2,$3,$4,$5) ON CONFLICT DO NOTHING`,
+			address, member, req.Role, grantedBy, time.Now().UTC(),
+		)
+		if err != nil {
+			return err
+		}
+
+		// the member already holds the role
+		if n, _ := result.RowsAffected(); n == 0 {
+			return nil
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           grantedBy,
+			Action:          audit.RoleGranted,
+			NewValue:        audit.Value(map[string]string{"address": member, "role": req.Role}),
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to grant role",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Message: "role granted",
+	})
+}
+
+func (h *Handler) RevokeMultisigRole(c echo.Context) error {
+	address := c.Param("address")
+	role := c.Param("role")
+
+	if !model.IsValidRole(role) {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid role " + role,
+		})
+	}
+
+	prefix, _, err := cosmos.DecodeAddress(address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid multisig address",
+			Log:     err.Error(),
+		})
+	}
+
+	// roles are stored with the prefix of the multisig, as GrantMultisigRole does
+	member, err := cosmos.ConvertAddress(c.Param("member"), prefix)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid address",
+			Log:     err.Error(),
+		})
+	}
+
+	revoked := true
+	err = h.inTx(func(tx *sql.Tx) error {
+		if role == model.RoleAdmin {
+			// lock the account, so that concurrent revocations see each other's changes
+			var locked string
+			if err := tx.QueryRow(`SELECT address FROM multisig_accounts WHERE address=$1 FOR UPDATE`, address).Scan(&locked); err != nil && err != sql.ErrNoRows {
+				return err
+			}
+
+			var admins int
+			if err := tx.QueryRow(`SELECT count(*) FROM multisig_roles WHERE multisig_address=$1 AND role=$2 AND address<>$3`,
+				address, model.RoleAdmin, member).Scan(&admins); err != nil {
+				return err
+			}
+
+			if admins == 0 {
+				return errLastAdmin
+			}
+		}
+
+		res, err := tx.Exec(`DELETE FROM multisig_roles WHERE multisig_address=$1 AND address=$2 AND role=$3`, address, member, role)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			revoked = false
+			return nil
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           auditActor(c, address),
+			Action:          audit.RoleRevoked,
+			OldValue:        audit.Value(map[string]string{"address": member, "role": role}),
+		})
+	})
+	if err == errLastAdmin {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to revoke role",
+			Log:     err.Error(),
+		})
+	}
+
+	if !revoked {
+		return c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Status:  "error",
+			Message: "role not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Message: "role revoked",
+	})
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/model"
+)
+
+func TestRevokeMultisigRole(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	admin := testAddress(t, "osmo", 0x01)
+	member := testAddress(t, "osmo", 0x02)
+
+	testCases := []struct {
+		name      string
+		member    string
+		role      string
+		admins    int
+		deleted   int64
+		expStatus int
+	}{
+		{"other admin remains", testAddress(t, "cosmos", 0x02), model.RoleAdmin, 1, 1, http.StatusOK},
+		{"last admin", testAddress(t, "cosmos", 0x02), model.RoleAdmin, 0, 0, http.StatusConflict},
+		{"signer role", member, model.RoleSigner, 0, 1, http.StatusOK},
+		{"role not held", member, model.RoleProposer, 0, 0, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			if tc.role == model.RoleAdmin {
+				mock.ExpectQuery("SELECT address FROM multisig_accounts (.+) FOR UPDATE").WithArgs(multisig).WillReturnRows(
+					sqlmock.NewRows([]string{"address"}).AddRow(multisig))
+				mock.ExpectQuery("SELECT count").WithArgs(multisig, model.RoleAdmin, member).WillReturnRows(
+					sqlmock.NewRows([]string{"count"}).AddRow(tc.admins))
+			}
+			if tc.expStatus == http.StatusConflict {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectExec("DELETE FROM multisig_roles").WithArgs(multisig, member, tc.role).
+					WillReturnResult(sqlmock.NewResult(0, tc.deleted))
+				if tc.deleted > 0 {
+					expectAudit(mock, multisig)
+				}
+				mock.ExpectCommit()
+			}
+
+			c, rec := commentContext(http.MethodDelete, "", []string{"address", "member", "role"},
+				[]string{multisig, tc.member, tc.role}, admin)
+			h := &Handler{DB: db}
+			require.NoError(t, h.RevokeMultisigRole(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+var errScheduleNotDue = errors.New("schedule is not due")
+
+const scheduleColumns = `id,multisig_address,template_id,spec,params,expires_in,active,next_run_at,last_run_at,last_tx_id,last_error,created_by,created_at`
+
+func scanSchedule(row interface{ Scan(...interface{}) error }) (schema.TxSchedule, error) {
+	var s schema.TxSchedule
+	err := row.Scan(
+		&s.ID,
+		&s.MultisigAddress,
+		&s.TemplateID,
+		&s.Spec,
+		&s.Params,
+		&s.ExpiresIn,
+		&s.Active,
+		&s.NextRunAt,
+		&s.LastRunAt,
+		&s.LastTxID,
+		&s.LastError,
+		&s.CreatedBy,
+		&s.CreatedAt,
+	)
+
+	return s, err
+}
+
+// nextRun returns the next time a schedule runs after the given time.
+func nextRun(spec string, after time.Time) (time.Time, error) {
+	schedule, err := model.ParseSchedule(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return schedule.Next(after).UTC(), nil
+}
+
+func (h *Handler) GetSchedules(c echo.Context) error {
+	address := c.Param("address")
+
+	rows, err := h.DB.Query(`SELECT `+scheduleColumns+` FROM tx_schedules WHERE multisig_address=$1 ORDER BY id`, address)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query schedules",
+			Log:     err.Error(),
+		})
+	}
+	defer rows.Close()
+
+	schedules := make([]schema.TxSchedule, 0)
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to decode schedules",
+				Log:     err.Error(),
+			})
+		}
+		schedules = append(schedules, s)
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   schedules,
+	})
+}
+
+// CreateSchedule proposes transactions from a template on a schedule, on behalf of the
+// member creating it.
+func (h *Handler) CreateSchedule(c echo.Context) error {
+	address := c.Param("address")
+
+	req := &model.TxScheduleReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	t, err := h.loadTemplate(address, req.TemplateID)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Status:  "error",
+			Message: "template not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query template",
+			Log:     err.Error(),
+		})
+	}
+
+	// the parameters are checked now rather than on the first run
+	if _, err := templateRequest(t, "", req.Params); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	if req.Params == nil {
+		req.Params = map[string]string{}
+	}
+	paramsbz, err := json.Marshal(req.Params)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid params",
+			Log:     err.Error(),
+		})
+	}
+
+	now := time.Now().UTC()
+	next, err := nextRun(req.Spec, now)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	var s schema.TxSchedule
+	err = h.inTx(func(tx *sql.Tx) error {
+		s, err = scanSchedule(tx.QueryRow(`INSERT INTO tx_schedules ("multisig_address","template_id","spec","params","expires_in","active","next_run_at","created_by","created_at")
+		VALUES ($1,$2,$3,$4,$5,true,$6,$7,$8) RETURNING `+scheduleColumns,
+			address, t.ID, req.Spec, paramsbz, req.ExpiresIn, next, member, now))
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           member,
+			Action:          audit.ScheduleCreated,
+			NewValue: audit.Value(map[string]interface{}{
+				"schedule_id": s.ID,
+				"template_id": t.ID,
+				"template":    t.Name,
+				"spec":        req.Spec,
+				"params":      req.Params,
+			}),
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to store schedule",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   s,
+	})
+}
+
+// UpdateSchedule pauses or resumes a schedule. A resumed schedule runs next at its
+// first run time from now, the runs missed while it was paused are skipped.
+func (h *Handler) UpdateSchedule(c echo.Context) error {
+	address := c.Param("address")
+	scheduleId, err := strconv.Atoi(c.Param("scheduleId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid schedule id",
+		})
+	}
+
+	req := &model.UpdateTxScheduleReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	var s schema.TxSchedule
+	err = h.inTx(func(tx *sql.Tx) error {
+		old, err := scanSchedule(tx.QueryRow(`SELECT `+scheduleColumns+` FROM tx_schedules WHERE id=$1 AND multisig_address=$2 FOR UPDATE`,
+			scheduleId, address))
+		if err != nil {
+			return err
+		}
+
+		next := old.NextRunAt
+		if req.Active && !old.Active {
+			if next, err = nextRun(old.Spec, time.Now().UTC()); err != nil {
+				return err
+			}
+		}
+
+		s, err = scanSchedule(tx.QueryRow(`UPDATE tx_schedules SET active=$1,next_run_at=$2 WHERE id=$3 RETURNING `+scheduleColumns,
+			req.Active, next, scheduleId))
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           member,
+			Action:          audit.ScheduleUpdated,
+			OldValue:        audit.Value(map[string]interface{}{"schedule_id": old.ID, "active": old.Active}),
+			NewValue:        audit.Value(map[string]interface{}{"schedule_id": s.ID, "active": s.Active}),
+		})
+	})
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Status:  "error",
+			Message: "schedule not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to update schedule",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   s,
+	})
+}
+
+func (h *Handler) DeleteSchedule(c echo.Context) error {
+	address := c.Param("address")
+	scheduleId, err := strconv.Atoi(c.Param("scheduleId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid schedule id",
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	err = h.inTx(func(tx *sql.Tx) error {
+		var templateId int
+		var spec string
+		if err := tx.QueryRow(`DELETE FROM tx_schedules WHERE id=$1 AND multisig_address=$2 RETURNING template_id,spec`, scheduleId, address).
+			Scan(&templateId, &spec); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			Actor:           member,
+			Action:          audit.ScheduleDeleted,
+			OldValue:        audit.Value(map[string]interface{}{"schedule_id": scheduleId, "template_id": templateId, "spec": spec}),
+		})
+	})
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Status:  "error",
+			Message: "schedule not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to delete schedule",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Message: "schedule deleted",
+	})
+}
+
+// ProposeScheduled proposes the transaction of a due schedule, through the same
+// validation as the transactions proposed by members. The run is claimed before
+// proposing, so a failed proposal is recorded on the schedule rather than retried.
+func (h *Handler) ProposeScheduled(scheduleId int) error {
+	var s schema.TxSchedule
+	now := time.Now().UTC()
+	err := h.inTx(func(tx *sql.Tx) error {
+		var err error
+		s, err = scanSchedule(tx.QueryRow(`SELECT `+scheduleColumns+` FROM tx_schedules WHERE id=$1 FOR UPDATE`, scheduleId))
+		if err != nil {
+			return err
+		}
+
+		if !s.Active || s.NextRunAt.After(now) {
+			return errScheduleNotDue
+		}
+
+		next, err := nextRun(s.Spec, now)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE tx_schedules SET next_run_at=$1,last_run_at=$2 WHERE id=$3`, next, now, scheduleId)
+		return err
+	})
+	if err == errScheduleNotDue || err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := h.proposeScheduled(s, now)
+
+	var txId *int
+	var lastError *string
+	if err != nil {
+		msg := err.Error()
+		lastError = &msg
+	} else {
+		txId = &id
+	}
+	if _, dbErr := h.DB.Exec(`UPDATE tx_schedules SET last_tx_id=$1,last_error=$2 WHERE id=$3`, txId, lastError, scheduleId); dbErr != nil {
+		return dbErr
+	}
+
+	return err
+}
+
+func (h *Handler) proposeScheduled(s schema.TxSchedule, now time.Time) (int, error) {
+	// the schedule stops proposing once its creator can no longer propose
+	var allowed bool
+	if err := h.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM multisig_roles WHERE multisig_address=$1 AND address=$2 AND role IN ($3,$4))`,
+		s.MultisigAddress, s.CreatedBy, model.RoleAdmin, model.RoleProposer).Scan(&allowed); err != nil {
+		return 0, err
+	}
+	if !allowed {
+		return 0, fmt.Errorf("%s is no longer a proposer of the multisig", s.CreatedBy)
+	}
+
+	t, err := h.loadTemplate(s.MultisigAddress, s.TemplateID)
+	if err != nil {
+		return 0, err
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(s.Params, &values); err != nil {
+		return 0, fmt.Errorf("invalid stored params: %w", err)
+	}
+
+	req, err := templateRequest(t, h.multisigChainID(s.MultisigAddress), values)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.ExpiresIn > 0 {
+		expiresAt := now.Add(time.Duration(s.ExpiresIn) * time.Second)
+		req.ExpiresAt = &expiresAt
+	}
+
+	return h.proposeTransaction(s.MultisigAddress, s.CreatedBy, req)
+}
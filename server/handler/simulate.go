@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+type SimulateTxResponse struct {
+	GasUsed uint64     `json:"gas_used,string"`
+	Fee     model.Fees `json:"fee"`
+}
+
+// gasPrices returns the configured gas prices of the chain, falling back to the price
+// paid by the given fee.
+func gasPrices(chainID string, fee cosmos.StdFee) []cosmos.GasPrice {
+	var prices []cosmos.GasPrice
+	if chain, err := utils.GetChainAPIs(chainID); err == nil {
+		for _, p := range chain.GasPrices {
+			prices = append(prices, cosmos.GasPrice{Denom: p.Denom, Amount: p.Amount})
+		}
+	}
+
+	if len(prices) == 0 {
+		return fee.GasPrices()
+	}
+
+	// prefer the denom the fee is paid in
+	for i, p := range prices {
+		for _, coin := range fee.Amount {
+			if coin.Denom == p.Denom {
+				prices[0], prices[i] = prices[i], prices[0]
+				return prices
+			}
+		}
+	}
+
+	return prices
+}
+
+// simulate runs the messages as a transaction of the multisig, signed by the first
+// threshold members, and suggests a gas limit and fee.
+func (h *Handler) simulate(address, chainID string, threshold int, messages []model.Message, memo string, fee model.Fees) (*SimulateTxResponse, error) {
+	multisigPk, _, err := h.getMultisigPubKey(address, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := getAccount(chainID, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the multisig account from the chain: %w", err)
+	}
+
+	// signatures are not verified in simulation but their gas is charged
+	sigs := make([][]byte, len(multisigPk.PubKeys))
+	for i := 0; i < threshold && i < len(sigs); i++ {
+		sigs[i] = make([]byte, 64)
+		if multisigPk.PubKeys[i].Eth {
+			sigs[i] = make([]byte, 65)
+		}
+	}
+
+	stdFee := fee.StdFee()
+	if stdFee.Gas == "" {
+		stdFee.Gas = "0"
+	}
+
+	txBytes, err := cosmos.MultisigTxBytes(multisigPk, account.Sequence, stdFee, memo, 0, model.Msgs(messages), sigs)
+	if err != nil {
+		return nil, err
+	}
+
+	gasUsed, err := simulateTx(chainID, txBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	gasLimit := cosmos.AdjustGas(gasUsed, utils.GAS_ADJUSTMENT)
+	resp := &SimulateTxResponse{
+		GasUsed: gasUsed,
+		Fee: model.Fees{
+			Amount: []model.Fee{},
+			Gas:    strconv.FormatUint(gasLimit, 10),
+		},
+	}
+
+	if prices := gasPrices(chainID, stdFee); len(prices) > 0 {
+		coin, err := prices[0].Fee(gasLimit)
+		if err != nil {
+			return nil, err
+		}
+		resp.Fee.Amount = append(resp.Fee.Amount, model.Fee{Denom: coin.Denom, Amount: coin.Amount})
+	}
+
+	return resp, nil
+}
+
+// feeWarnings compares the fee of a transaction with the simulation result.
+func feeWarnings(sim *SimulateTxResponse, simErr error, fee model.Fees) []string {
+	warnings := []string{}
+	if simErr != nil {
+		return append(warnings, fmt.Sprintf("simulation failed: %s", simErr.Error()))
+	}
+
+	stdFee := fee.StdFee()
+	gas, _ := strconv.ParseUint(stdFee.Gas, 10, 64)
+	if gas < sim.GasUsed {
+		warnings = append(warnings, fmt.Sprintf("gas limit %d is below the simulated gas used %d", gas, sim.GasUsed))
+	}
+
+	for _, coin := range sim.Fee.Amount {
+		suggested := cosmos.Coin{Denom: coin.Denom, Amount: coin.Amount}
+		if !stdFee.CoversFee(suggested) {
+			warnings = append(warnings, fmt.Sprintf("fee is below the suggested fee %s%s", coin.Amount, coin.Denom))
+		}
+	}
+
+	return warnings
+}
+
+func (h *Handler) SimulateTransaction(c echo.Context) error {
+	address := c.Param("address")
+
+	req := &model.SimulateTransactionRequest{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Log:     err.Error(),
+		})
+	}
+
+	var chainID string
+	var threshold int
+	if err := h.DB.QueryRow(`SELECT chain_id,threshold FROM multisig_accounts WHERE address=$1`, address).Scan(&chainID, &threshold); err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: fmt.Sprintf("multisig account %s not found", address),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query multisig account",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := model.ValidateMessages(req.Messages, validateOptions(address, chainID)); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Log:     err.Error(),
+		})
+	}
+
+	resp, err := h.simulate(address, chainID, threshold, req.Messages, req.Memo, req.Fee)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to simulate transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   resp,
+	})
+}
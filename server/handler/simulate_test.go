@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/model"
+)
+
+func TestFeeWarnings(t *testing.T) {
+	sim := &SimulateTxResponse{
+		GasUsed: 100000,
+		Fee: model.Fees{
+			Amount: []model.Fee{{Denom: "uosmo", Amount: "3250"}},
+			Gas:    "130000",
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		fee    model.Fees
+		simErr error
+		exp    []string
+	}{
+		{
+			"sufficient fee",
+			model.Fees{Amount: []model.Fee{{Denom: "uosmo", Amount: "5000"}}, Gas: "200000"},
+			nil,
+			[]string{},
+		},
+		{
+			"gas below simulation",
+			model.Fees{Amount: []model.Fee{{Denom: "uosmo", Amount: "5000"}}, Gas: "90000"},
+			nil,
+			[]string{"gas limit 90000 is below the simulated gas used 100000"},
+		},
+		{
+			"fee below suggestion",
+			model.Fees{Amount: []model.Fee{{Denom: "uosmo", Amount: "100"}}, Gas: "200000"},
+			nil,
+			[]string{"fee is below the suggested fee 3250uosmo"},
+		},
+		{
+			"simulation failed",
+			model.Fees{Amount: []model.Fee{{Denom: "uosmo", Amount: "5000"}}, Gas: "200000"},
+			errors.New("insufficient funds"),
+			[]string{"simulation failed: insufficient funds"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, feeWarnings(sim, tc.simErr, tc.fee))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/vitwit/resolute/server/clients"
+	"github.com/vitwit/resolute/server/summary"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// getMonikers is replaced in tests to avoid querying the chain.
+var getMonikers = clients.GetValidatorMonikers
+
+// summaryChain returns the chain details messages of the chain are summarized with.
+// Validator monikers are only fetched once a message refers to a validator.
+func summaryChain(chainID string) summary.Chain {
+	chain, _ := utils.GetChainAPIs(chainID)
+
+	var monikers map[string]string
+	fetched := false
+
+	return summary.NewChain(chain, func(operator string) string {
+		if !fetched {
+			fetched = true
+			if m, err := getMonikers(chainID); err == nil {
+				monikers = m
+			}
+		}
+		return monikers[operator]
+	})
+}
+
+// multisigChainID returns the chain id of a multisig account, or an empty string
+// when it is unknown.
+func (h *Handler) multisigChainID(address string) string {
+	var chainID string
+	if err := h.DB.QueryRow(`SELECT chain_id FROM multisig_accounts WHERE address=$1`, address).Scan(&chainID); err != nil {
+		return ""
+	}
+
+	return chainID
+}
+
+// summarizeStored summarizes the messages of a stored transaction.
+func summarizeStored(messages *json.RawMessage, chain summary.Chain) []summary.Summary {
+	if messages == nil {
+		return []summary.Summary{}
+	}
+
+	return summary.Summarize(summary.DecodeJSON(*messages), chain)
+}
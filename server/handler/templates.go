@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+const templateColumns = `id,multisig_address,name,title,messages,fee,memo,params,created_by,created_at,updated_at`
+
+func scanTemplate(row interface{ Scan(...interface{}) error }) (schema.TxTemplate, error) {
+	var t schema.TxTemplate
+	err := row.Scan(
+		&t.ID,
+		&t.MultisigAddress,
+		&t.Name,
+		&t.Title,
+		&t.Messages,
+		&t.Fee,
+		&t.Memo,
+		&t.Params,
+		&t.CreatedBy,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+
+	return t, err
+}
+
+func (h *Handler) loadTemplate(address string, templateId int) (schema.TxTemplate, error) {
+	return scanTemplate(h.DB.QueryRow(`SELECT `+templateColumns+` FROM tx_templates WHERE id=$1 AND multisig_address=$2`, templateId, address))
+}
+
+// templateRequest fills in the parameters of a template, giving the transaction to
+// propose from it.
+func templateRequest(t schema.TxTemplate, chainID string, values map[string]string) (*model.CreateTransactionRequest, error) {
+	var (
+		messages []model.Message
+		params   []model.TemplateParam
+	)
+	req := &model.CreateTransactionRequest{ChainId: chainID}
+	if err := json.Unmarshal(t.Messages, &messages); err != nil {
+		return nil, fmt.Errorf("invalid stored messages: %w", err)
+	}
+	if err := json.Unmarshal(t.Fee, &req.Fee); err != nil {
+		return nil, fmt.Errorf("invalid stored fee: %w", err)
+	}
+	if err := json.Unmarshal(t.Params, &params); err != nil {
+		return nil, fmt.Errorf("invalid stored params: %w", err)
+	}
+
+	var err error
+	req.Messages, req.Memo, req.Title, err = model.RenderTemplate(messages, t.Memo, t.Title, params, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (h *Handler) GetTemplates(c echo.Context) error {
+	address := c.Param("address")
+
+	rows, err := h.DB.Query(`SELECT `+templateColumns+` FROM tx_templates WHERE multisig_address=$1 ORDER BY name`, address)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query templates",
+			Log:     err.Error(),
+		})
+	}
+	defer rows.Close()
+
+	templates := make([]schema.TxTemplate, 0)
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to decode templates",
+				Log:     err.Error(),
+			})
+		}
+		templates = append(templates, t)
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   templates,
+	})
+}
+
+// bindTemplate decodes and validates a template, returning the JSON columns to store.
+func bindTemplate(c echo.Context) (*model.TxTemplateReq, []byte, []byte, []byte, error) {
+	req := &model.TxTemplateReq{}
+	if err := c.Bind(req); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	if req.Params == nil {
+		req.Params = []model.TemplateParam{}
+	}
+
+	msgsbz, err := json.Marshal(req.Messages)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	feebz, err := json.Marshal(req.Fee)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	paramsbz, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	return req, msgsbz, feebz, paramsbz, nil
+}
+
+func (h *Handler) CreateTemplate(c echo.Context) error {
+	address := c.Param("address")
+
+	req, msgsbz, feebz, paramsbz, err := bindTemplate(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	member, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid member address",
+			Log:     err.Error(),
+		})
+	}
+
+	now := time.Now().UTC()
+	t, err := scanTemplate(h.DB.QueryRow(`INSERT INTO tx_templates ("multisig_address","name","title","messages","fee","memo","params","created_by","created_at","updated_at")
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$9) RETURNING `+templateColumns,
+		address, strings.TrimSpace(req.Name), req.Title, msgsbz, feebz, req.Memo, paramsbz, member, now))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to store template",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   t,
+	})
+}
+
+func (h *Handler) UpdateTemplate(c echo.Context) error {
+	address := c.Param("address")
+	templateId, err := strconv.Atoi(c.Param("templateId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid template id",
+		})
+	}
+
+	req, msgsbz, feebz, paramsbz, err := bindTemplate(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	t, err := scanTemplate(h.DB.QueryRow(`UPDATE tx_templates SET name=$1,title=$2,messages=$3,fee=$4,memo=$5,params=$6,updated_at=$7
+	WHERE id=$8 AND multisig_address=$9 RETURNING `+templateColumns,
+		strings.TrimSpace(req.Name), req.Title, msgsbz, feebz, req.Memo, paramsbz, time.Now().UTC(), templateId, address))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Status:  "error",
+			Message: "template not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to update template",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   t,
+	})
+}
+
+// DeleteTemplate deletes a template along with its schedules.
+func (h *Handler) DeleteTemplate(c echo.Context) error {
+	address := c.Param("address")
+	templateId, err := strconv.Atoi(c.Param("templateId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid template id",
+		})
+	}
+
+	result, err := h.DB.Exec(`DELETE FROM tx_templates WHERE id=$1 AND multisig_address=$2`, templateId, address)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to delete template",
+			Log:     err.Error(),
+		})
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Status:  "error",
+			Message: "template not found",
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Message: "template deleted",
+	})
+}
+
+// ProposeTemplate creates a pending transaction from a template, with the parameters
+// of the request.
+func (h *Handler) ProposeTemplate(c echo.Context) error {
+	address := c.Param("address")
+	templateId, err := strconv.Atoi(c.Param("templateId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid template id",
+		})
+	}
+
+	req := &model.ProposeTemplateReq{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode request",
+			Log:     err.Error(),
+		})
+	}
+
+	proposer, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid proposer address",
+			Log:     err.Error(),
+		})
+	}
+
+	t, err := h.loadTemplate(address, templateId)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Status:  "error",
+			Message: "template not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to query template",
+			Log:     err.Error(),
+		})
+	}
+
+	txReq, err := templateRequest(t, h.multisigChainID(address), req.Params)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+	txReq.ExpiresAt, txReq.TimeoutHeight = req.ExpiresAt, req.TimeoutHeight
+
+	id, err := h.proposeTransaction(address, proposer, txReq)
+	if err != nil {
+		return proposalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Data:    map[string]int{"id": id},
+		Message: "transactions created",
+	})
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+)
+
+var (
+	templateRowColumns = []string{"id", "multisig_address", "name", "title", "messages", "fee", "memo", "params", "created_by", "created_at", "updated_at"}
+	scheduleRowColumns = []string{"id", "multisig_address", "template_id", "spec", "params", "expires_in", "active", "next_run_at", "last_run_at", "last_tx_id", "last_error", "created_by", "created_at"}
+)
+
+// payrollTemplate is a template sending an amount to a recipient, both parameters of
+// the template.
+func payrollTemplate(multisig string) schema.TxTemplate {
+	return schema.TxTemplate{
+		ID:              1,
+		MultisigAddress: multisig,
+		Name:            "Payroll",
+		Title:           "Payroll {{month}}",
+		Messages: []byte(`[{"typeUrl":"/cosmos.bank.v1beta1.MsgSend","value":{"fromAddress":"` + multisig +
+			`","toAddress":"{{to}}","amount":[{"denom":"uosmo","amount":"{{amount}}"}]}}]`),
+		Fee:    []byte(`{"amount":[{"denom":"uosmo","amount":"1000"}],"gas":"200000"}`),
+		Memo:   "payroll",
+		Params: []byte(`[{"name":"to"},{"name":"amount","default":"100"},{"name":"month"}]`),
+	}
+}
+
+func templateRow(tmpl schema.TxTemplate) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows(templateRowColumns).AddRow(tmpl.ID, tmpl.MultisigAddress, tmpl.Name, tmpl.Title,
+		[]byte(tmpl.Messages), []byte(tmpl.Fee), tmpl.Memo, []byte(tmpl.Params), tmpl.MultisigAddress, now, now)
+}
+
+func TestTemplateRequest(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	recipient := testAddress(t, "osmo", 0x03)
+	tmpl := payrollTemplate(multisig)
+
+	req, err := templateRequest(tmpl, "osmosis-1", map[string]string{"to": recipient, "month": "March"})
+	require.NoError(t, err)
+	require.Equal(t, "osmosis-1", req.ChainId)
+	require.Equal(t, "Payroll March", req.Title)
+	require.Equal(t, "payroll", req.Memo)
+	require.Len(t, req.Messages, 1)
+	require.Equal(t, recipient, req.Messages[0].Value["toAddress"])
+	require.Equal(t, "100", req.Messages[0].Value["amount"].([]interface{})[0].(map[string]interface{})["amount"])
+
+	_, err = templateRequest(tmpl, "osmosis-1", map[string]string{"to": recipient})
+	require.EqualError(t, err, "missing value of parameter month")
+
+	_, err = templateRequest(tmpl, "osmosis-1", map[string]string{"to": recipient, "month": "March", "memo": "x"})
+	require.EqualError(t, err, "unknown parameter memo")
+}
+
+func TestCreateTemplate(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	proposer := testAddress(t, "osmo", 0x01)
+
+	msgs := `[{"typeUrl":"/cosmos.bank.v1beta1.MsgSend","value":{"fromAddress":"` + multisig +
+		`","toAddress":"{{to}}","amount":[{"denom":"uosmo","amount":"10"}]}}]`
+	fee := `{"amount":[{"denom":"uosmo","amount":"1000"}],"gas":"200000"}`
+
+	testCases := []struct {
+		name      string
+		body      string
+		expStatus int
+	}{
+		{"declared parameter", `{"name":"Payroll","messages":` + msgs + `,"fee":` + fee + `,"params":[{"name":"to"}]}`, http.StatusOK},
+		{"undeclared parameter", `{"name":"Payroll","messages":` + msgs + `,"fee":` + fee + `}`, http.StatusBadRequest},
+		{"duplicate parameter", `{"name":"Payroll","messages":` + msgs + `,"fee":` + fee + `,"params":[{"name":"to"},{"name":"to"}]}`, http.StatusBadRequest},
+		{"invalid parameter name", `{"name":"Payroll","messages":` + msgs + `,"fee":` + fee + `,"params":[{"name":"to"},{"name":"To Address"}]}`, http.StatusBadRequest},
+		{"empty name", `{"name":" ","messages":` + msgs + `,"fee":` + fee + `,"params":[{"name":"to"}]}`, http.StatusBadRequest},
+		{"no messages", `{"name":"Payroll","messages":[],"fee":` + fee + `}`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			if tc.expStatus == http.StatusOK {
+				mock.ExpectQuery("INSERT INTO tx_templates").
+					WithArgs(multisig, "Payroll", "", sqlmock.AnyArg(), sqlmock.AnyArg(), "", sqlmock.AnyArg(), proposer, sqlmock.AnyArg()).
+					WillReturnRows(templateRow(payrollTemplate(multisig)))
+			}
+
+			c, rec := commentContext(http.MethodPost, tc.body, []string{"address"}, []string{multisig}, proposer)
+			h := &Handler{DB: db}
+			require.NoError(t, h.CreateTemplate(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestProposeTemplate(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	proposer := testAddress(t, "osmo", 0x01)
+	recipient := testAddress(t, "osmo", 0x03)
+
+	testCases := []struct {
+		name      string
+		body      string
+		found     bool
+		expStatus int
+	}{
+		{"template not found", `{"params":{"to":"` + recipient + `","month":"March"}}`, false, http.StatusNotFound},
+		{"missing parameter", `{"params":{"to":"` + recipient + `"}}`, true, http.StatusBadRequest},
+		{"unknown parameter", `{"params":{"to":"` + recipient + `","month":"March","memo":"x"}}`, true, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			rows := sqlmock.NewRows(templateRowColumns)
+			if tc.found {
+				rows = templateRow(payrollTemplate(multisig))
+			}
+			mock.ExpectQuery("SELECT (.+) FROM tx_templates").WithArgs(1, multisig).WillReturnRows(rows)
+			if tc.found {
+				mock.ExpectQuery("SELECT chain_id FROM multisig_accounts").WithArgs(multisig).WillReturnRows(
+					sqlmock.NewRows([]string{"chain_id"}).AddRow("osmosis-1"))
+			}
+
+			c, rec := commentContext(http.MethodPost, tc.body, []string{"address", "templateId"}, []string{multisig, "1"}, proposer)
+			h := &Handler{DB: db}
+			require.NoError(t, h.ProposeTemplate(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestProposeScheduled(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	proposer := testAddress(t, "osmo", 0x01)
+	now := time.Now().UTC()
+
+	testCases := []struct {
+		name       string
+		active     bool
+		nextRunAt  time.Time
+		proposer   bool
+		expClaimed bool
+	}{
+		{"paused", false, now.Add(-time.Minute), true, false},
+		{"not due", true, now.Add(time.Hour), true, false},
+		{"creator is no longer a proposer", true, now.Add(-time.Minute), false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT (.+) FROM tx_schedules WHERE id=\\$1 FOR UPDATE").WithArgs(7).WillReturnRows(
+				sqlmock.NewRows(scheduleRowColumns).AddRow(7, multisig, 1, "@monthly", []byte(`{"to":"`+proposer+`","month":"March"}`),
+					0, tc.active, tc.nextRunAt, nil, nil, nil, proposer, now))
+			if !tc.expClaimed {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectExec("UPDATE tx_schedules SET next_run_at").
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 7).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery("SELECT EXISTS(.+) FROM multisig_roles").
+					WithArgs(multisig, proposer, model.RoleAdmin, model.RoleProposer).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tc.proposer))
+				mock.ExpectExec("UPDATE tx_schedules SET last_tx_id").
+					WithArgs(nil, errorArg{}, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			h := &Handler{DB: db}
+			err = h.ProposeScheduled(7)
+			require.Equal(t, tc.expClaimed, err != nil)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// errorArg matches the error recorded on a schedule.
+type errorArg struct{}
+
+func (errorArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	return ok && strings.HasSuffix(s, "is no longer a proposer of the multisig")
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -9,11 +10,30 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/clients"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/cron"
+	"github.com/vitwit/resolute/server/events"
 	"github.com/vitwit/resolute/server/model"
 	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/summary"
+	"github.com/vitwit/resolute/server/txn_types"
 	"github.com/vitwit/resolute/server/utils"
 )
 
+// validateOptions returns the rules messages of the multisig account are checked against.
+func validateOptions(address string, chainID string) cosmos.ValidateOptions {
+	prefix, _, _ := cosmos.DecodeAddress(address)
+	opts := cosmos.ValidateOptions{Prefix: prefix}
+
+	if chain, err := utils.GetChainAPIs(chainID); err == nil {
+		opts.AllowedTypes = chain.AllowedMessageTypes
+	}
+
+	return opts
+}
+
 func (h *Handler) CreateTransaction(c echo.Context) error {
 	address := c.Param("address")
 
@@ -26,69 +46,188 @@ func (h *Handler) CreateTransaction(c echo.Context) error {
 		})
 	}
 
-	if err := req.Validate(); err != nil {
+	proposer, err := utils.GetAuthMemberAddress(c, address)
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Status:  "error",
-			Message: err.Error(),
+			Message: "invalid proposer address",
 			Log:     err.Error(),
 		})
 	}
 
-	row := h.DB.QueryRow(`SELECT address,threshold,chain_id,pubkey_type,name,created_by,created_at
+	if _, err := h.proposeTransaction(address, proposer, req); err != nil {
+		return proposalErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "success",
+		Message: "transactions created",
+	})
+}
+
+// proposalError is a failure to propose a transaction, along with the response it is
+// reported with.
+type proposalError struct {
+	status  int
+	message string
+	err     error
+}
+
+func (e *proposalError) Error() string {
+	if e.err == nil {
+		return e.message
+	}
+
+	return fmt.Sprintf("%s: %s", e.message, e.err.Error())
+}
+
+// proposalResponse returns the status and response a failed proposal is reported with.
+func proposalResponse(err error) (int, model.ErrorResponse) {
+	pe, ok := err.(*proposalError)
+	if !ok {
+		pe = &proposalError{status: http.StatusInternalServerError, message: "failed to store transaction", err: err}
+	}
+
+	resp := model.ErrorResponse{Status: "error", Message: pe.message}
+	if pe.err != nil {
+		resp.Log = pe.err.Error()
+	}
+
+	return pe.status, resp
+}
+
+func proposalErrorResponse(c echo.Context, err error) error {
+	return c.JSON(proposalResponse(err))
+}
+
+// proposeTransaction validates a new transaction against the multisig account, its
+// policies and its address book, and queues it. Transactions proposed through the
+// API, from templates and by schedules all go through it.
+func (h *Handler) proposeTransaction(address, proposer string, req *model.CreateTransactionRequest) (int, error) {
+	if err := req.Validate(); err != nil {
+		return 0, &proposalError{status: http.StatusBadRequest, message: err.Error(), err: err}
+	}
+
+	row := h.DB.QueryRow(`SELECT address,threshold,chain_id,pubkey_type,name,created_by,created_at,recipient_mode
 	 FROM multisig_accounts WHERE "address"=$1`, address)
 	var addr schema.MultisigAccount
 	if err := row.Scan(&addr.Address, &addr.Threshold, &addr.ChainID, &addr.PubkeyType, &addr.Name,
-		&addr.CreatedBy, &addr.CreatedAt); err != nil {
+		&addr.CreatedBy, &addr.CreatedAt, &addr.RecipientMode); err != nil {
 		if sql.ErrNoRows == err {
-			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
-				Status:  "error",
-				Message: fmt.Sprintf("invalid multisig account address %s: not found", address),
-				Log:     err.Error(),
-			})
+			return 0, &proposalError{
+				status:  http.StatusBadRequest,
+				message: fmt.Sprintf("invalid multisig account address %s: not found", address),
+				err:     err,
+			}
+		}
+		return 0, &proposalError{status: http.StatusInternalServerError, message: "something went wrong", err: err}
+	}
+
+	if err := model.ValidateMessages(req.Messages, validateOptions(address, addr.ChainID)); err != nil {
+		return 0, &proposalError{status: http.StatusBadRequest, message: err.Error(), err: err}
+	}
+
+	policies, err := h.evaluatePolicies(address, addr.ChainID, addr.Threshold, model.Msgs(req.Messages), 0)
+	if err != nil {
+		return 0, &proposalError{
+			status:  http.StatusInternalServerError,
+			message: "failed to evaluate the policies of the multisig account",
+			err:     err,
 		}
-		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Status:  "error",
-			Message: "something went wrong",
-			Log:     err.Error(),
-		})
+	}
+
+	if err := policies.Err(); err != nil {
+		return 0, &proposalError{status: http.StatusForbidden, message: err.Error()}
+	}
+
+	recipientWarnings, status, err := h.checkRecipients(address, addr.RecipientMode, model.Msgs(req.Messages))
+	if err != nil {
+		return 0, &proposalError{status: status, message: err.Error()}
 	}
 
 	feebz, err := json.Marshal(req.Fee)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Status:  "error",
-			Message: "failed to decode fee: invalid fee",
-			Log:     err.Error(),
-		})
+		return 0, &proposalError{status: http.StatusBadRequest, message: "failed to decode fee: invalid fee", err: err}
 	}
 
 	msgsbz, err := json.Marshal(req.Messages)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Status:  "error",
-			Message: "failed to decode messages: invalid messages",
-			Log:     err.Error(),
-		})
+		return 0, &proposalError{status: http.StatusBadRequest, message: "failed to decode messages: invalid messages", err: err}
+	}
+
+	account, err := getAccount(addr.ChainID, address)
+	if err != nil {
+		return 0, &proposalError{
+			status:  http.StatusBadGateway,
+			message: "failed to fetch the multisig account from the chain",
+			err:     err,
+		}
+	}
+
+	sim, simErr := h.simulate(address, addr.ChainID, addr.Threshold, req.Messages, req.Memo, req.Fee)
+	warningsbz, err := json.Marshal(append(feeWarnings(sim, simErr, req.Fee), recipientWarnings...))
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := h.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	sequence, err := lockQueue(tx, address, account.Sequence)
+	if err != nil {
+		return 0, err
+	}
+
+	var timeoutHeight *uint64
+	if req.TimeoutHeight != 0 {
+		timeoutHeight = &req.TimeoutHeight
 	}
 
 	var id int
-	err = h.DB.QueryRow(`INSERT INTO "transactions"("multisig_address","fee","status","last_updated","messages","memo", "title", "created_at") 
+	err = tx.QueryRow(`INSERT INTO "transactions"("multisig_address","fee","status","last_updated","messages","memo", "title", "created_at", "account_number", "sequence", "warnings", "created_by", "expires_at", "timeout_height") 
 	VALUES
-	 ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id"`,
-		address, feebz, model.Pending, time.Now(), msgsbz, req.Memo, req.Title, time.Now(),
+	 ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14) RETURNING "id"`,
+		address, feebz, model.Pending, time.Now(), msgsbz, req.Memo, req.Title, time.Now(), account.AccountNumber, sequence, warningsbz, proposer,
+		req.ExpiresAt, timeoutHeight,
 	).Scan(&id)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Status:  "error",
-			Message: "failed to store transaction",
-			Log:     err.Error(),
-		})
+		return 0, &proposalError{status: http.StatusBadRequest, message: "failed to store transaction", err: err}
 	}
 
-	return c.JSON(http.StatusOK, model.SuccessResponse{
-		Status:  "success",
-		Message: "transactions created",
+	if err := audit.Record(tx, schema.AuditEvent{
+		MultisigAddress: address,
+		TxID:            audit.TxID(id),
+		Actor:           proposer,
+		Action:          audit.TxProposed,
+		NewValue: audit.Value(map[string]interface{}{
+			"title":          req.Title,
+			"messages":       json.RawMessage(msgsbz),
+			"fee":            json.RawMessage(feebz),
+			"memo":           req.Memo,
+			"sequence":       sequence,
+			"expires_at":     req.ExpiresAt,
+			"timeout_height": timeoutHeight,
+		}),
+	}); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	events.Publish(events.Event{
+		Type:            events.TxCreated,
+		MultisigAddress: address,
+		TxID:            id,
+		Actor:           proposer,
+		Data:            events.Data(map[string]interface{}{"title": req.Title, "sequence": sequence}),
 	})
+
+	return id, nil
 }
 
 func (h *Handler) GetTransactions(c echo.Context) error {
@@ -102,10 +241,19 @@ func (h *Handler) GetTransactions(c echo.Context) error {
 		})
 	}
 
+	includeDeleted, err := utils.ParseIncludeDeleted(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid include_deleted",
+			Log:     err.Error(),
+		})
+	}
+
 	//count of transaction status
 
 	var rows1 *sql.Rows
-	rows1, err = h.DB.Query(`SELECT CASE WHEN t.status = 'FAILED' THEN 'failed' WHEN t.status = 'SUCCESS' THEN 'completed' WHEN jsonb_array_length(t.signatures) >= a.threshold THEN 'to-broadcast' ELSE 'to-sign' END AS computed_status, COUNT(*) AS count FROM transactions t JOIN multisig_accounts a ON t.multisig_address = a.address WHERE t.multisig_address = $1 GROUP BY computed_status`, address)
+	rows1, err = h.DB.Query(`SELECT CASE WHEN t.status = 'FAILED' THEN 'failed' WHEN t.status = 'SUCCESS' THEN 'completed' WHEN t.status = 'BROADCASTING' THEN 'broadcasting' WHEN t.status = 'STALE' THEN 'stale' WHEN t.status = 'REJECTED' THEN 'rejected' WHEN t.status = 'CANCELLED' THEN 'cancelled' WHEN t.status = 'EXPIRED' THEN 'expired' WHEN jsonb_array_length(t.signatures) >= a.threshold THEN 'to-broadcast' ELSE 'to-sign' END AS computed_status, COUNT(*) AS count FROM transactions t JOIN multisig_accounts a ON t.multisig_address = a.address WHERE t.multisig_address = $1 AND (t.deleted_at IS NULL OR $2) GROUP BY computed_status`, address, includeDeleted)
 
 	if err != nil {
 		if rows1 != nil && sql.ErrNoRows == rows1.Err() {
@@ -145,13 +293,13 @@ func (h *Handler) GetTransactions(c echo.Context) error {
 	status := utils.GetStatus(c.QueryParam("status"))
 	var rows *sql.Rows
 	if status == model.Pending {
-		rows, err = h.DB.Query(`SELECT t.id,COALESCE(t.signed_at, '0001-01-01 00:00:00'::timestamp) AS signed_at,t.multisig_address,t.status,t.created_at,t.last_updated,t.memo,t.signatures,t.messages,t.hash,t.err_msg,t.fee, m.threshold, 
-		json_agg(jsonb_build_object('pubkey', p.pubkey, 'address', p.address, 'multisig_address',p.multisig_address)) AS pubkeys FROM transactions t JOIN multisig_accounts m ON t.multisig_address = m.address JOIN pubkeys p ON t.multisig_address = p.multisig_address WHERE t.multisig_address=$1 and t.status='PENDING' GROUP BY t.id, t.multisig_address, m.threshold, t.messages LIMIT $2 OFFSET $3`,
-			address, limit, (page-1)*limit)
+		rows, err = h.DB.Query(`SELECT t.id,COALESCE(t.signed_at, '0001-01-01 00:00:00'::timestamp) AS signed_at,t.multisig_address,t.status,t.created_at,t.last_updated,t.memo,t.signatures,t.messages,t.hash,t.err_msg,t.fee,t.account_number,t.sequence,t.warnings,t.created_by,t.rejections,t.deleted_at,t.version,t.expires_at,t.timeout_height,(SELECT count(*) FROM tx_comments c WHERE c.tx_id = t.id) AS comments, m.threshold, 
+		json_agg(jsonb_build_object('pubkey', p.pubkey, 'address', p.address, 'multisig_address',p.multisig_address)) AS pubkeys FROM transactions t JOIN multisig_accounts m ON t.multisig_address = m.address JOIN pubkeys p ON t.multisig_address = p.multisig_address WHERE t.multisig_address=$1 and t.status IN ('PENDING','BROADCASTING','STALE') AND (t.deleted_at IS NULL OR $4) GROUP BY t.id, t.multisig_address, m.threshold, t.messages ORDER BY t.sequence NULLS LAST, t.id LIMIT $2 OFFSET $3`,
+			address, limit, (page-1)*limit, includeDeleted)
 	} else {
-		rows, err = h.DB.Query(`SELECT t.id,COALESCE(t.signed_at, '0001-01-01 00:00:00'::timestamp) AS signed_at,t.multisig_address,t.status,t.created_at,t.last_updated,t.memo,t.signatures,t.messages,t.hash,t.err_msg,t.fee, m.threshold, 
-		json_agg(jsonb_build_object('pubkey', p.pubkey, 'address', p.address, 'multisig_address',p.multisig_address)) AS pubkeys FROM transactions t JOIN multisig_accounts m ON t.multisig_address = m.address JOIN pubkeys p ON t.multisig_address = p.multisig_address WHERE t.multisig_address=$1 and t.status <> 'PENDING' GROUP BY t.id, t.multisig_address, m.threshold, t.messages LIMIT $2 OFFSET $3`,
-			address, limit, (page-1)*limit)
+		rows, err = h.DB.Query(`SELECT t.id,COALESCE(t.signed_at, '0001-01-01 00:00:00'::timestamp) AS signed_at,t.multisig_address,t.status,t.created_at,t.last_updated,t.memo,t.signatures,t.messages,t.hash,t.err_msg,t.fee,t.account_number,t.sequence,t.warnings,t.created_by,t.rejections,t.deleted_at,t.version,t.expires_at,t.timeout_height,(SELECT count(*) FROM tx_comments c WHERE c.tx_id = t.id) AS comments, m.threshold, 
+		json_agg(jsonb_build_object('pubkey', p.pubkey, 'address', p.address, 'multisig_address',p.multisig_address)) AS pubkeys FROM transactions t JOIN multisig_accounts m ON t.multisig_address = m.address JOIN pubkeys p ON t.multisig_address = p.multisig_address WHERE t.multisig_address=$1 and t.status NOT IN ('PENDING','BROADCASTING','STALE') AND (t.deleted_at IS NULL OR $4) GROUP BY t.id, t.multisig_address, m.threshold, t.messages LIMIT $2 OFFSET $3`,
+			address, limit, (page-1)*limit, includeDeleted)
 	}
 	if err != nil {
 		if rows != nil && sql.ErrNoRows == rows.Err() {
@@ -170,6 +318,9 @@ func (h *Handler) GetTransactions(c echo.Context) error {
 	}
 	defer rows.Close()
 
+	chainID := h.multisigChainID(address)
+	chain := h.labelledChain(address, chainID)
+
 	transactions := make([]schema.AllTransactionResult, 0)
 	for rows.Next() {
 		var transaction schema.AllTransactionResult
@@ -188,6 +339,16 @@ func (h *Handler) GetTransactions(c echo.Context) error {
 			&transaction.Hash,
 			&transaction.ErrMsg,
 			&transaction.Fee,
+			&transaction.AccountNumber,
+			&transaction.Sequence,
+			&transaction.Warnings,
+			&transaction.CreatedBy,
+			&transaction.Rejections,
+			&transaction.DeletedAt,
+			&transaction.Version,
+			&transaction.ExpiresAt,
+			&transaction.TimeoutHeight,
+			&transaction.Comments,
 			&transaction.Threshold,
 			&transaction.Pubkeys,
 		); err != nil {
@@ -204,8 +365,33 @@ func (h *Handler) GetTransactions(c echo.Context) error {
 			transaction.SignedAt = signedAt // Otherwise, set the actual signed time
 		}
 
+		transaction.Summaries = summarizeStored(transaction.Messages, chain)
+
 		transactions = append(transactions, transaction)
 	}
+	rows.Close()
+
+	if status == model.Pending && len(transactions) > 0 {
+		policies, account, err := h.loadPolicies(address, chainID, transactions[0].Threshold)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to query policies",
+				Log:     err.Error(),
+			})
+		}
+
+		for i, transaction := range transactions {
+			var messages, signatures []byte
+			if transaction.Messages != nil {
+				messages = *transaction.Messages
+			}
+			if transaction.Signatures != nil {
+				signatures = *transaction.Signatures
+			}
+			transactions[i].Policy = policyStatus(policies, account, transaction.Status, messages, signatures)
+		}
+	}
 
 	return c.JSON(http.StatusOK, model.SuccessResponse{
 		Data:   transactions,
@@ -219,11 +405,31 @@ func (h *Handler) GetAllMultisigTxns(c echo.Context) error {
 
 	status := utils.GetStatus(c.QueryParam("status"))
 	page, limit, _, err := utils.ParsePaginationParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+			Log:     err.Error(),
+		})
+	}
+
+	includeDeleted, err := utils.ParseIncludeDeleted(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid include_deleted",
+			Log:     err.Error(),
+		})
+	}
 
-	multisigRows, err := h.DB.Query(`SELECT p.multisig_address
-	FROM pubkeys p
-	JOIN multisig_accounts m ON p.multisig_address = m.address
-	WHERE p.address = $1`, address)
+	// only the owner of the address can see transactions of its private accounts
+	owner := cosmos.SameAccount(address, utils.GetAuthAddress(c))
+
+	multisigRows, err := h.DB.Query(`SELECT m.address, m.chain_id
+	FROM multisig_accounts m
+	WHERE m.address IN (SELECT multisig_address FROM pubkeys WHERE address = $1
+	UNION SELECT multisig_address FROM multisig_roles WHERE address = $1)
+	AND (m.visibility = $2 OR $3) AND (m.deleted_at IS NULL OR $4)`, address, model.VisibilityPublic, owner, includeDeleted)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Status:  "error",
@@ -235,8 +441,8 @@ func (h *Handler) GetAllMultisigTxns(c echo.Context) error {
 	transactions := make([]schema.AllTransactionResult, 0)
 
 	for multisigRows.Next() {
-		var multisigAddress string
-		if err := multisigRows.Scan(&multisigAddress); err != nil {
+		var multisigAddress, chainID string
+		if err := multisigRows.Scan(&multisigAddress, &chainID); err != nil {
 			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 				Status:  "error",
 				Message: "failed to decode multisig account",
@@ -246,13 +452,13 @@ func (h *Handler) GetAllMultisigTxns(c echo.Context) error {
 
 		var rows *sql.Rows
 		if status == "PENDING" {
-			rows, err = h.DB.Query(`SELECT t.id, COALESCE(t.signed_at, '0001-01-01 00:00:00'::timestamp) AS signed_at, t.multisig_address,t.status,t.created_at,t.last_updated,t.memo,t.signatures,t.messages,t.hash,t.err_msg,t.fee, m.threshold, 
-		json_agg(jsonb_build_object('pubkey', p.pubkey, 'address', p.address, 'multisig_address',p.multisig_address)) AS pubkeys FROM transactions t JOIN multisig_accounts m ON t.multisig_address = m.address JOIN pubkeys p ON t.multisig_address = p.multisig_address WHERE t.multisig_address=$1 and t.status='PENDING' GROUP BY t.id, t.multisig_address, m.threshold, t.messages LIMIT $2 OFFSET $3`,
-				multisigAddress, limit, (page-1)*limit)
+			rows, err = h.DB.Query(`SELECT t.id, COALESCE(t.signed_at, '0001-01-01 00:00:00'::timestamp) AS signed_at, t.multisig_address,t.status,t.created_at,t.last_updated,t.memo,t.signatures,t.messages,t.hash,t.err_msg,t.fee,t.account_number,t.sequence,t.warnings,t.created_by,t.rejections,t.deleted_at,t.version,t.expires_at,t.timeout_height,(SELECT count(*) FROM tx_comments c WHERE c.tx_id = t.id) AS comments, m.threshold, 
+		json_agg(jsonb_build_object('pubkey', p.pubkey, 'address', p.address, 'multisig_address',p.multisig_address)) AS pubkeys FROM transactions t JOIN multisig_accounts m ON t.multisig_address = m.address JOIN pubkeys p ON t.multisig_address = p.multisig_address WHERE t.multisig_address=$1 and t.status IN ('PENDING','BROADCASTING','STALE') AND (t.deleted_at IS NULL OR $4) GROUP BY t.id, t.multisig_address, m.threshold, t.messages ORDER BY t.sequence NULLS LAST, t.id LIMIT $2 OFFSET $3`,
+				multisigAddress, limit, (page-1)*limit, includeDeleted)
 		} else {
-			rows, err = h.DB.Query(`SELECT t.id, COALESCE(t.signed_at, '0001-01-01 00:00:00'::timestamp) AS signed_at, t.multisig_address,t.status,t.created_at,t.last_updated,t.memo,t.signatures,t.messages,t.hash,t.err_msg,t.fee, m.threshold, 
-		json_agg(jsonb_build_object('pubkey', p.pubkey, 'address', p.address, 'multisig_address',p.multisig_address)) AS pubkeys FROM transactions t JOIN multisig_accounts m ON t.multisig_address = m.address JOIN pubkeys p ON t.multisig_address = p.multisig_address WHERE t.multisig_address=$1 and t.status <> 'PENDING' GROUP BY t.id, t.multisig_address, m.threshold, t.messages LIMIT $2 OFFSET $3`,
-				multisigAddress, limit, (page-1)*limit)
+			rows, err = h.DB.Query(`SELECT t.id, COALESCE(t.signed_at, '0001-01-01 00:00:00'::timestamp) AS signed_at, t.multisig_address,t.status,t.created_at,t.last_updated,t.memo,t.signatures,t.messages,t.hash,t.err_msg,t.fee,t.account_number,t.sequence,t.warnings,t.created_by,t.rejections,t.deleted_at,t.version,t.expires_at,t.timeout_height,(SELECT count(*) FROM tx_comments c WHERE c.tx_id = t.id) AS comments, m.threshold, 
+		json_agg(jsonb_build_object('pubkey', p.pubkey, 'address', p.address, 'multisig_address',p.multisig_address)) AS pubkeys FROM transactions t JOIN multisig_accounts m ON t.multisig_address = m.address JOIN pubkeys p ON t.multisig_address = p.multisig_address WHERE t.multisig_address=$1 and t.status NOT IN ('PENDING','BROADCASTING','STALE') AND (t.deleted_at IS NULL OR $4) GROUP BY t.id, t.multisig_address, m.threshold, t.messages LIMIT $2 OFFSET $3`,
+				multisigAddress, limit, (page-1)*limit, includeDeleted)
 		}
 		if err != nil {
 			if rows != nil && sql.ErrNoRows == rows.Err() {
@@ -270,6 +476,7 @@ func (h *Handler) GetAllMultisigTxns(c echo.Context) error {
 			})
 		}
 
+		chain := h.labelledChain(multisigAddress, chainID)
 		for rows.Next() {
 			var transaction schema.AllTransactionResult
 			var signedAt time.Time
@@ -287,6 +494,16 @@ func (h *Handler) GetAllMultisigTxns(c echo.Context) error {
 				&transaction.Hash,
 				&transaction.ErrMsg,
 				&transaction.Fee,
+				&transaction.AccountNumber,
+				&transaction.Sequence,
+				&transaction.Warnings,
+				&transaction.CreatedBy,
+				&transaction.Rejections,
+				&transaction.DeletedAt,
+				&transaction.Version,
+				&transaction.ExpiresAt,
+				&transaction.TimeoutHeight,
+				&transaction.Comments,
 				&transaction.Threshold,
 				&transaction.Pubkeys,
 			); err != nil {
@@ -301,6 +518,7 @@ func (h *Handler) GetAllMultisigTxns(c echo.Context) error {
 			} else {
 				transaction.SignedAt = signedAt
 			}
+			transaction.Summaries = summarizeStored(transaction.Messages, chain)
 			transactions = append(transactions, transaction)
 		}
 		rows.Close()
@@ -327,7 +545,7 @@ func (h *Handler) GetTransaction(c echo.Context) error {
 	}
 
 	row := h.DB.QueryRow(`SELECT id,multisig_address,fee,status,created_at,messages,hash,
-	err_msg,last_updated,memo,signatures FROM transactions WHERE id=$1 AND multisig_address=$2`, txId, address)
+	err_msg,last_updated,memo,signatures,account_number,sequence,warnings,created_by,rejections,deleted_at,version,expires_at,timeout_height FROM transactions WHERE id=$1 AND multisig_address=$2`, txId, address)
 
 	var transaction schema.Transaction
 	if err := row.Scan(
@@ -342,6 +560,15 @@ func (h *Handler) GetTransaction(c echo.Context) error {
 		&transaction.LastUpdated,
 		&transaction.Memo,
 		&transaction.Signatures,
+		&transaction.AccountNumber,
+		&transaction.Sequence,
+		&transaction.Warnings,
+		&transaction.CreatedBy,
+		&transaction.Rejections,
+		&transaction.DeletedAt,
+		&transaction.Version,
+		&transaction.ExpiresAt,
+		&transaction.TimeoutHeight,
 	); err != nil {
 		if sql.ErrNoRows == row.Err() {
 			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
@@ -358,6 +585,30 @@ func (h *Handler) GetTransaction(c echo.Context) error {
 		})
 	}
 
+	chainID := h.multisigChainID(address)
+	transaction.Summaries = summary.Summarize(summary.DecodeJSON(transaction.Messages), h.labelledChain(address, chainID))
+
+	if transaction.Status == string(model.Pending) || transaction.Status == string(model.Stale) {
+		var threshold int
+		if err := h.DB.QueryRow(`SELECT threshold FROM multisig_accounts WHERE address=$1`, address).Scan(&threshold); err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to query multisig account",
+				Log:     err.Error(),
+			})
+		}
+
+		policies, account, err := h.loadPolicies(address, chainID, threshold)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Status:  "error",
+				Message: "failed to query policies",
+				Log:     err.Error(),
+			})
+		}
+		transaction.Policy = policyStatus(policies, account, transaction.Status, transaction.Messages, transaction.Signatures)
+	}
+
 	return c.JSON(http.StatusOK, model.SuccessResponse{
 		Data:   transaction,
 		Status: "success",
@@ -393,59 +644,159 @@ func (h *Handler) SignTransaction(c echo.Context) error {
 		})
 	}
 
-	row := h.DB.QueryRow(`SELECT signatures FROM transactions WHERE id=$1 AND multisig_address=$2`, txId, address)
+	if !cosmos.SameAccount(req.Signer, utils.GetAuthAddress(c)) {
+		return c.JSON(http.StatusForbidden, model.ErrorResponse{
+			Status:  "error",
+			Message: "signer does not match the authenticated address",
+		})
+	}
 
-	var transaction schema.Transaction
+	prefix, _, err := cosmos.DecodeAddress(address)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid multisig address",
+			Log:     err.Error(),
+		})
+	}
+
+	signer, err := cosmos.ConvertAddress(req.Signer, prefix)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid signer address",
+			Log:     err.Error(),
+		})
+	}
+	req.Signer = signer
+
+	row := h.DB.QueryRow(`SELECT t.signatures,t.messages,t.fee,t.memo,t.status,t.account_number,t.sequence,t.rejections,t.version,t.expires_at,t.timeout_height,m.chain_id,m.threshold FROM transactions t
+	JOIN multisig_accounts m ON t.multisig_address = m.address WHERE t.id=$1 AND t.multisig_address=$2 AND t.deleted_at IS NULL`, txId, address)
+
+	var (
+		transaction schema.Transaction
+		chainID     string
+		threshold   int
+	)
 	if err := row.Scan(
 		&transaction.Signatures,
+		&transaction.Messages,
+		&transaction.Fee,
+		&transaction.Memo,
+		&transaction.Status,
+		&transaction.AccountNumber,
+		&transaction.Sequence,
+		&transaction.Rejections,
+		&transaction.Version,
+		&transaction.ExpiresAt,
+		&transaction.TimeoutHeight,
+		&chainID,
+		&threshold,
 	); err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "transaction not found",
+			})
+		}
 		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Status:  "error",
 			Message: err.Error(),
 		})
 	}
 
-	var signatures []Signature
-	if err := json.Unmarshal(transaction.Signatures, &signatures); err != nil {
-		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+	if transaction.Status != string(model.Pending) {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
 			Status:  "error",
-			Message: err.Error(),
+			Message: fmt.Sprintf("cannot sign a %s transaction", transaction.Status),
 		})
 	}
 
-	var result []Signature
-	if len(signatures) == 0 {
-		result = append(result, Signature{
-			Address:   req.Signer,
-			Signature: req.Signature,
+	if expired(transaction.ExpiresAt) {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: errExpiredTx(transaction.ExpiresAt).Error(),
 		})
-	} else {
-		exists := false
-		for _, sig := range signatures {
-			if sig.Address == req.Signer {
-				exists = true
-				result = append(result, Signature{
-					Address:   req.Signer,
-					Signature: req.Signature,
-				})
-			} else {
+	}
 
-				result = append(result, Signature{
-					Address:   sig.Address,
-					Signature: sig.Signature,
-				})
-			}
+	var rejections []Rejection
+	if err := json.Unmarshal(transaction.Rejections, &rejections); err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to decode rejections",
+			Log:     err.Error(),
+		})
+	}
 
+	for _, r := range rejections {
+		if r.Address == req.Signer {
+			return c.JSON(http.StatusConflict, model.ErrorResponse{
+				Status:  "error",
+				Message: "you already rejected the transaction",
+			})
 		}
+	}
+
+	// the policies or the outflow of the account may have changed since the transaction was created
+	policies, err := h.evaluatePolicies(address, chainID, threshold, summary.DecodeJSON(transaction.Messages), 0)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to evaluate the policies of the multisig account",
+			Log:     err.Error(),
+		})
+	}
+
+	if err := policies.Err(); err != nil {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	if status, err := h.verifyTxSignature(txId, address, chainID, transaction, req); err != nil {
+		return c.JSON(status, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
+
+	var signatures []Signature
+	if err := json.Unmarshal(transaction.Signatures, &signatures); err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
 
-		if !exists {
+	var (
+		result   []Signature
+		previous string
+	)
+	exists := false
+	for _, sig := range signatures {
+		if sig.Address == req.Signer {
+			exists = true
+			previous = sig.Signature
 			result = append(result, Signature{
 				Address:   req.Signer,
 				Signature: req.Signature,
 			})
+		} else {
+			result = append(result, Signature{
+				Address:   sig.Address,
+				Signature: sig.Signature,
+			})
 		}
 	}
 
+	if !exists {
+		result = append(result, Signature{
+			Address:   req.Signer,
+			Signature: req.Signature,
+		})
+	}
+
 	bz, err := json.Marshal(result)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
@@ -454,7 +805,38 @@ func (h *Handler) SignTransaction(c echo.Context) error {
 		})
 	}
 
-	_, err = h.DB.Exec("UPDATE transactions SET signatures=$1, signed_at=$2 WHERE id=$3", bz, time.Now().UTC(), id)
+	event := schema.AuditEvent{
+		MultisigAddress: address,
+		TxID:            audit.TxID(txId),
+		Actor:           req.Signer,
+		Action:          audit.TxSigned,
+		NewValue:        audit.Value(Signature{Address: req.Signer, Signature: req.Signature}),
+	}
+	if exists {
+		event.Action = audit.TxResigned
+		event.OldValue = audit.Value(Signature{Address: req.Signer, Signature: previous})
+	}
+
+	err = h.inTx(func(tx *sql.Tx) error {
+		// the signature was checked against this version of the transaction
+		result, err := tx.Exec("UPDATE transactions SET signatures=$1, signed_at=$2, version=version+1 WHERE id=$3 AND version=$4",
+			bz, time.Now().UTC(), id, transaction.Version)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := result.RowsAffected(); n == 0 {
+			return errTxChanged
+		}
+
+		return audit.Record(tx, event)
+	})
+	if err == errTxChanged {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: err.Error(),
+		})
+	}
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Status:  "error",
@@ -462,17 +844,112 @@ func (h *Handler) SignTransaction(c echo.Context) error {
 		})
 	}
 
+	data := events.Data(map[string]int{"signatures": len(result), "threshold": threshold})
+	events.Publish(events.Event{Type: events.TxSigned, MultisigAddress: address, TxID: txId, Actor: req.Signer, Data: data})
+	if len(signatures) < threshold && len(result) >= threshold {
+		events.Publish(events.Event{Type: events.ThresholdReached, MultisigAddress: address, TxID: txId, Actor: req.Signer, Data: data})
+	}
+
 	return c.JSON(http.StatusOK, model.SuccessResponse{
 		Status: "successfully signed",
 	})
 }
 
+// verifyTxSignature checks the signature against the amino JSON sign doc of the
+// transaction, the only sign mode supported by legacy amino multisig accounts.
+func (h *Handler) verifyTxSignature(txId int, address, chainID string, tx schema.Transaction, req *SignTxReq) (int, error) {
+	var pubkeyJSON []byte
+	err := h.DB.QueryRow(`SELECT pubkey FROM pubkeys WHERE multisig_address=$1 AND address=$2`, address, req.Signer).Scan(&pubkeyJSON)
+	if err == sql.ErrNoRows {
+		return http.StatusForbidden, fmt.Errorf("%s is not a signer of the multisig account", req.Signer)
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	pk, err := parseStoredPubkey(pubkeyJSON)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	doc, err := parseSignDoc(tx)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	info, status, err := h.signSequences(txId, address, chainID, tx)
+	if err != nil {
+		return status, err
+	}
+
+	if _, err := cosmos.VerifyAminoSignature(pk, chainID, info.AccountNumber, info.Sequences,
+		doc.Fee, doc.Memo, doc.TimeoutHeight, doc.Msgs, req.Signature); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid signature from %s: %w", req.Signer, err)
+	}
+
+	return http.StatusOK, nil
+}
+
 type UpdateTxReq struct {
 	Status       string `json:"status"`
 	ErrorMessage string `json:"error_message"`
 	TxHash       string `json:"hash"`
 }
 
+// chainTx is the part of a transaction fetched from the chain identifying its signer.
+type chainTx struct {
+	AuthInfo struct {
+		SignerInfos []struct {
+			PublicKey struct {
+				Type       string `json:"@type"`
+				Threshold  uint32 `json:"threshold"`
+				PublicKeys []struct {
+					Type string `json:"@type"`
+					Key  string `json:"key"`
+				} `json:"public_keys"`
+			} `json:"public_key"`
+			Sequence uint64 `json:"sequence,string"`
+		} `json:"signer_infos"`
+	} `json:"auth_info"`
+}
+
+// signedByMultisig reports whether the transaction fetched from the chain was signed by
+// the multisig account, at the sequence when one is given.
+func signedByMultisig(resp *txn_types.TxResponse, address string, sequence sql.NullInt64) bool {
+	bz, err := json.Marshal(resp.Tx)
+	if err != nil {
+		return false
+	}
+
+	var tx chainTx
+	if err := json.Unmarshal(bz, &tx); err != nil || len(tx.AuthInfo.SignerInfos) != 1 {
+		return false
+	}
+
+	signer := tx.AuthInfo.SignerInfos[0]
+	if signer.PublicKey.Type != cosmos.MultisigTypeUrl || (sequence.Valid && signer.Sequence != uint64(sequence.Int64)) {
+		return false
+	}
+
+	pubKeys := make([]cosmos.PubKey, 0, len(signer.PublicKey.PublicKeys))
+	for _, key := range signer.PublicKey.PublicKeys {
+		pk, err := cosmos.ParsePubKey(key.Type, key.Key)
+		if err != nil {
+			return false
+		}
+		pubKeys = append(pubKeys, pk)
+	}
+
+	_, addressBytes, err := cosmos.DecodeAddress(address)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(cosmos.NewMultisigPubKey(signer.PublicKey.Threshold, pubKeys).Address(), addressBytes)
+}
+
+// UpdateTransactionInfo records the outcome of a pending transaction the client
+// broadcasted on its own. The outcome is checked against the chain.
 func (h *Handler) UpdateTransactionInfo(c echo.Context) error {
 	id := c.Param("id")
 	address := c.Param("address")
@@ -493,18 +970,124 @@ func (h *Handler) UpdateTransactionInfo(c echo.Context) error {
 	}
 
 	status := utils.GetStatus(req.Status)
-	_, err = h.DB.Exec(`UPDATE transactions SET status=$1,hash=$2,err_msg=$3,last_updated=$4 WHERE id=$5 AND multisig_address=$6`,
-		status, req.TxHash, req.ErrorMessage, time.Now().UTC(), txId, address,
-	)
+	if (status != model.Success && status != model.Failed) || req.TxHash == "" {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "status must be SUCCESS or FAILED along with the hash of the transaction",
+		})
+	}
 
-	if err != nil {
+	chainID := h.multisigChainID(address)
+	if chainID == "" {
 		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("invalid multisig account address %s: not found", address),
+		})
+	}
+
+	resp, err := getTx(chainID, req.TxHash)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to fetch transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	errMsg := req.ErrorMessage
+	switch {
+	case resp == nil && status == model.Success:
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("transaction %s was not found on chain", req.TxHash),
+		})
+	case resp != nil && resp.Code == 0 && status == model.Failed:
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("transaction %s succeeded on chain", req.TxHash),
+		})
+	case resp != nil && resp.Code != 0 && status == model.Success:
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("transaction %s failed on chain: %s", req.TxHash, resp.RawLog),
+		})
+	case resp != nil && resp.Code != 0:
+		errMsg = resp.RawLog
+	}
+
+	actor := auditActor(c, address)
+	err = h.inTx(func(tx *sql.Tx) error {
+		var oldStatus string
+		var oldHash, oldErrMsg sql.NullString
+		var sequence sql.NullInt64
+		if err := tx.QueryRow(`SELECT status,hash,err_msg,sequence FROM transactions WHERE id=$1 AND multisig_address=$2 AND deleted_at IS NULL FOR UPDATE`,
+			txId, address).Scan(&oldStatus, &oldHash, &oldErrMsg, &sequence); err != nil {
+			return err
+		}
+
+		if oldStatus != string(model.Pending) {
+			return errTxNotPending
+		}
+
+		if resp != nil && !signedByMultisig(resp, address, sequence) {
+			return errTxHashMismatch
+		}
+
+		if _, err := tx.Exec(`UPDATE transactions SET status=$1,hash=$2,err_msg=$3,last_updated=$4 WHERE id=$5 AND multisig_address=$6 AND status=$7`,
+			status, req.TxHash, errMsg, time.Now().UTC(), txId, address, model.Pending,
+		); err != nil {
+			return err
+		}
+
+		if err := audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			TxID:            audit.TxID(txId),
+			Actor:           actor,
+			Action:          audit.TxUpdated,
+			OldValue:        audit.Value(map[string]string{"status": oldStatus, "hash": oldHash.String, "err_msg": oldErrMsg.String}),
+			NewValue:        audit.Value(map[string]string{"status": string(status), "hash": req.TxHash, "err_msg": errMsg}),
+		}); err != nil {
+			return err
+		}
+
+		// A transaction failing in a block used its sequence, only a transaction rejected
+		// before inclusion leaves it to the transactions queued after it.
+		if status == model.Failed && resp == nil && sequence.Valid {
+			return cron.ReleaseSequence(tx, address, uint64(sequence.Int64), actor)
+		}
+
+		return nil
+	})
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "transaction not found",
+			})
+		case errTxNotPending, errTxHashMismatch:
+			return c.JSON(http.StatusConflict, model.ErrorResponse{
+				Status:  "error",
+				Message: err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Status:  "error",
 			Message: "failed to update transaction",
 			Log:     err.Error(),
 		})
 	}
 
+	// clients broadcasting on their own report the outcome here
+	switch status {
+	case model.Success:
+		events.Publish(events.Event{Type: events.TxConfirmed, MultisigAddress: address, TxID: txId, Actor: actor,
+			Data: events.Data(map[string]string{"hash": req.TxHash})})
+	case model.Failed:
+		events.Publish(events.Event{Type: events.TxFailed, MultisigAddress: address, TxID: txId, Actor: actor,
+			Data: events.Data(map[string]string{"hash": req.TxHash, "err_msg": errMsg})})
+	}
+
 	return c.JSON(http.StatusOK, model.SuccessResponse{
 		Status: "transaction updated",
 	})
@@ -521,43 +1104,195 @@ func (h *Handler) DeleteTransaction(c echo.Context) error {
 		})
 	}
 
-	// Fetch signed_at before attempting to delete, to avoid issues if the transaction does not exist
-	var signedAt time.Time
-	var status string
-	var transaction schema.Transaction
-	err = h.DB.QueryRow(`SELECT signed_at,status, signatures FROM transactions WHERE id=$1 AND multisig_address=$2`, txId, address).Scan(&signedAt, &status, &transaction.Signatures)
+	actor := auditActor(c, address)
+	deletedAt := time.Now().UTC()
+	err = h.inTx(func(tx *sql.Tx) error {
+		var status string
+		var sequence sql.NullInt64
+		var messages, signatures []byte
+		if err := tx.QueryRow(`SELECT status,sequence,messages,signatures FROM transactions WHERE id=$1 AND multisig_address=$2 AND deleted_at IS NULL FOR UPDATE`,
+			txId, address).Scan(&status, &sequence, &messages, &signatures); err != nil {
+			return err
+		}
+
+		if status == model.Broadcasting {
+			return errTxBroadcasting
+		}
+
+		// The transaction is kept for the retention period. A pending transaction leaves the
+		// queue and is queued again when it is restored.
+		result, err := tx.Exec(`UPDATE transactions SET deleted_at=$1,last_updated=$1,sequence=CASE WHEN status=$2 THEN NULL ELSE sequence END
+		WHERE id=$3 AND multisig_address=$4 AND deleted_at IS NULL AND status=$5`, deletedAt, model.Pending, txId, address, status)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := result.RowsAffected(); n == 0 {
+			return errTxChanged
+		}
+
+		oldValue := map[string]interface{}{
+			"status":     status,
+			"messages":   json.RawMessage(messages),
+			"signatures": json.RawMessage(signatures),
+		}
+		if sequence.Valid {
+			oldValue["sequence"] = sequence.Int64
+		}
+
+		if err := audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			TxID:            audit.TxID(txId),
+			Actor:           actor,
+			Action:          audit.TxDeleted,
+			OldValue:        audit.Value(oldValue),
+			NewValue:        audit.Value(map[string]interface{}{"deleted_at": deletedAt}),
+		}); err != nil {
+			return err
+		}
+
+		// Transactions queued after a pending transaction take over its sequence
+		if status == string(model.Pending) && sequence.Valid {
+			return cron.ReleaseSequence(tx, address, uint64(sequence.Int64), actor)
+		}
+
+		return nil
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
+		switch err {
+		case sql.ErrNoRows:
 			return c.JSON(http.StatusNotFound, model.ErrorResponse{
 				Status:  "error",
 				Message: "transaction not found",
 			})
+		case errTxBroadcasting, errTxChanged:
+			return c.JSON(http.StatusConflict, model.ErrorResponse{
+				Status:  "error",
+				Message: err.Error(),
+			})
 		}
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to delete transaction",
+			Log:     err.Error(),
+		})
 	}
 
-	// Delete the transaction
-	_, err = h.DB.Exec(`DELETE FROM transactions WHERE id=$1 AND multisig_address=$2`, txId, address)
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status:  "transaction deleted",
+		Message: fmt.Sprintf("the transaction can be restored until %s", deletedAt.Add(h.RetentionPeriod).Format(time.RFC3339)),
+	})
+}
+
+// RestoreTransaction brings back a deleted transaction within the retention period. A
+// pending transaction is queued at the end of the queue, its signatures are cleared.
+func (h *Handler) RestoreTransaction(c echo.Context) error {
+	address := c.Param("address")
+	txId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid transaction id",
+		})
+	}
+
+	var chainID, status string
+	var deletedAt *time.Time
+	if err := h.DB.QueryRow(`SELECT m.chain_id,t.status,t.deleted_at FROM transactions t JOIN multisig_accounts m ON t.multisig_address = m.address
+	WHERE t.id=$1 AND t.multisig_address=$2`, txId, address).Scan(&chainID, &status, &deletedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Status:  "error",
+				Message: "transaction not found",
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Status:  "error",
-			Message: "failed to delete transaction",
+			Message: "failed to query transaction",
 			Log:     err.Error(),
 		})
 	}
 
-	// Clear signatures for transactions with signed_at > txSignedAt
-	if !signedAt.IsZero() && status == "PENDING" && len(transaction.Signatures) > 0 {
-		_, err = h.DB.Exec(`UPDATE transactions SET signatures='[]'::jsonb, signed_at = '0001-01-01 00:00:00' WHERE multisig_address=$1 AND signed_at > $2 and status='PENDING'`, address, signedAt)
+	if deletedAt == nil {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: "transaction is not deleted",
+		})
+	}
+
+	if !utils.Restorable(*deletedAt, h.RetentionPeriod) {
+		return c.JSON(http.StatusGone, model.ErrorResponse{
+			Status:  "error",
+			Message: "the retention period of the transaction is over",
+		})
+	}
+
+	var account *clients.Account
+	if status == string(model.Pending) {
+		account, err = getAccount(chainID, address)
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			return c.JSON(http.StatusBadGateway, model.ErrorResponse{
 				Status:  "error",
-				Message: "failed to update transaction signatures",
+				Message: "failed to fetch the multisig account from the chain",
 				Log:     err.Error(),
 			})
 		}
 	}
 
+	restored := true
+	newValue := map[string]interface{}{"status": status}
+	err = h.inTx(func(tx *sql.Tx) error {
+		var result sql.Result
+		if account != nil {
+			sequence, err := lockQueue(tx, address, account.Sequence)
+			if err != nil {
+				return err
+			}
+			newValue["sequence"] = sequence
+
+			result, err = tx.Exec(`UPDATE transactions SET deleted_at=NULL,signatures='[]'::jsonb,signed_at=NULL,account_number=$1,sequence=$2,version=version+1,last_updated=$3
+			WHERE id=$4 AND multisig_address=$5 AND deleted_at IS NOT NULL`, account.AccountNumber, sequence, time.Now().UTC(), txId, address)
+			if err != nil {
+				return err
+			}
+		} else {
+			result, err = tx.Exec(`UPDATE transactions SET deleted_at=NULL,last_updated=$1 WHERE id=$2 AND multisig_address=$3 AND deleted_at IS NOT NULL`,
+				time.Now().UTC(), txId, address)
+			if err != nil {
+				return err
+			}
+		}
+
+		if n, _ := result.RowsAffected(); n == 0 {
+			restored = false
+			return nil
+		}
+
+		return audit.Record(tx, schema.AuditEvent{
+			MultisigAddress: address,
+			TxID:            audit.TxID(txId),
+			Actor:           auditActor(c, address),
+			Action:          audit.TxRestored,
+			OldValue:        audit.Value(map[string]interface{}{"deleted_at": deletedAt}),
+			NewValue:        audit.Value(newValue),
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to restore transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if !restored {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: "transaction is not deleted",
+		})
+	}
+
 	return c.JSON(http.StatusOK, model.SuccessResponse{
-		Status: "transaction deleted",
+		Status: "transaction restored",
 	})
 }
@@ -0,0 +1,361 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/clients"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/txn_types"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+func testAddress(t *testing.T, prefix string, b byte) string {
+	address, err := cosmos.EncodeAddress(prefix, bytes.Repeat([]byte{b}, 20))
+	require.NoError(t, err)
+
+	return address
+}
+
+func TestSignTransactionSigner(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	member := testAddress(t, "osmo", 0x01)
+	other := testAddress(t, "osmo", 0x02)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	e := echo.New()
+	body := `{"signer":"` + other + `","signature":"c2lnbmF0dXJl"}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("address", "id")
+	c.SetParamValues(multisig, "1")
+	c.Set(utils.AUTH_ADDRESS_KEY, member)
+
+	h := &Handler{DB: db}
+	require.NoError(t, h.SignTransaction(c))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSignTransactionSignature(t *testing.T) {
+	f := newTxFixture(t)
+	mockAccount(t)
+
+	pubkey := `{"type":"` + cosmos.Secp256k1TypeUrl + `","value":"` + base64.StdEncoding.EncodeToString(f.pubkeys[0].Key) + `"}`
+
+	testCases := []struct {
+		name      string
+		status    string
+		sequence  driver.Value
+		signature string
+		rejected  bool
+		changed   bool
+		expired   bool
+		expStatus int
+	}{
+		{"signature for the stored sequence", "PENDING", int64(4), f.sign(t, 0, 4).Signature, false, false, false, http.StatusOK},
+		{"signature for another sequence", "PENDING", int64(4), f.sign(t, 0, 3).Signature, false, false, false, http.StatusBadRequest},
+		{"legacy transaction signed for a queued sequence", "PENDING", nil, f.sign(t, 0, 4).Signature, false, false, false, http.StatusOK},
+		{"junk signature", "PENDING", int64(4), "c2lnbmF0dXJl", false, false, false, http.StatusBadRequest},
+		{"stale transaction", "STALE", int64(2), f.sign(t, 0, 2).Signature, false, false, false, http.StatusConflict},
+		{"signer rejected the transaction", "PENDING", int64(4), f.sign(t, 0, 4).Signature, true, false, false, http.StatusConflict},
+		{"transaction changed while signing", "PENDING", int64(4), f.sign(t, 0, 4).Signature, false, true, false, http.StatusConflict},
+		{"expired transaction", "PENDING", int64(4), f.sign(t, 0, 4).Signature, false, false, true, http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			var accountNumber driver.Value
+			if tc.sequence != nil {
+				accountNumber = int64(7)
+			}
+
+			rejections := "[]"
+			if tc.rejected {
+				rejections = `[{"address":"` + f.members[0] + `","reason":"","rejected_at":"2024-01-01T00:00:00Z"}]`
+			}
+
+			var expiresAt driver.Value
+			if tc.expired {
+				expiresAt = time.Now().Add(-time.Minute)
+			}
+
+			mock.ExpectQuery("SELECT t.signatures").WithArgs(1, f.multisig).WillReturnRows(
+				sqlmock.NewRows([]string{"signatures", "messages", "fee", "memo", "status", "account_number", "sequence", "rejections", "version", "expires_at", "timeout_height", "chain_id", "threshold"}).
+					AddRow([]byte("[]"), []byte(f.messages), []byte(f.fee), "", tc.status, accountNumber, tc.sequence, []byte(rejections), 3, expiresAt, nil, "osmosis-1", 2))
+			if tc.status == "PENDING" && !tc.rejected && !tc.expired {
+				expectPolicies(t, mock, f.multisig, nil)
+				mock.ExpectQuery("SELECT pubkey FROM pubkeys").WithArgs(f.multisig, f.members[0]).WillReturnRows(
+					sqlmock.NewRows([]string{"pubkey"}).AddRow([]byte(pubkey)))
+			}
+			if tc.status == "PENDING" && tc.sequence == nil {
+				mock.ExpectQuery("SELECT count").WithArgs(f.multisig, model.Pending, 1).WillReturnRows(
+					sqlmock.NewRows([]string{"count"}).AddRow(1))
+			}
+			if tc.expStatus == http.StatusOK || tc.changed {
+				updated := int64(1)
+				if tc.changed {
+					updated = 0
+				}
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE transactions SET signatures").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "1", 3).
+					WillReturnResult(sqlmock.NewResult(0, updated))
+				if tc.changed {
+					mock.ExpectRollback()
+				} else {
+					expectAudit(mock, f.multisig)
+					mock.ExpectCommit()
+				}
+			}
+
+			e := echo.New()
+			body := `{"signer":"` + f.members[0] + `","signature":"` + tc.signature + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("address", "id")
+			c.SetParamValues(f.multisig, "1")
+			c.Set(utils.AUTH_ADDRESS_KEY, f.members[0])
+
+			h := &Handler{DB: db}
+			require.NoError(t, h.SignTransaction(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// chainTxResponse returns the transaction of the fixture as fetched from the chain.
+func chainTxResponse(t *testing.T, f *txFixture, code int, sequence string) *txn_types.TxResponse {
+	keys := make([]interface{}, 0, len(f.pubkeys))
+	for _, pk := range cosmos.SortPubKeys(f.pubkeys) {
+		keys = append(keys, map[string]interface{}{"@type": cosmos.Secp256k1TypeUrl, "key": base64.StdEncoding.EncodeToString(pk.Key)})
+	}
+
+	bz, err := json.Marshal(map[string]interface{}{
+		"auth_info": map[string]interface{}{
+			"signer_infos": []interface{}{map[string]interface{}{
+				"public_key": map[string]interface{}{"@type": cosmos.MultisigTypeUrl, "threshold": 2, "public_keys": keys},
+				"sequence":   sequence,
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	var tx interface{}
+	require.NoError(t, json.Unmarshal(bz, &tx))
+
+	return &txn_types.TxResponse{Code: code, RawLog: "out of gas", Tx: tx}
+}
+
+func TestUpdateTransactionInfo(t *testing.T) {
+	f := newTxFixture(t)
+	other := newTxFixture(t)
+	other.pubkeys = other.pubkeys[:1]
+
+	testCases := []struct {
+		name       string
+		body       string
+		chainTx    *txn_types.TxResponse
+		status     string
+		expStatus  int
+		expRelease bool
+	}{
+		{"confirmed success", `{"status":"SUCCESS","hash":"ABCD"}`, chainTxResponse(t, f, 0, "4"), "PENDING", http.StatusOK, false},
+		{"failed in a block", `{"status":"FAILED","hash":"ABCD"}`, chainTxResponse(t, f, 11, "4"), "PENDING", http.StatusOK, false},
+		{"failed before inclusion", `{"status":"FAILED","hash":"ABCD","error_message":"insufficient fees"}`, nil, "PENDING", http.StatusOK, true},
+		{"success not found on chain", `{"status":"SUCCESS","hash":"ABCD"}`, nil, "", http.StatusConflict, false},
+		{"success failed on chain", `{"status":"SUCCESS","hash":"ABCD"}`, chainTxResponse(t, f, 11, "4"), "", http.StatusConflict, false},
+		{"hash of another account", `{"status":"SUCCESS","hash":"ABCD"}`, chainTxResponse(t, other, 0, "4"), "PENDING", http.StatusConflict, false},
+		{"hash at another sequence", `{"status":"SUCCESS","hash":"ABCD"}`, chainTxResponse(t, f, 0, "5"), "PENDING", http.StatusConflict, false},
+		{"cancelled transaction", `{"status":"SUCCESS","hash":"ABCD"}`, chainTxResponse(t, f, 0, "4"), "CANCELLED", http.StatusConflict, false},
+		{"transaction being broadcasted", `{"status":"FAILED","hash":"ABCD"}`, nil, "BROADCASTING", http.StatusConflict, false},
+		{"back to pending", `{"status":"PENDING","hash":"ABCD"}`, nil, "", http.StatusBadRequest, false},
+		{"missing hash", `{"status":"SUCCESS"}`, nil, "", http.StatusBadRequest, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			getTx = func(chainId, hash string) (*txn_types.TxResponse, error) {
+				require.Equal(t, "osmosis-1", chainId)
+				return tc.chainTx, nil
+			}
+			t.Cleanup(func() { getTx = clients.GetTx })
+
+			if tc.expStatus != http.StatusBadRequest {
+				mock.ExpectQuery("SELECT chain_id FROM multisig_accounts").WithArgs(f.multisig).WillReturnRows(
+					sqlmock.NewRows([]string{"chain_id"}).AddRow("osmosis-1"))
+			}
+			if tc.status != "" {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT status,hash,err_msg,sequence FROM transactions (.+) FOR UPDATE").WithArgs(1, f.multisig).WillReturnRows(
+					sqlmock.NewRows([]string{"status", "hash", "err_msg", "sequence"}).AddRow(tc.status, nil, nil, int64(4)))
+			}
+			if tc.expStatus == http.StatusOK {
+				mock.ExpectExec("UPDATE transactions SET status").WithArgs(sqlmock.AnyArg(), "ABCD", sqlmock.AnyArg(), sqlmock.AnyArg(), 1, f.multisig, model.Pending).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, f.multisig)
+				if tc.expRelease {
+					expectRelease(mock, f.multisig, 4, 2)
+				}
+				mock.ExpectCommit()
+			} else if tc.status != "" {
+				mock.ExpectRollback()
+			}
+
+			c, rec := commentContext(http.MethodPost, tc.body, []string{"address", "id"}, []string{f.multisig, "1"}, f.members[0])
+			h := &Handler{DB: db}
+			require.NoError(t, h.UpdateTransactionInfo(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteTransaction(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	admin := testAddress(t, "osmo", 0x01)
+
+	testCases := []struct {
+		name       string
+		status     string
+		found      bool
+		changed    bool
+		expStatus  int
+		expRelease bool
+	}{
+		{"pending transaction", "PENDING", true, false, http.StatusOK, true},
+		{"executed transaction", "SUCCESS", true, false, http.StatusOK, false},
+		{"transaction being broadcasted", "BROADCASTING", true, false, http.StatusConflict, false},
+		{"transaction changed concurrently", "PENDING", true, true, http.StatusConflict, false},
+		{"deleted or missing transaction", "", false, false, http.StatusNotFound, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			rows := sqlmock.NewRows([]string{"status", "sequence", "messages", "signatures"})
+			if tc.found {
+				rows.AddRow(tc.status, int64(4), []byte("[]"), []byte("[]"))
+			}
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT status,sequence,messages,signatures FROM transactions (.+) deleted_at IS NULL FOR UPDATE").
+				WithArgs(1, multisig).WillReturnRows(rows)
+			if tc.expStatus == http.StatusOK || tc.changed {
+				affected := int64(1)
+				if tc.changed {
+					affected = 0
+				}
+				mock.ExpectExec("UPDATE transactions SET deleted_at(.+) AND deleted_at IS NULL AND status=\\$5").
+					WithArgs(sqlmock.AnyArg(), model.Pending, 1, multisig, tc.status).WillReturnResult(sqlmock.NewResult(0, affected))
+			}
+			if tc.expStatus == http.StatusOK {
+				expectAudit(mock, multisig)
+				if tc.expRelease {
+					expectRelease(mock, multisig, 4, 2)
+				}
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodDelete, "/", nil), rec)
+			c.SetParamNames("address", "id")
+			c.SetParamValues(multisig, "1")
+			c.Set(utils.AUTH_ADDRESS_KEY, admin)
+
+			h := &Handler{DB: db, RetentionPeriod: 24 * time.Hour}
+			require.NoError(t, h.DeleteTransaction(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRestoreTransaction(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	admin := testAddress(t, "osmo", 0x01)
+	mockAccount(t)
+
+	testCases := []struct {
+		name      string
+		status    string
+		deletedAt driver.Value
+		expStatus int
+	}{
+		{"deleted pending transaction", "PENDING", time.Now().Add(-time.Hour), http.StatusOK},
+		{"deleted executed transaction", "SUCCESS", time.Now().Add(-time.Hour), http.StatusOK},
+		{"transaction not deleted", "PENDING", nil, http.StatusConflict},
+		{"retention period over", "SUCCESS", time.Now().Add(-48 * time.Hour), http.StatusGone},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT m.chain_id,t.status,t.deleted_at FROM transactions").WithArgs(1, multisig).WillReturnRows(
+				sqlmock.NewRows([]string{"chain_id", "status", "deleted_at"}).AddRow("osmosis-1", tc.status, tc.deletedAt))
+			if tc.expStatus == http.StatusOK {
+				mock.ExpectBegin()
+				if tc.status == "PENDING" {
+					mock.ExpectQuery("SELECT address FROM multisig_accounts").WithArgs(multisig).WillReturnRows(
+						sqlmock.NewRows([]string{"address"}).AddRow(multisig))
+					mock.ExpectQuery("UPDATE transactions SET status").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+					mock.ExpectQuery("SELECT MAX\\(sequence\\)").WithArgs(multisig, model.Pending, model.Broadcasting).
+						WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(int64(4)))
+					mock.ExpectExec("UPDATE transactions SET deleted_at=NULL,signatures").
+						WithArgs(uint64(7), uint64(5), sqlmock.AnyArg(), 1, multisig).WillReturnResult(sqlmock.NewResult(0, 1))
+				} else {
+					mock.ExpectExec("UPDATE transactions SET deleted_at=NULL,last_updated").
+						WithArgs(sqlmock.AnyArg(), 1, multisig).WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+				expectAudit(mock, multisig)
+				mock.ExpectCommit()
+			}
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+			c.SetParamNames("address", "id")
+			c.SetParamValues(multisig, "1")
+			c.Set(utils.AUTH_ADDRESS_KEY, admin)
+
+			h := &Handler{DB: db, RetentionPeriod: 24 * time.Hour}
+			require.NoError(t, h.RestoreTransaction(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
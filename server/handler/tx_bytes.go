@@ -0,0 +1,520 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/audit"
+	"github.com/vitwit/resolute/server/clients"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/cron"
+	"github.com/vitwit/resolute/server/events"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+// chain clients, replaced in tests to avoid querying the chain
+var (
+	getAccount  = clients.GetAccount
+	broadcastTx = clients.BroadcastTx
+	simulateTx  = clients.SimulateTx
+	getTx       = clients.GetTx
+)
+
+// signDoc is the part of a stored transaction covered by member signatures.
+type signDoc struct {
+	Msgs          []cosmos.Msg
+	Fee           cosmos.StdFee
+	Memo          string
+	TimeoutHeight uint64
+}
+
+func parseSignDoc(tx schema.Transaction) (signDoc, error) {
+	var messages []model.Message
+	if err := json.Unmarshal(tx.Messages, &messages); err != nil {
+		return signDoc{}, fmt.Errorf("invalid stored messages: %w", err)
+	}
+
+	var fee model.Fees
+	if tx.Fee != nil {
+		if err := json.Unmarshal(*tx.Fee, &fee); err != nil {
+			return signDoc{}, fmt.Errorf("invalid stored fee: %w", err)
+		}
+	}
+
+	doc := signDoc{
+		Msgs: model.Msgs(messages),
+		Fee:  fee.StdFee(),
+	}
+	if tx.Memo != nil {
+		doc.Memo = *tx.Memo
+	}
+	if tx.TimeoutHeight != nil {
+		doc.TimeoutHeight = *tx.TimeoutHeight
+	}
+
+	return doc, nil
+}
+
+func parseStoredPubkey(bz []byte) (cosmos.PubKey, error) {
+	var pubkey model.Pubkey
+	if err := json.Unmarshal(bz, &pubkey); err != nil {
+		return cosmos.PubKey{}, fmt.Errorf("invalid stored pubkey: %w", err)
+	}
+
+	pk, err := pubkey.Parse()
+	if err != nil {
+		return cosmos.PubKey{}, fmt.Errorf("invalid stored pubkey: %w", err)
+	}
+
+	return pk, nil
+}
+
+// signInfo holds the account number and the sequences a transaction may be signed for.
+type signInfo struct {
+	AccountNumber uint64
+	Sequences     []uint64
+	// ChainSequence is the current sequence of the multisig account on chain
+	ChainSequence uint64
+}
+
+// signSequences returns the account number and sequence the transaction is queued for.
+// A pending transaction whose sequence was already used on chain is marked as stale.
+// Transactions created before sequences were recorded accept any sequence up to the
+// number of other pending transactions, as the frontend signed them at the sequence
+// they would be broadcast at.
+func (h *Handler) signSequences(txId int, address, chainID string, tx schema.Transaction) (*signInfo, int, error) {
+	account, err := getAccount(chainID, address)
+	if err != nil {
+		return nil, http.StatusBadGateway, fmt.Errorf("failed to fetch the multisig account from the chain: %w", err)
+	}
+
+	if tx.Sequence != nil {
+		if account.Sequence > *tx.Sequence {
+			if err := h.inTx(func(tx *sql.Tx) error {
+				return cron.MarkStale(tx, address, account.Sequence)
+			}); err != nil {
+				return nil, http.StatusInternalServerError, err
+			}
+			return nil, http.StatusConflict, errStaleTx
+		}
+
+		info := &signInfo{
+			AccountNumber: account.AccountNumber,
+			Sequences:     []uint64{*tx.Sequence},
+			ChainSequence: account.Sequence,
+		}
+		if tx.AccountNumber != nil {
+			info.AccountNumber = *tx.AccountNumber
+		}
+
+		return info, http.StatusOK, nil
+	}
+
+	var pending uint64
+	if err := h.DB.QueryRow(`SELECT count(*) FROM transactions WHERE multisig_address=$1 AND status=$2 AND id<>$3 AND deleted_at IS NULL`,
+		address, model.Pending, txId).Scan(&pending); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	info := &signInfo{
+		AccountNumber: account.AccountNumber,
+		Sequences:     make([]uint64, 0, pending+1),
+		ChainSequence: account.Sequence,
+	}
+	for i := uint64(0); i <= pending; i++ {
+		info.Sequences = append(info.Sequences, account.Sequence+i)
+	}
+
+	return info, http.StatusOK, nil
+}
+
+// multisigMember is a key of the multisig with the address it is stored under.
+type multisigMember struct {
+	Address string
+	PubKey  cosmos.PubKey
+}
+
+// getMultisigPubKey rebuilds the multisig public key of the account. Keys are not stored
+// in order, so both the stored and the sorted order are checked against the address.
+func (h *Handler) getMultisigPubKey(address string, threshold int) (cosmos.MultisigPubKey, []multisigMember, error) {
+	rows, err := h.DB.Query(`SELECT address, pubkey FROM pubkeys WHERE multisig_address=$1`, address)
+	if err != nil {
+		return cosmos.MultisigPubKey{}, nil, err
+	}
+	defer rows.Close()
+
+	var members []multisigMember
+	for rows.Next() {
+		var member multisigMember
+		var pubkeyJSON []byte
+		if err := rows.Scan(&member.Address, &pubkeyJSON); err != nil {
+			return cosmos.MultisigPubKey{}, nil, err
+		}
+
+		if member.PubKey, err = parseStoredPubkey(pubkeyJSON); err != nil {
+			return cosmos.MultisigPubKey{}, nil, err
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return cosmos.MultisigPubKey{}, nil, err
+	}
+
+	_, addressBz, err := cosmos.DecodeAddress(address)
+	if err != nil {
+		return cosmos.MultisigPubKey{}, nil, err
+	}
+
+	pubkeys := make([]cosmos.PubKey, len(members))
+	for i, m := range members {
+		pubkeys[i] = m.PubKey
+	}
+
+	for _, ordered := range [][]cosmos.PubKey{pubkeys, cosmos.SortPubKeys(pubkeys)} {
+		pk := cosmos.NewMultisigPubKey(uint32(threshold), ordered)
+		if string(pk.Address()) != string(addressBz) {
+			continue
+		}
+
+		result := make([]multisigMember, len(ordered))
+		for i, key := range ordered {
+			for _, m := range members {
+				if string(m.PubKey.Key) == string(key.Key) {
+					result[i] = m
+				}
+			}
+		}
+
+		return pk, result, nil
+	}
+
+	return cosmos.MultisigPubKey{}, nil, fmt.Errorf("stored pubkeys do not match the multisig address %s", address)
+}
+
+type TxBytesResponse struct {
+	TxBytes       string `json:"tx_bytes"`
+	ChainID       string `json:"chain_id"`
+	AccountNumber uint64 `json:"account_number,string"`
+	Sequence      uint64 `json:"sequence,string"`
+}
+
+// assembleTx builds the signed TxRaw of the transaction from the stored signatures that
+// are valid for the same sequence.
+func (h *Handler) assembleTx(txId int, address string) (*TxBytesResponse, int, error) {
+	row := h.DB.QueryRow(`SELECT t.signatures,t.messages,t.fee,t.memo,t.status,t.account_number,t.sequence,t.expires_at,t.timeout_height,m.chain_id,m.threshold,m.recipient_mode FROM transactions t
+	JOIN multisig_accounts m ON t.multisig_address = m.address WHERE t.id=$1 AND t.multisig_address=$2 AND t.deleted_at IS NULL`, txId, address)
+
+	var (
+		transaction   schema.Transaction
+		chainID       string
+		threshold     int
+		recipientMode string
+	)
+	if err := row.Scan(
+		&transaction.Signatures,
+		&transaction.Messages,
+		&transaction.Fee,
+		&transaction.Memo,
+		&transaction.Status,
+		&transaction.AccountNumber,
+		&transaction.Sequence,
+		&transaction.ExpiresAt,
+		&transaction.TimeoutHeight,
+		&chainID,
+		&threshold,
+		&recipientMode,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, fmt.Errorf("transaction not found")
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+
+	// the signatures of other transactions were used, refused or never to be used on chain
+	if transaction.Status != string(model.Pending) {
+		return nil, http.StatusConflict, fmt.Errorf("transaction is %s, only pending transactions can be broadcast", transaction.Status)
+	}
+
+	if expired(transaction.ExpiresAt) {
+		return nil, http.StatusConflict, errExpiredTx(transaction.ExpiresAt)
+	}
+
+	doc, err := parseSignDoc(transaction)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	var signatures []Signature
+	if err := json.Unmarshal(transaction.Signatures, &signatures); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if len(signatures) < threshold {
+		return nil, http.StatusBadRequest, fmt.Errorf("transaction has %d of %d required signatures", len(signatures), threshold)
+	}
+
+	policies, err := h.evaluatePolicies(address, chainID, threshold, doc.Msgs, len(signatures))
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	if err := policies.Err(); err != nil {
+		return nil, http.StatusConflict, err
+	}
+
+	// the address book may have changed since the transaction was proposed
+	if _, status, err := h.checkRecipients(address, recipientMode, doc.Msgs); err != nil {
+		return nil, status, err
+	}
+
+	// policies may require more signatures than the threshold of the multisig
+	required := policies.RequiredSignatures
+	if len(signatures) < required {
+		return nil, http.StatusBadRequest, fmt.Errorf("transaction has %d of %d signatures required by the policies of the multisig account",
+			len(signatures), required)
+	}
+
+	multisigPk, members, err := h.getMultisigPubKey(address, threshold)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	info, status, err := h.signSequences(txId, address, chainID, transaction)
+	if err != nil {
+		return nil, status, err
+	}
+
+	if transaction.Sequence != nil && *transaction.Sequence > info.ChainSequence {
+		return nil, http.StatusConflict, fmt.Errorf("transactions queued before sequence %d must be broadcast first", *transaction.Sequence)
+	}
+
+	accountNumber := info.AccountNumber
+	for _, sequence := range info.Sequences {
+		sigs := make([][]byte, len(members))
+		count := 0
+		for i, member := range members {
+			for _, sig := range signatures {
+				if !cosmos.SameAccount(sig.Address, member.Address) {
+					continue
+				}
+
+				if _, err := cosmos.VerifyAminoSignature(member.PubKey, chainID, accountNumber, []uint64{sequence},
+					doc.Fee, doc.Memo, doc.TimeoutHeight, doc.Msgs, sig.Signature); err == nil {
+					sigs[i], _ = base64.StdEncoding.DecodeString(sig.Signature)
+					count++
+				}
+				break
+			}
+		}
+
+		if count < required {
+			continue
+		}
+
+		txBytes, err := cosmos.MultisigTxBytes(multisigPk, sequence, doc.Fee, doc.Memo, doc.TimeoutHeight, doc.Msgs, sigs)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+
+		return &TxBytesResponse{
+			TxBytes:       base64.StdEncoding.EncodeToString(txBytes),
+			ChainID:       chainID,
+			AccountNumber: accountNumber,
+			Sequence:      sequence,
+		}, http.StatusOK, nil
+	}
+
+	return nil, http.StatusConflict, fmt.Errorf("signatures are not valid for the current account sequence")
+}
+
+func (h *Handler) GetTxBytes(c echo.Context) error {
+	address := c.Param("address")
+	txId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid transaction id ",
+		})
+	}
+
+	resp, status, err := h.assembleTx(txId, address)
+	if err != nil {
+		return c.JSON(status, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to assemble the transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   resp,
+	})
+}
+
+type BroadcastTxResponse struct {
+	Hash string `json:"hash"`
+}
+
+// BroadcastTransaction submits the assembled transaction to the chain. The transaction
+// stays BROADCASTING until the cron job sees it included in a block.
+func (h *Handler) BroadcastTransaction(c echo.Context) error {
+	address := c.Param("address")
+	txId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "invalid transaction id ",
+		})
+	}
+
+	tx, status, err := h.assembleTx(txId, address)
+	if err != nil {
+		return c.JSON(status, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to assemble the transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	actor := auditActor(c, address)
+
+	// claim the transaction so that concurrent requests broadcast it only once
+	claimed := true
+	err = h.inTx(func(dbTx *sql.Tx) error {
+		result, err := dbTx.Exec(`UPDATE transactions SET status=$1,last_updated=$2 WHERE id=$3 AND multisig_address=$4 AND status=$5
+		AND (expires_at IS NULL OR expires_at>$2)`,
+			model.Broadcasting, time.Now().UTC(), txId, address, model.Pending)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := result.RowsAffected(); n == 0 {
+			claimed = false
+			return nil
+		}
+
+		return audit.Record(dbTx, schema.AuditEvent{
+			MultisigAddress: address,
+			TxID:            audit.TxID(txId),
+			Actor:           actor,
+			Action:          audit.StatusChanged,
+			OldValue:        audit.Status(string(model.Pending)),
+			NewValue:        audit.Status(model.Broadcasting),
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to update transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if !claimed {
+		return c.JSON(http.StatusConflict, model.ErrorResponse{
+			Status:  "error",
+			Message: "transaction is not pending or expired",
+		})
+	}
+
+	txBytes, _ := base64.StdEncoding.DecodeString(tx.TxBytes)
+	resp, err := broadcastTx(tx.ChainID, txBytes)
+	if err != nil {
+		if err := h.inTx(func(dbTx *sql.Tx) error {
+			if _, err := dbTx.Exec(`UPDATE transactions SET status=$1,last_updated=$2 WHERE id=$3 AND multisig_address=$4`,
+				model.Pending, time.Now().UTC(), txId, address); err != nil {
+				return err
+			}
+
+			return audit.Record(dbTx, schema.AuditEvent{
+				MultisigAddress: address,
+				TxID:            audit.TxID(txId),
+				Actor:           actor,
+				Action:          audit.StatusChanged,
+				OldValue:        audit.Status(model.Broadcasting),
+				NewValue:        audit.Status(string(model.Pending)),
+			})
+		}); err != nil {
+			utils.ErrorLogger.Printf("failed to reset transaction %d: %s\n", txId, err.Error())
+		}
+
+		return c.JSON(http.StatusBadGateway, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to broadcast the transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	if resp.Code != 0 {
+		if err := h.inTx(func(dbTx *sql.Tx) error {
+			if _, err := dbTx.Exec(`UPDATE transactions SET status=$1,hash=$2,err_msg=$3,last_updated=$4 WHERE id=$5 AND multisig_address=$6`,
+				model.Failed, resp.Txhash, resp.RawLog, time.Now().UTC(), txId, address); err != nil {
+				return err
+			}
+
+			if err := audit.Record(dbTx, schema.AuditEvent{
+				MultisigAddress: address,
+				TxID:            audit.TxID(txId),
+				Actor:           actor,
+				Action:          audit.StatusChanged,
+				OldValue:        audit.Status(model.Broadcasting),
+				NewValue:        audit.Value(map[string]string{"status": model.Failed, "hash": resp.Txhash, "err_msg": resp.RawLog}),
+			}); err != nil {
+				return err
+			}
+
+			// the transaction was rejected before inclusion, so its sequence is still unused
+			return cron.ReleaseSequence(dbTx, address, tx.Sequence, actor)
+		}); err != nil {
+			utils.ErrorLogger.Printf("failed to update transaction %d: %s\n", txId, err.Error())
+		}
+
+		events.Publish(events.Event{Type: events.TxFailed, MultisigAddress: address, TxID: txId, Actor: actor,
+			Data: events.Data(map[string]string{"hash": resp.Txhash, "err_msg": resp.RawLog})})
+
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "transaction was rejected by the chain",
+			Log:     resp.RawLog,
+		})
+	}
+
+	err = h.inTx(func(dbTx *sql.Tx) error {
+		if _, err := dbTx.Exec(`UPDATE transactions SET hash=$1,last_updated=$2 WHERE id=$3 AND multisig_address=$4`,
+			resp.Txhash, time.Now().UTC(), txId, address); err != nil {
+			return err
+		}
+
+		return audit.Record(dbTx, schema.AuditEvent{
+			MultisigAddress: address,
+			TxID:            audit.TxID(txId),
+			Actor:           actor,
+			Action:          audit.TxBroadcast,
+			NewValue:        audit.Value(map[string]string{"hash": resp.Txhash}),
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to update transaction",
+			Log:     err.Error(),
+		})
+	}
+
+	events.Publish(events.Event{Type: events.TxBroadcast, MultisigAddress: address, TxID: txId, Actor: actor,
+		Data: events.Data(map[string]string{"hash": resp.Txhash})})
+
+	return c.JSON(http.StatusOK, model.SuccessResponse{
+		Status: "success",
+		Data:   BroadcastTxResponse{Hash: resp.Txhash},
+	})
+}
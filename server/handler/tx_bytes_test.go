@@ -0,0 +1,450 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/clients"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/txn_types"
+)
+
+type txFixture struct {
+	keys     []*secp256k1.PrivateKey
+	pubkeys  []cosmos.PubKey
+	members  []string
+	multisig string
+	messages string
+	fee      string
+	// timeoutHeight and expiresAt are stored with the transaction when set
+	timeoutHeight uint64
+	expiresAt     driver.Value
+	// status is the status of the transaction, PENDING when empty
+	status string
+	// recipientMode is the recipient mode of the multisig, off when empty
+	recipientMode string
+}
+
+// newTxFixture creates a 2 of 2 multisig with a pending bank send.
+func newTxFixture(t *testing.T) *txFixture {
+	f := &txFixture{}
+	for i := 1; i <= 2; i++ {
+		key := secp256k1.PrivKeyFromBytes([]byte(fmt.Sprintf("resolute-test-private-key-00000%d", i)))
+		pk, err := cosmos.ParsePubKey(cosmos.Secp256k1TypeUrl, base64.StdEncoding.EncodeToString(key.PubKey().SerializeCompressed()))
+		require.NoError(t, err)
+		member, err := cosmos.EncodeAddress("osmo", pk.Address())
+		require.NoError(t, err)
+
+		f.keys = append(f.keys, key)
+		f.pubkeys = append(f.pubkeys, pk)
+		f.members = append(f.members, member)
+	}
+
+	var err error
+	f.multisig, err = cosmos.EncodeAddress("osmo", cosmos.NewMultisigPubKey(2, cosmos.SortPubKeys(f.pubkeys)).Address())
+	require.NoError(t, err)
+
+	f.messages = `[{"typeUrl":"/cosmos.bank.v1beta1.MsgSend","value":{"fromAddress":"` + f.multisig +
+		`","toAddress":"` + f.members[0] + `","amount":[{"denom":"uosmo","amount":"10"}]}}]`
+	f.fee = `{"amount":[{"denom":"uosmo","amount":"2500"}],"gas":"250000"}`
+
+	return f
+}
+
+func (f *txFixture) sign(t *testing.T, i int, sequence uint64) Signature {
+	var msgs []model.Message
+	require.NoError(t, json.Unmarshal([]byte(f.messages), &msgs))
+	var fees model.Fees
+	require.NoError(t, json.Unmarshal([]byte(f.fee), &fees))
+
+	signBytes, err := cosmos.AminoSignBytes("osmosis-1", 7, sequence, fees.StdFee(), "", f.timeoutHeight, model.Msgs(msgs))
+	require.NoError(t, err)
+
+	hash := sha256.Sum256(signBytes)
+	sig := ecdsa.SignCompact(f.keys[i], hash[:], true)
+	return Signature{Address: f.members[i], Signature: base64.StdEncoding.EncodeToString(sig[1:])}
+}
+
+// expectAssemble registers the queries made to assemble the transaction. A nil sequence
+// is a transaction created before sequences were recorded.
+func (f *txFixture) expectAssemble(t *testing.T, mock sqlmock.Sqlmock, signatures []Signature, sequence driver.Value, complete bool) {
+	bz, err := json.Marshal(signatures)
+	require.NoError(t, err)
+
+	var accountNumber driver.Value
+	if sequence != nil {
+		accountNumber = int64(7)
+	}
+
+	var timeoutHeight driver.Value
+	if f.timeoutHeight != 0 {
+		timeoutHeight = int64(f.timeoutHeight)
+	}
+
+	status := f.status
+	if status == "" {
+		status = string(model.Pending)
+	}
+
+	recipientMode := f.recipientMode
+	if recipientMode == "" {
+		recipientMode = model.RecipientModeOff
+	}
+
+	mock.ExpectQuery("SELECT t.signatures").WithArgs(1, f.multisig).WillReturnRows(
+		sqlmock.NewRows([]string{"signatures", "messages", "fee", "memo", "status", "account_number", "sequence", "expires_at", "timeout_height", "chain_id", "threshold", "recipient_mode"}).
+			AddRow(bz, []byte(f.messages), []byte(f.fee), "", status, accountNumber, sequence, f.expiresAt, timeoutHeight, "osmosis-1", 2, recipientMode))
+	if !complete {
+		return
+	}
+
+	expectPolicies(t, mock, f.multisig, nil)
+	if recipientMode != model.RecipientModeOff {
+		f.expectAddressBook(mock, f.members[0])
+	}
+	f.expectPubkeys(mock)
+	if sequence == nil {
+		mock.ExpectQuery("SELECT count").WithArgs(f.multisig, model.Pending, 1).WillReturnRows(
+			sqlmock.NewRows([]string{"count"}).AddRow(1))
+	}
+}
+
+// expectAddressBook expects the address book of the multisig to be loaded with the
+// given address.
+func (f *txFixture) expectAddressBook(mock sqlmock.Sqlmock, address string) {
+	now := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM address_book").WithArgs(f.multisig).WillReturnRows(
+		sqlmock.NewRows(addressBookRowColumns).AddRow(1, f.multisig, address, "Payroll", "osmosis-1", "", f.multisig, now, now))
+}
+
+// expectPubkeys expects the public keys of the multisig to be loaded.
+func (f *txFixture) expectPubkeys(mock sqlmock.Sqlmock) {
+	rows := sqlmock.NewRows([]string{"address", "pubkey"})
+	for i, pk := range f.pubkeys {
+		rows.AddRow(f.members[i], []byte(`{"type":"`+cosmos.Secp256k1TypeUrl+`","value":"`+base64.StdEncoding.EncodeToString(pk.Key)+`"}`))
+	}
+	mock.ExpectQuery("SELECT address, pubkey FROM pubkeys").WithArgs(f.multisig).WillReturnRows(rows)
+}
+
+func mockAccount(t *testing.T) {
+	getAccount = func(chainId, address string) (*clients.Account, error) {
+		return &clients.Account{Address: address, AccountNumber: 7, Sequence: 3}, nil
+	}
+	t.Cleanup(func() { getAccount = clients.GetAccount })
+}
+
+func TestGetTxBytes(t *testing.T) {
+	f := newTxFixture(t)
+	mockAccount(t)
+
+	testCases := []struct {
+		name       string
+		signatures []Signature
+		sequence   driver.Value
+		expStatus  int
+		expSeq     uint64
+	}{
+		{
+			"threshold met at the stored sequence",
+			[]Signature{f.sign(t, 0, 3), f.sign(t, 1, 3)},
+			int64(3),
+			http.StatusOK,
+			3,
+		},
+		{
+			"signatures for another sequence",
+			[]Signature{f.sign(t, 0, 4), f.sign(t, 1, 4)},
+			int64(3),
+			http.StatusConflict,
+			0,
+		},
+		{
+			"transactions queued before",
+			[]Signature{f.sign(t, 0, 4), f.sign(t, 1, 4)},
+			int64(4),
+			http.StatusConflict,
+			0,
+		},
+		{
+			"stale transaction",
+			[]Signature{f.sign(t, 0, 2), f.sign(t, 1, 2)},
+			int64(2),
+			http.StatusConflict,
+			0,
+		},
+		{
+			"legacy transaction signed for a queued sequence",
+			[]Signature{f.sign(t, 0, 4), f.sign(t, 1, 4)},
+			nil,
+			http.StatusOK,
+			4,
+		},
+		{
+			"legacy transaction with signatures for different sequences",
+			[]Signature{f.sign(t, 0, 3), f.sign(t, 1, 4)},
+			nil,
+			http.StatusConflict,
+			0,
+		},
+		{
+			"threshold not met",
+			[]Signature{f.sign(t, 0, 3)},
+			nil,
+			http.StatusBadRequest,
+			0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			f.expectAssemble(t, mock, tc.signatures, tc.sequence, tc.expStatus != http.StatusBadRequest)
+			if tc.sequence == int64(2) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE transactions SET status").
+					WithArgs(model.Stale, sqlmock.AnyArg(), f.multisig, model.Pending, uint64(3)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectAudit(mock, f.multisig)
+				mock.ExpectCommit()
+			}
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+			c.SetParamNames("address", "id")
+			c.SetParamValues(f.multisig, "1")
+
+			h := &Handler{DB: db}
+			require.NoError(t, h.GetTxBytes(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			if tc.expStatus == http.StatusOK {
+				var resp struct {
+					Data TxBytesResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, tc.expSeq, resp.Data.Sequence)
+				require.NotEmpty(t, resp.Data.TxBytes)
+			}
+		})
+	}
+}
+
+func TestGetTxBytesExpiry(t *testing.T) {
+	mockAccount(t)
+
+	testCases := []struct {
+		name          string
+		timeoutHeight uint64
+		expiresAt     driver.Value
+		expStatus     int
+	}{
+		{"timeout height in the sign doc", 1200, nil, http.StatusOK},
+		{"not expired yet", 0, time.Now().Add(time.Hour), http.StatusOK},
+		{"expired transaction", 0, time.Now().Add(-time.Minute), http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			f := newTxFixture(t)
+			f.timeoutHeight = tc.timeoutHeight
+			f.expiresAt = tc.expiresAt
+			f.expectAssemble(t, mock, []Signature{f.sign(t, 0, 3), f.sign(t, 1, 3)}, int64(3), tc.expStatus == http.StatusOK)
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+			c.SetParamNames("address", "id")
+			c.SetParamValues(f.multisig, "1")
+
+			h := &Handler{DB: db}
+			require.NoError(t, h.GetTxBytes(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetTxBytesStatus(t *testing.T) {
+	mockAccount(t)
+
+	for _, status := range []string{"CANCELLED", "REJECTED", "FAILED", "STALE", "BROADCASTING", "SUCCESS"} {
+		t.Run(status, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			f := newTxFixture(t)
+			f.status = status
+			f.expectAssemble(t, mock, []Signature{f.sign(t, 0, 3), f.sign(t, 1, 3)}, int64(3), false)
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+			c.SetParamNames("address", "id")
+			c.SetParamValues(f.multisig, "1")
+
+			h := &Handler{DB: db}
+			require.NoError(t, h.GetTxBytes(c))
+			require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetTxBytesRecipients(t *testing.T) {
+	mockAccount(t)
+
+	testCases := []struct {
+		name      string
+		mode      string
+		known     bool
+		expStatus int
+	}{
+		{"known recipient", model.RecipientModeEnforce, true, http.StatusOK},
+		{"recipient removed from the address book", model.RecipientModeEnforce, false, http.StatusForbidden},
+		{"unknown recipient without enforcement", model.RecipientModeWarn, false, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			f := newTxFixture(t)
+			f.recipientMode = tc.mode
+			signatures := []Signature{f.sign(t, 0, 3), f.sign(t, 1, 3)}
+			if tc.known {
+				f.expectAssemble(t, mock, signatures, int64(3), true)
+			} else {
+				f.expectAssemble(t, mock, signatures, int64(3), false)
+				expectPolicies(t, mock, f.multisig, nil)
+				f.expectAddressBook(mock, testAddress(t, "osmo", 0x0a))
+				if tc.expStatus == http.StatusOK {
+					f.expectPubkeys(mock)
+				}
+			}
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+			c.SetParamNames("address", "id")
+			c.SetParamValues(f.multisig, "1")
+
+			h := &Handler{DB: db}
+			require.NoError(t, h.GetTxBytes(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBroadcastTransaction(t *testing.T) {
+	f := newTxFixture(t)
+	mockAccount(t)
+	signatures := []Signature{f.sign(t, 0, 3), f.sign(t, 1, 3)}
+
+	testCases := []struct {
+		name       string
+		claimed    bool
+		resp       *txn_types.TxResponse
+		expStatus  int
+		expUpdate  []driver.Value
+		expRelease bool
+	}{
+		{
+			"broadcasted",
+			true,
+			&txn_types.TxResponse{Txhash: "ABCD"},
+			http.StatusOK,
+			[]driver.Value{"ABCD", sqlmock.AnyArg(), 1, f.multisig},
+			false,
+		},
+		{
+			"rejected by check tx",
+			true,
+			&txn_types.TxResponse{Txhash: "ABCD", Code: 13, RawLog: "insufficient fee"},
+			http.StatusBadRequest,
+			[]driver.Value{model.Failed, "ABCD", "insufficient fee", sqlmock.AnyArg(), 1, f.multisig},
+			true,
+		},
+		{
+			"already broadcasted",
+			false,
+			nil,
+			http.StatusConflict,
+			nil,
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			f.expectAssemble(t, mock, signatures, int64(3), true)
+			claimed := int64(0)
+			if tc.claimed {
+				claimed = 1
+			}
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE transactions SET status").
+				WithArgs(model.Broadcasting, sqlmock.AnyArg(), 1, f.multisig, model.Pending).
+				WillReturnResult(sqlmock.NewResult(0, claimed))
+			if tc.claimed {
+				expectAudit(mock, f.multisig)
+			}
+			mock.ExpectCommit()
+			if tc.expUpdate != nil {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE transactions SET").WithArgs(tc.expUpdate...).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, f.multisig)
+				if tc.expRelease {
+					expectRelease(mock, f.multisig, 3)
+				}
+				mock.ExpectCommit()
+			}
+
+			broadcastTx = func(chainId string, txBytes []byte) (*txn_types.TxResponse, error) {
+				require.Equal(t, "osmosis-1", chainId)
+				return tc.resp, nil
+			}
+			defer func() { broadcastTx = clients.BroadcastTx }()
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+			c.SetParamNames("address", "id")
+			c.SetParamValues(f.multisig, "1")
+
+			h := &Handler{DB: db}
+			require.NoError(t, h.BroadcastTransaction(c))
+			require.Equal(t, tc.expStatus, rec.Code, rec.Body.String())
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/clients"
 	"github.com/vitwit/resolute/server/model"
 	"github.com/vitwit/resolute/server/schema"
+	"github.com/vitwit/resolute/server/utils"
 )
 
 func (h *Handler) GetUser(c echo.Context) error {
@@ -44,6 +46,13 @@ func (h *Handler) CreateUserSignature(c echo.Context) error {
 		})
 	}
 
+	if req.Address != address {
+		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Status:  "error",
+			Message: "address does not match the signer",
+		})
+	}
+
 	if err := req.Validate(); err != nil {
 		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Status:  "error",
@@ -52,6 +61,22 @@ func (h *Handler) CreateUserSignature(c echo.Context) error {
 		})
 	}
 
+	ok, err := consumeChallenge(req.Address, req.Message)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to verify challenge",
+			Log:     err.Error(),
+		})
+	}
+
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Status:  "error",
+			Message: "challenge expired or does not match, request a new challenge",
+		})
+	}
+
 	pubKeyBytes, err := json.Marshal(req.PubKey)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, model.ErrorResponse{
@@ -98,8 +123,18 @@ func (h *Handler) CreateUserSignature(c echo.Context) error {
 		})
 	}
 
+	session, err := clients.CreateSession(req.Address, utils.SESSION_EXPIRY, utils.REFRESH_EXPIRY)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Status:  "error",
+			Message: "failed to create session",
+			Log:     err.Error(),
+		})
+	}
+
 	return c.JSON(http.StatusOK, model.SuccessResponse{
 		Status:  "success",
 		Message: "signature created",
+		Data:    session,
 	})
 }
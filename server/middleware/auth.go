@@ -2,39 +2,48 @@ package middleware
 
 import (
 	"database/sql"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/vitwit/resolute/server/clients"
 	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/utils"
 )
 
 func (h *Handler) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		signature := c.QueryParams().Get("signature")
-		address := c.QueryParams().Get("cosmos_address")
+		token := utils.GetBearerToken(c)
+		if token == "" {
+			return c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+				Status:  "Unauthorized",
+				Message: "access token is required",
+			})
+		}
 
-		if address == "" {
-			return c.JSON(http.StatusNotAcceptable, model.ErrorResponse{
+		session, err := clients.GetSession(token)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 				Status:  "error",
-				Message: "address is required",
+				Message: "failed to verify access token",
+				Log:     err.Error(),
 			})
 		}
 
-		if signature == "" {
-			return c.JSON(http.StatusNotAcceptable, model.ErrorResponse{
-				Status:  "error",
-				Message: "signature is required",
+		if session == nil {
+			return c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+				Status:  "Unauthorized",
+				Message: "access token is invalid or expired",
 			})
 		}
 
 		var userAddress string
 
-		saniSignature := strings.Replace(signature, " ", "+", -1)
-
-		err := h.DB.QueryRow(`SELECT address FROM users where address=$1 and signature=$2`, address, saniSignature).Scan(&userAddress)
+		err = h.DB.QueryRow(`SELECT address FROM users where address=$1`, session.Address).Scan(&userAddress)
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			return c.JSON(http.StatusUnauthorized, model.ErrorResponse{
 				Status:  "Unauthorized",
 				Message: "Unauthorized access",
 			})
@@ -46,23 +55,65 @@ func (h *Handler) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			})
 		}
 
+		c.Set(utils.AUTH_ADDRESS_KEY, userAddress)
+
 		return next(c)
 	}
 }
 
-func (h *Handler) IsMultisigAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+// TokenFromQuery accepts the access token from the access_token query parameter or
+// cookie, for clients such as EventSource that cannot set the Authorization header.
+// The token is moved to the header and removed from the URL of the request, so that
+// it does not show up in the access logs.
+func (h *Handler) TokenFromQuery(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		address := c.QueryParams().Get("address")
-		multisigAddress := c.Param("address")
+		req := c.Request()
+
+		query := req.URL.Query()
+		token := query.Get(utils.ACCESS_TOKEN_PARAM)
+		if query.Has(utils.ACCESS_TOKEN_PARAM) {
+			// the request is updated in place, as the logger holds on to it
+			query.Del(utils.ACCESS_TOKEN_PARAM)
+			req.URL.RawQuery = query.Encode()
+			req.RequestURI = req.URL.RequestURI()
+		}
 
-		var userAddress string
+		if token == "" {
+			if cookie, err := req.Cookie(utils.ACCESS_TOKEN_PARAM); err == nil {
+				token = cookie.Value
+			}
+		}
+
+		if token != "" && utils.GetBearerToken(c) == "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+
+		return next(c)
+	}
+}
+
+// OptionalAuthMiddleware authenticates the request when an access token is sent, and
+// lets anonymous requests through without an authenticated address.
+func (h *Handler) OptionalAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if utils.GetBearerToken(c) == "" {
+			return next(c)
+		}
+
+		return h.AuthMiddleware(next)(c)
+	}
+}
 
-		err := h.DB.QueryRow(`SELECT address FROM multisig_accounts where created_by=$1 and address=$2`, address, multisigAddress).Scan(&userAddress)
+// CanReadMultisig allows anyone to read public multisig accounts, while private ones
+// require an authenticated user holding any role on the account.
+func (h *Handler) CanReadMultisig(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var visibility string
+
+		err := h.DB.QueryRow(`SELECT visibility FROM multisig_accounts where address=$1`, c.Param("address")).Scan(&visibility)
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
-				Status:  "Unauthorized",
-				Message: err.Error(),
-			})
+			// let the handler report the missing account
+			return next(c)
 		} else if err != nil {
 			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
 				Status:  "error",
@@ -71,23 +122,71 @@ func (h *Handler) IsMultisigAdmin(next echo.HandlerFunc) echo.HandlerFunc {
 			})
 		}
 
-		return next(c)
+		if visibility == model.VisibilityPublic {
+			return next(c)
+		}
+
+		return h.AuthMiddleware(h.HasMultisigRole(model.Roles...)(next))(c)
 	}
 }
 
-func (h *Handler) IsMultisigMember(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		address := c.QueryParams().Get("address")
-		multisigAddress := c.Param("address")
+// HasMultisigRole allows the request only if the authenticated user holds one of the
+// given roles on the multisig account. Admins are always allowed.
+func (h *Handler) HasMultisigRole(roles ...string) echo.MiddlewareFunc {
+	allowed := append([]string{model.RoleAdmin}, roles...)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			multisigAddress := c.Param("address")
+
+			address, err := utils.GetAuthMemberAddress(c, multisigAddress)
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+					Status:  "Unauthorized",
+					Message: "Unauthorized access",
+					Log:     err.Error(),
+				})
+			}
+
+			var count int
+
+			err = h.DB.QueryRow(`SELECT count(*) FROM multisig_roles where multisig_address=$1 and address=$2 and role=ANY($3)`,
+				multisigAddress, address, pq.Array(allowed)).Scan(&count)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, model.ErrorResponse{
+					Status:  "error",
+					Message: "failed to decode",
+					Log:     err.Error(),
+				})
+			}
+
+			if count == 0 {
+				return c.JSON(http.StatusForbidden, model.ErrorResponse{
+					Status:  "Unauthorized",
+					Message: fmt.Sprintf("You need one of the roles [%s] on the multisig", strings.Join(allowed, ", ")),
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
 
-		var userAddress string
+// IsMultisigAdmin allows only the admins of the multisig account.
+func (h *Handler) IsMultisigAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return h.HasMultisigRole()(next)
+}
 
-		err := h.DB.QueryRow(`SELECT address FROM pubkeys where address=$1 and multisig_address=$2`, address, multisigAddress).Scan(&userAddress)
+// IsActiveMultisig rejects changes to archived multisig accounts, which are read-only
+// until they are restored.
+func (h *Handler) IsActiveMultisig(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var deletedAt sql.NullTime
+
+		err := h.DB.QueryRow(`SELECT deleted_at FROM multisig_accounts where address=$1`, c.Param("address")).Scan(&deletedAt)
 		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
-				Status:  "Unauthorized",
-				Message: "You are not a member of the multisig",
-			})
+			// let the handler report the missing account
+			return next(c)
 		} else if err != nil {
 			return c.JSON(http.StatusBadRequest, model.ErrorResponse{
 				Status:  "error",
@@ -96,6 +195,13 @@ func (h *Handler) IsMultisigMember(next echo.HandlerFunc) echo.HandlerFunc {
 			})
 		}
 
+		if deletedAt.Valid {
+			return c.JSON(http.StatusGone, model.ErrorResponse{
+				Status:  "error",
+				Message: "multisig account is archived, restore it first",
+			})
+		}
+
 		return next(c)
 	}
 }
@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/model"
+	"github.com/vitwit/resolute/server/utils"
+)
+
+func testAddress(t *testing.T, prefix string, b byte) string {
+	address, err := cosmos.EncodeAddress(prefix, bytes.Repeat([]byte{b}, 20))
+	require.NoError(t, err)
+
+	return address
+}
+
+func TestMultisigAuthorization(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	admin := testAddress(t, "osmo", 0x01)
+	attacker := testAddress(t, "osmo", 0x02)
+
+	roleQuery := `SELECT count\(\*\) FROM multisig_roles where multisig_address=\$1 and address=\$2 and role=ANY\(\$3\)`
+	countRows := func(n int) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"count"}).AddRow(n)
+	}
+
+	testCases := []struct {
+		name       string
+		authAddr   string
+		query      string
+		middleware func(h *Handler) echo.MiddlewareFunc
+		setup      func(mock sqlmock.Sqlmock)
+		expStatus  int
+	}{
+		{
+			"admin: unauthenticated request",
+			"",
+			"?address=" + admin,
+			func(h *Handler) echo.MiddlewareFunc { return h.IsMultisigAdmin },
+			func(mock sqlmock.Sqlmock) {},
+			http.StatusUnauthorized,
+		},
+		{
+			"admin: other user passing the admin address",
+			testAddress(t, "cosmos", 0x02),
+			"?address=" + admin,
+			func(h *Handler) echo.MiddlewareFunc { return h.IsMultisigAdmin },
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(roleQuery).WithArgs(multisig, attacker, pq.Array([]string{model.RoleAdmin})).
+					WillReturnRows(countRows(0))
+			},
+			http.StatusForbidden,
+		},
+		{
+			"admin: authenticated admin",
+			testAddress(t, "cosmos", 0x01),
+			"",
+			func(h *Handler) echo.MiddlewareFunc { return h.IsMultisigAdmin },
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(roleQuery).WithArgs(multisig, admin, pq.Array([]string{model.RoleAdmin})).
+					WillReturnRows(countRows(1))
+			},
+			http.StatusOK,
+		},
+		{
+			"proposer: unauthenticated request",
+			"",
+			"?address=" + admin,
+			func(h *Handler) echo.MiddlewareFunc { return h.HasMultisigRole(model.RoleProposer) },
+			func(mock sqlmock.Sqlmock) {},
+			http.StatusUnauthorized,
+		},
+		{
+			"proposer: other user passing a member address",
+			testAddress(t, "cosmos", 0x02),
+			"?address=" + admin,
+			func(h *Handler) echo.MiddlewareFunc { return h.HasMultisigRole(model.RoleProposer) },
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(roleQuery).WithArgs(multisig, attacker, pq.Array([]string{model.RoleAdmin, model.RoleProposer})).
+					WillReturnRows(countRows(0))
+			},
+			http.StatusForbidden,
+		},
+		{
+			"proposer: authenticated proposer",
+			testAddress(t, "cosmos", 0x01),
+			"",
+			func(h *Handler) echo.MiddlewareFunc { return h.HasMultisigRole(model.RoleProposer) },
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(roleQuery).WithArgs(multisig, admin, pq.Array([]string{model.RoleAdmin, model.RoleProposer})).
+					WillReturnRows(countRows(1))
+			},
+			http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tc.setup(mock)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/"+tc.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("address")
+			c.SetParamValues(multisig)
+			if tc.authAddr != "" {
+				c.Set(utils.AUTH_ADDRESS_KEY, tc.authAddr)
+			}
+
+			h := &Handler{DB: db}
+			err = tc.middleware(h)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+			require.NoError(t, err)
+			require.Equal(t, tc.expStatus, rec.Code)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCanReadMultisig(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	visibilityQuery := `SELECT visibility FROM multisig_accounts where address=\$1`
+
+	testCases := []struct {
+		name       string
+		visibility string
+		expStatus  int
+	}{
+		{"public account without a token", model.VisibilityPublic, http.StatusOK},
+		{"private account without a token", model.VisibilityPrivate, http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectQuery(visibilityQuery).WithArgs(multisig).
+				WillReturnRows(sqlmock.NewRows([]string{"visibility"}).AddRow(tc.visibility))
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+			c.SetParamNames("address")
+			c.SetParamValues(multisig)
+
+			h := &Handler{DB: db}
+			err = h.CanReadMultisig(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+			require.NoError(t, err)
+			require.Equal(t, tc.expStatus, rec.Code)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIsActiveMultisig(t *testing.T) {
+	multisig := testAddress(t, "osmo", 0x0f)
+	deletedAtQuery := `SELECT deleted_at FROM multisig_accounts where address=\$1`
+
+	testCases := []struct {
+		name      string
+		rows      *sqlmock.Rows
+		expStatus int
+	}{
+		{"active account", sqlmock.NewRows([]string{"deleted_at"}).AddRow(nil), http.StatusOK},
+		{"archived account", sqlmock.NewRows([]string{"deleted_at"}).AddRow(time.Now()), http.StatusGone},
+		{"missing account", sqlmock.NewRows([]string{"deleted_at"}), http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectQuery(deletedAtQuery).WithArgs(multisig).WillReturnRows(tc.rows)
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+			c.SetParamNames("address")
+			c.SetParamValues(multisig)
+
+			h := &Handler{DB: db}
+			err = h.IsActiveMultisig(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+			require.NoError(t, err)
+			require.Equal(t, tc.expStatus, rec.Code)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTokenFromQuery(t *testing.T) {
+	testCases := []struct {
+		name      string
+		target    string
+		cookie    string
+		header    string
+		expAuth   string
+		expTarget string
+	}{
+		{"query parameter", "/multisig/osmo1/events?access_token=abcd&since=1", "", "", "Bearer abcd", "/multisig/osmo1/events?since=1"},
+		{"cookie", "/multisig/osmo1/events", "abcd", "", "Bearer abcd", "/multisig/osmo1/events"},
+		{"authorization header first", "/multisig/osmo1/events?access_token=abcd", "", "Bearer efgh", "Bearer efgh", "/multisig/osmo1/events"},
+		{"no token", "/multisig/osmo1/events", "", "", "", "/multisig/osmo1/events"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: utils.ACCESS_TOKEN_PARAM, Value: tc.cookie})
+			}
+			if tc.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tc.header)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			h := &Handler{}
+			err := h.TokenFromQuery(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+			require.NoError(t, err)
+			require.Equal(t, tc.expAuth, req.Header.Get(echo.HeaderAuthorization))
+			require.Equal(t, tc.expTarget, req.RequestURI)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vitwit/resolute/server/cosmos"
+)
+
+// Recipient modes decide what happens to transactions sending to addresses which are
+// not in the address book of the multisig account.
+const (
+	RecipientModeOff     = "off"
+	RecipientModeWarn    = "warn"
+	RecipientModeEnforce = "enforce"
+)
+
+const (
+	// MaxLabelLength is the maximum length of an address book label
+	MaxLabelLength = 100
+	// MaxNotesLength is the maximum length of the notes of an address book entry
+	MaxNotesLength = 1000
+)
+
+type AddressBookEntryReq struct {
+	Address string `json:"address"`
+	Label   string `json:"label"`
+	// ChainID defaults to the chain of the multisig account
+	ChainID string `json:"chain_id"`
+	Notes   string `json:"notes"`
+}
+
+func (r AddressBookEntryReq) Validate() error {
+	if len(strings.TrimSpace(r.Label)) == 0 {
+		return errors.New("label cannot be empty")
+	}
+
+	if len(r.Label) > MaxLabelLength {
+		return fmt.Errorf("label cannot be longer than %d characters", MaxLabelLength)
+	}
+
+	if len(r.Notes) > MaxNotesLength {
+		return fmt.Errorf("notes cannot be longer than %d characters", MaxNotesLength)
+	}
+
+	if _, _, err := cosmos.DecodeAddress(r.Address); err != nil {
+		return fmt.Errorf("invalid address %s", r.Address)
+	}
+
+	return nil
+}
+
+type UpdateRecipientModeReq struct {
+	Mode string `json:"mode"`
+}
+
+func (r UpdateRecipientModeReq) Validate() error {
+	switch r.Mode {
+	case RecipientModeOff, RecipientModeWarn, RecipientModeEnforce:
+		return nil
+	default:
+		return fmt.Errorf("mode must be one of %s, %s or %s", RecipientModeOff, RecipientModeWarn, RecipientModeEnforce)
+	}
+}
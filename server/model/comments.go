@@ -0,0 +1,43 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// MaxCommentLength is the maximum length of a comment
+	MaxCommentLength = 2000
+	// MaxCommentAttachments is the maximum number of links attached to a comment
+	MaxCommentAttachments = 5
+)
+
+type CommentReq struct {
+	Body        string   `json:"body"`
+	Attachments []string `json:"attachments"`
+}
+
+func (r CommentReq) Validate() error {
+	if len(strings.TrimSpace(r.Body)) == 0 {
+		return errors.New("comment cannot be empty")
+	}
+
+	if len(r.Body) > MaxCommentLength {
+		return fmt.Errorf("comment cannot be longer than %d characters", MaxCommentLength)
+	}
+
+	if len(r.Attachments) > MaxCommentAttachments {
+		return fmt.Errorf("a comment can have at most %d attachments", MaxCommentAttachments)
+	}
+
+	for _, link := range r.Attachments {
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid attachment link %s", link)
+		}
+	}
+
+	return nil
+}
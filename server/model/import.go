@@ -0,0 +1,155 @@
+package model
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// MaxImportRows is the maximum number of payments in an imported CSV file
+	MaxImportRows = 1000
+	// MaxImportFileSize is the maximum size of an imported CSV file, in bytes
+	MaxImportFileSize = 1 << 20
+	// DefaultImportMaxMessages is the number of messages per imported transaction when
+	// no limit is given
+	DefaultImportMaxMessages = 100
+	// MaxImportMessages is the maximum number of messages per imported transaction
+	MaxImportMessages = 500
+	// DefaultImportGasPerMessage is the gas limit of each message of an imported
+	// transaction when none is given
+	DefaultImportGasPerMessage = 100000
+)
+
+// ImportTxReq holds the options of a CSV import, sent as form fields along with the file.
+type ImportTxReq struct {
+	// Title is the title of the transactions, numbered when the import is split
+	Title string `form:"title"`
+	// MaxMessages is the maximum number of messages per transaction
+	MaxMessages int `form:"max_messages"`
+	// GasPerMessage is the gas limit of each message, the gas limit of a transaction
+	// is the sum of the gas of its messages
+	GasPerMessage uint64 `form:"gas_per_message"`
+	// MaxGas is the maximum gas limit of a transaction, no limit when 0
+	MaxGas uint64 `form:"max_gas"`
+	// GasPrice is the gas price the fees are paid with, such as 0.025uatom. It defaults
+	// to the gas price of the chain.
+	GasPrice string `form:"gas_price"`
+}
+
+func (r *ImportTxReq) Validate() error {
+	if r.MaxMessages == 0 {
+		r.MaxMessages = DefaultImportMaxMessages
+	}
+	if r.GasPerMessage == 0 {
+		r.GasPerMessage = DefaultImportGasPerMessage
+	}
+
+	if r.MaxMessages < 0 || r.MaxMessages > MaxImportMessages {
+		return fmt.Errorf("max_messages must be between 1 and %d", MaxImportMessages)
+	}
+
+	if r.MaxGas != 0 && r.MaxGas < r.GasPerMessage {
+		return errors.New("max_gas cannot be lower than gas_per_message")
+	}
+
+	return nil
+}
+
+// MessagesPerTx returns the number of messages in each transaction of the import,
+// within both the message and gas budgets.
+func (r ImportTxReq) MessagesPerTx() int {
+	n := r.MaxMessages
+	if r.MaxGas != 0 && r.MaxGas/r.GasPerMessage < uint64(n) {
+		n = int(r.MaxGas / r.GasPerMessage)
+	}
+
+	return n
+}
+
+// ImportRow is a payment of an imported CSV file.
+type ImportRow struct {
+	// Row is the line number of the payment in the file
+	Row     int
+	Address string
+	Amount  string
+	Denom   string
+	Memo    string
+}
+
+// ImportRowError is the reason a row of an imported CSV file is rejected.
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportErrorResponse reports the rows rejected by an import, or the transactions
+// created before a transaction of the import failed.
+type ImportErrorResponse struct {
+	ErrorResponse
+	Errors  []ImportRowError `json:"errors,omitempty"`
+	Created []int            `json:"created,omitempty"`
+}
+
+// ParseImportCSV reads the payments of a CSV file with the columns address, amount,
+// denom and an optional memo. A first line naming the columns is skipped. Malformed
+// rows are reported rather than failing the whole file.
+func ParseImportCSV(r io.Reader) ([]ImportRow, []ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var (
+		rows []ImportRow
+		errs []ImportRowError
+	)
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid csv: %w", err)
+		}
+
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), "address") {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		if len(rows)+len(errs) == MaxImportRows {
+			return nil, nil, fmt.Errorf("csv cannot have more than %d rows", MaxImportRows)
+		}
+
+		if len(record) < 3 || len(record) > 4 {
+			errs = append(errs, ImportRowError{
+				Row:   line,
+				Error: fmt.Sprintf("expected the columns address, amount, denom and memo, got %d columns", len(record)),
+			})
+			continue
+		}
+
+		row := ImportRow{
+			Row:     line,
+			Address: strings.TrimSpace(record[0]),
+			Amount:  strings.TrimSpace(record[1]),
+			Denom:   strings.TrimSpace(record[2]),
+		}
+		if len(record) == 4 {
+			row.Memo = strings.TrimSpace(record[3])
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows)+len(errs) == 0 {
+		return nil, nil, errors.New("csv has no rows")
+	}
+
+	return rows, errs, nil
+}
@@ -2,17 +2,33 @@ package model
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/vitwit/resolute/server/cosmos"
 	"github.com/vitwit/resolute/server/schema"
 )
 
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+func validateVisibility(visibility string) error {
+	if visibility != VisibilityPublic && visibility != VisibilityPrivate {
+		return fmt.Errorf("visibility must be either %s or %s", VisibilityPublic, VisibilityPrivate)
+	}
+
+	return nil
+}
+
 type CreateAccountReq struct {
-	Address   string       `json:"address"`
-	Name      string       `json:"name"`
-	Threshold int32        `json:"threshold"`
-	Pubkeys   []PubkeysReq `json:"pubkeys"`
-	ChainId   string       `json:"chainId"`
-	CreatedBy string       `json:"createdBy"`
+	Address    string       `json:"address"`
+	Name       string       `json:"name"`
+	Threshold  int32        `json:"threshold"`
+	Pubkeys    []PubkeysReq `json:"pubkeys"`
+	ChainId    string       `json:"chainId"`
+	CreatedBy  string       `json:"createdBy"`
+	Visibility string       `json:"visibility"`
 }
 
 type PubkeysReq struct {
@@ -41,6 +57,16 @@ func (a CreateAccountReq) Validate() error {
 		return errors.New("more than one pubkey is required")
 	}
 
+	if int(a.Threshold) > len(a.Pubkeys) {
+		return errors.New("threshold cannot be greater than the number of pubkeys")
+	}
+
+	if len(a.Visibility) != 0 {
+		if err := validateVisibility(a.Visibility); err != nil {
+			return err
+		}
+	}
+
 	for _, pk := range a.Pubkeys {
 		if err := pk.Validate(); err != nil {
 			return err
@@ -50,6 +76,55 @@ func (a CreateAccountReq) Validate() error {
 	return nil
 }
 
+// VerifyAddress checks that every member address derives from its pubkey and that the
+// multisig address is the one of the threshold pubkey built from the members, encoded
+// with the chain's bech32 prefix.
+func (a CreateAccountReq) VerifyAddress(prefix string) error {
+	pubkeys := make([]cosmos.PubKey, 0, len(a.Pubkeys))
+	seen := make(map[string]bool)
+	for _, pk := range a.Pubkeys {
+		pubkey, err := pk.Pubkey.Parse()
+		if err != nil {
+			return err
+		}
+
+		memberPrefix, _, err := cosmos.DecodeAddress(pk.Address)
+		if err != nil {
+			return err
+		}
+
+		if memberPrefix != prefix {
+			return fmt.Errorf("address %s does not use the chain prefix %s", pk.Address, prefix)
+		}
+
+		if err := cosmos.VerifyAddress(pubkey, pk.Address); err != nil {
+			return err
+		}
+
+		if seen[pk.Address] {
+			return fmt.Errorf("duplicate pubkey for address %s", pk.Address)
+		}
+		seen[pk.Address] = true
+
+		pubkeys = append(pubkeys, pubkey)
+	}
+
+	// cosmjs and the sdk cli sort the keys by address unless asked not to
+	candidates := [][]cosmos.PubKey{pubkeys, cosmos.SortPubKeys(pubkeys)}
+	for _, keys := range candidates {
+		address, err := cosmos.EncodeAddress(prefix, cosmos.NewMultisigPubKey(uint32(a.Threshold), keys).Address())
+		if err != nil {
+			return err
+		}
+
+		if address == a.Address {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("address %s does not match the pubkeys and threshold", a.Address)
+}
+
 func (p PubkeysReq) Validate() error {
 	if len(p.Address) == 0 {
 		return errors.New("address cannot be empty")
@@ -75,6 +150,19 @@ func (p Pubkey) Validate() error {
 	return nil
 }
 
+type UpdateVisibilityReq struct {
+	Visibility string `json:"visibility"`
+}
+
+func (v UpdateVisibilityReq) Validate() error {
+	return validateVisibility(v.Visibility)
+}
+
+// Parse decodes the pubkey into its secp256k1 key.
+func (p Pubkey) Parse() (cosmos.PubKey, error) {
+	return cosmos.ParsePubKey(p.TypeUrl, p.Value)
+}
+
 type GetAccountsResponse struct {
 	Address   string
 	Name      string
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitwit/resolute/server/policy"
+)
+
+// Policy change actions
+const (
+	PolicyAdd    = "add"
+	PolicyRemove = "remove"
+)
+
+// Policy change statuses
+const (
+	PolicyChangePending  = "PENDING"
+	PolicyChangeApproved = "APPROVED"
+	PolicyChangeRejected = "REJECTED"
+)
+
+// PolicyChangeReq proposes to add a policy, or to remove the policy PolicyID.
+type PolicyChangeReq struct {
+	Action   string        `json:"action"`
+	PolicyID int           `json:"policy_id"`
+	Type     string        `json:"type"`
+	Params   policy.Params `json:"params"`
+}
+
+func (r PolicyChangeReq) Validate() error {
+	switch r.Action {
+	case PolicyAdd:
+		return policy.Validate(r.Type, r.Params)
+	case PolicyRemove:
+		if r.PolicyID <= 0 {
+			return errors.New("policy_id is required")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid action %s", r.Action)
+	}
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	RoleAdmin    = "admin"
+	RoleProposer = "proposer"
+	RoleSigner   = "signer"
+	RoleViewer   = "viewer"
+)
+
+// Roles lists every role which can be granted on a multisig account
+var Roles = []string{RoleAdmin, RoleProposer, RoleSigner, RoleViewer}
+
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+type GrantRoleReq struct {
+	Address string `json:"address"`
+	Role    string `json:"role"`
+}
+
+func (r GrantRoleReq) Validate() error {
+	if len(r.Address) == 0 {
+		return errors.New("address cannot be empty")
+	}
+
+	if !IsValidRole(r.Role) {
+		return fmt.Errorf("invalid role %s", r.Role)
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+const (
+	// MaxTemplateNameLength is the maximum length of the name of a transaction template
+	MaxTemplateNameLength = 100
+	// MaxScheduleSpecLength is the maximum length of the cron spec of a schedule
+	MaxScheduleSpecLength = 100
+)
+
+var (
+	// placeholderRe matches the {{name}} placeholders of template parameters
+	placeholderRe = regexp.MustCompile(`{{\s*([a-z_][a-z0-9_]*)\s*}}`)
+	paramNameRe   = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+)
+
+// TemplateParam is a parameter of a transaction template, filled in wherever its
+// {{name}} placeholder appears in a string of the messages, memo or title.
+type TemplateParam struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Default is used when no value is given, the parameter is required when nil
+	Default *string `json:"default,omitempty"`
+}
+
+// TxTemplateReq creates or replaces a transaction template.
+type TxTemplateReq struct {
+	Name     string          `json:"name"`
+	Title    string          `json:"title"`
+	Messages []Message       `json:"messages"`
+	Fee      Fees            `json:"fee"`
+	Memo     string          `json:"memo"`
+	Params   []TemplateParam `json:"params"`
+}
+
+func (r TxTemplateReq) Validate() error {
+	if len(strings.TrimSpace(r.Name)) == 0 {
+		return errors.New("name cannot be empty")
+	}
+
+	if len(r.Name) > MaxTemplateNameLength {
+		return fmt.Errorf("name cannot be longer than %d characters", MaxTemplateNameLength)
+	}
+
+	if len(r.Messages) == 0 {
+		return errors.New("atleast one messages is required")
+	}
+
+	if err := r.Fee.Validate(); err != nil {
+		return err
+	}
+
+	declared := make(map[string]bool)
+	for _, p := range r.Params {
+		if !paramNameRe.MatchString(p.Name) {
+			return fmt.Errorf("invalid parameter name %q", p.Name)
+		}
+		if declared[p.Name] {
+			return fmt.Errorf("duplicate parameter %s", p.Name)
+		}
+		declared[p.Name] = true
+	}
+
+	var used []string
+	for _, m := range r.Messages {
+		used = append(used, placeholders(m.Value)...)
+	}
+	used = append(used, placeholders(r.Memo)...)
+	used = append(used, placeholders(r.Title)...)
+	for _, name := range used {
+		if !declared[name] {
+			return fmt.Errorf("parameter %s is used but not declared", name)
+		}
+	}
+
+	return nil
+}
+
+// placeholders returns the parameter names used in the strings of a JSON value.
+func placeholders(v interface{}) []string {
+	var names []string
+	switch v := v.(type) {
+	case string:
+		for _, m := range placeholderRe.FindAllStringSubmatch(v, -1) {
+			names = append(names, m[1])
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			names = append(names, placeholders(item)...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			names = append(names, placeholders(item)...)
+		}
+	}
+
+	return names
+}
+
+// RenderTemplate fills in the parameters of the messages, memo and title of a template
+// with the given values, or their defaults.
+func RenderTemplate(messages []Message, memo, title string, params []TemplateParam, values map[string]string) ([]Message, string, string, error) {
+	resolved := make(map[string]string, len(params))
+	for _, p := range params {
+		if v, ok := values[p.Name]; ok {
+			resolved[p.Name] = v
+		} else if p.Default != nil {
+			resolved[p.Name] = *p.Default
+		} else {
+			return nil, "", "", fmt.Errorf("missing value of parameter %s", p.Name)
+		}
+	}
+
+	for name := range values {
+		if _, ok := resolved[name]; !ok {
+			return nil, "", "", fmt.Errorf("unknown parameter %s", name)
+		}
+	}
+
+	rendered := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		value, _ := render(m.Value, resolved).(map[string]interface{})
+		rendered = append(rendered, Message{TypeUrl: m.TypeUrl, Value: value})
+	}
+
+	return rendered, render(memo, resolved).(string), render(title, resolved).(string), nil
+}
+
+// render returns a copy of the JSON value with the placeholders of its strings replaced.
+func render(v interface{}, values map[string]string) interface{} {
+	switch v := v.(type) {
+	case string:
+		return placeholderRe.ReplaceAllStringFunc(v, func(s string) string {
+			return values[placeholderRe.FindStringSubmatch(s)[1]]
+		})
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = render(item, values)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = render(item, values)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// ProposeTemplateReq creates a pending transaction from a template.
+type ProposeTemplateReq struct {
+	Params map[string]string `json:"params"`
+	// ExpiresAt is the time after which the transaction can no longer be signed or broadcast
+	ExpiresAt *time.Time `json:"expires_at"`
+	// TimeoutHeight is the block height after which the chain rejects the transaction
+	TimeoutHeight uint64 `json:"timeout_height,string,omitempty"`
+}
+
+// ParseSchedule parses a standard 5 field cron spec, or a descriptor such as @monthly.
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+
+	return schedule, nil
+}
+
+// TxScheduleReq creates a schedule proposing transactions from a template.
+type TxScheduleReq struct {
+	TemplateID int               `json:"template_id"`
+	Spec       string            `json:"spec"`
+	Params     map[string]string `json:"params"`
+	// ExpiresIn is how long the proposed transactions can be signed, in seconds. They
+	// do not expire when it is 0.
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+func (r TxScheduleReq) Validate() error {
+	if r.TemplateID <= 0 {
+		return errors.New("template_id is required")
+	}
+
+	if r.ExpiresIn < 0 {
+		return errors.New("expires_in cannot be negative")
+	}
+
+	if len(r.Spec) > MaxScheduleSpecLength {
+		return fmt.Errorf("spec cannot be longer than %d characters", MaxScheduleSpecLength)
+	}
+
+	_, err := ParseSchedule(r.Spec)
+	return err
+}
+
+// UpdateTxScheduleReq pauses or resumes a schedule.
+type UpdateTxScheduleReq struct {
+	Active bool `json:"active"`
+}
@@ -2,15 +2,24 @@ package model
 
 import (
 	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vitwit/resolute/server/cosmos"
 )
 
 type STATUS string
 
 const (
-	Pending STATUS = "PENDING"
-	Success        = "SUCCESS"
-	Failed         = "FAILED"
-	History        = "history"
+	Pending      STATUS = "PENDING"
+	Broadcasting        = "BROADCASTING"
+	Stale               = "STALE"
+	Success             = "SUCCESS"
+	Failed              = "FAILED"
+	Rejected            = "REJECTED"
+	Cancelled           = "CANCELLED"
+	Expired             = "EXPIRED"
+	History             = "history"
 )
 
 type Fees struct {
@@ -31,6 +40,19 @@ func (f Fees) Validate() error {
 	return nil
 }
 
+// StdFee returns the amino JSON form of the fee.
+func (f Fees) StdFee() cosmos.StdFee {
+	fee := cosmos.StdFee{
+		Amount: make([]cosmos.Coin, 0, len(f.Amount)),
+		Gas:    f.Gas,
+	}
+	for _, a := range f.Amount {
+		fee.Amount = append(fee.Amount, cosmos.Coin{Amount: a.Amount, Denom: a.Denom})
+	}
+
+	return fee
+}
+
 type Fee struct {
 	Denom  string `json:"denom"`
 	Amount string `json:"amount"`
@@ -53,12 +75,31 @@ type Message struct {
 	Value   map[string]interface{} `json:"value"`
 }
 
+// Msg returns the message in the form used to build sign docs.
+func (m Message) Msg() cosmos.Msg {
+	return cosmos.Msg{TypeUrl: m.TypeUrl, Value: m.Value}
+}
+
+// Msgs converts a list of messages.
+func Msgs(messages []Message) []cosmos.Msg {
+	msgs := make([]cosmos.Msg, 0, len(messages))
+	for _, m := range messages {
+		msgs = append(msgs, m.Msg())
+	}
+
+	return msgs
+}
+
 type CreateTransactionRequest struct {
 	Fee      Fees      `json:"fee"`
 	Title    string    `json:"title"`
 	Messages []Message `json:"messages"`
 	ChainId  string    `json:"chain_id"`
 	Memo     string    `json:"memo"`
+	// ExpiresAt is the time after which the transaction can no longer be signed or broadcast
+	ExpiresAt *time.Time `json:"expires_at"`
+	// TimeoutHeight is the block height after which the chain rejects the transaction
+	TimeoutHeight uint64 `json:"timeout_height,string,omitempty"`
 }
 
 func (m CreateTransactionRequest) Validate() error {
@@ -73,5 +114,67 @@ func (m CreateTransactionRequest) Validate() error {
 	if len(m.ChainId) == 0 {
 		return errors.New("chain-id cannot be empty")
 	}
+
+	if m.ExpiresAt != nil && !m.ExpiresAt.After(time.Now()) {
+		return errors.New("expiry time must be in the future")
+	}
+	return nil
+}
+
+// EditTransactionRequest changes the content of a pending transaction. Fields left out
+// are kept, Version is the version of the transaction the changes were made on.
+type EditTransactionRequest struct {
+	Fee             *Fees     `json:"fee"`
+	Title           *string   `json:"title"`
+	Messages        []Message `json:"messages"`
+	Memo            *string   `json:"memo"`
+	Version         int       `json:"version"`
+	ClearSignatures bool      `json:"clear_signatures"`
+}
+
+func (m EditTransactionRequest) Validate() error {
+	if m.Version <= 0 {
+		return errors.New("version is required")
+	}
+
+	if m.Fee == nil && m.Title == nil && m.Messages == nil && m.Memo == nil {
+		return errors.New("nothing to update")
+	}
+
+	if m.Fee != nil {
+		if err := m.Fee.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if m.Messages != nil && len(m.Messages) == 0 {
+		return errors.New("atleast one messages is required")
+	}
+
+	return nil
+}
+
+// ValidateMessages checks the messages against the rules of the chain.
+func ValidateMessages(messages []Message, opts cosmos.ValidateOptions) error {
+	for i, m := range messages {
+		if err := cosmos.ValidateMsg(m.Msg(), opts); err != nil {
+			return fmt.Errorf("message %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+type SimulateTransactionRequest struct {
+	Fee      Fees      `json:"fee"`
+	Messages []Message `json:"messages"`
+	Memo     string    `json:"memo"`
+}
+
+func (m SimulateTransactionRequest) Validate() error {
+	if len(m.Messages) == 0 {
+		return errors.New("atleast one messages is required")
+	}
+
 	return nil
 }
@@ -1,9 +1,19 @@
 package model
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vitwit/resolute/server/cosmos"
 )
 
+// ChallengeSaltPrefix starts the line of a login challenge holding the salt, which
+// binds the salt stored with the user to the signature.
+const ChallengeSaltPrefix = "Salt: "
+
 type CreateUserSignature struct {
 	Address      string `pg:"address" json:"address"`
 	Signature    string `pg:"signature" json:"signature"`
@@ -30,7 +40,35 @@ func (cu CreateUserSignature) Validate() error {
 		return errors.New("pubkey cannot be empty")
 	}
 
-	// TODO: need to verify the signature with message and pubkey
+	if len(cu.Message) == 0 {
+		return errors.New("message cannot be empty")
+	}
+
+	if signedSalt(cu.Message) != cu.Salt {
+		return errors.New("salt does not match the signed message")
+	}
+
+	var pubkey Pubkey
+	if err := json.Unmarshal([]byte(cu.PubKey), &pubkey); err != nil {
+		return fmt.Errorf("invalid pubkey: %w", err)
+	}
+
+	pk, err := pubkey.Parse()
+	if err != nil {
+		return err
+	}
+
+	return cosmos.VerifyADR36(pk, cu.Address, []byte(cu.Message), cu.Signature)
+}
+
+// signedSalt returns the salt of a signed login challenge, or 0 when it has none.
+func signedSalt(message string) int64 {
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, ChallengeSaltPrefix) {
+			salt, _ := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, ChallengeSaltPrefix)), 10, 64)
+			return salt
+		}
+	}
 
-	return nil
+	return 0
 }
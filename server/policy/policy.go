@@ -0,0 +1,351 @@
+// Package policy evaluates the spending policies of multisig accounts against the
+// messages of a transaction.
+//
+// Policies either raise the number of signatures a transaction needs, or forbid it
+// outright until the transaction or the policy changes.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/summary"
+)
+
+// Policy types.
+const (
+	// LargeSend requires more signatures for transfers above an amount of a denom.
+	LargeSend = "large_send"
+	// RecipientAllowlist restricts the recipients of transfers to a list of addresses.
+	RecipientAllowlist = "recipient_allowlist"
+	// DailyOutflow caps the amount of a denom sent in 24 hours.
+	DailyOutflow = "daily_outflow"
+	// AuthzGrantees restricts authz and fee grants to the members of the multisig and
+	// a list of addresses.
+	AuthzGrantees = "authz_grantees"
+)
+
+// Types lists every policy type.
+var Types = []string{LargeSend, RecipientAllowlist, DailyOutflow, AuthzGrantees}
+
+// Params are the settings of a policy, the fields used depend on its type.
+type Params struct {
+	Denom  string `json:"denom,omitempty"`
+	Amount string `json:"amount,omitempty"`
+	// Signatures required by a large send, all the signers of the multisig when 0
+	Signatures int      `json:"signatures,omitempty"`
+	Addresses  []string `json:"addresses,omitempty"`
+}
+
+// Policy is an active policy of a multisig account.
+type Policy struct {
+	ID     int    `json:"id"`
+	Type   string `json:"type"`
+	Params Params `json:"params"`
+}
+
+// Validate checks the params of a policy of the given type.
+func Validate(policyType string, params Params) error {
+	switch policyType {
+	case LargeSend, DailyOutflow:
+		if params.Denom == "" {
+			return errors.New("denom cannot be empty")
+		}
+		if n, ok := new(big.Int).SetString(params.Amount, 10); !ok || n.Sign() < 0 {
+			return fmt.Errorf("invalid amount %q", params.Amount)
+		}
+		if params.Signatures < 0 {
+			return errors.New("signatures cannot be negative")
+		}
+	case RecipientAllowlist, AuthzGrantees:
+		if policyType == RecipientAllowlist && len(params.Addresses) == 0 {
+			return errors.New("addresses cannot be empty")
+		}
+		for _, address := range params.Addresses {
+			if _, _, err := cosmos.DecodeAddress(address); err != nil {
+				return fmt.Errorf("invalid address %s", address)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid policy type %s", policyType)
+	}
+
+	return nil
+}
+
+// Transfer is an amount sent out of the multisig account.
+type Transfer struct {
+	To     string
+	Denom  string
+	Amount *big.Int
+}
+
+// Transfers returns the amounts sent by the messages, including the ones executed
+// through authz, as a grantee can also execute messages on its own behalf.
+func Transfers(msgs []cosmos.Msg) []Transfer {
+	var transfers []Transfer
+	for _, msg := range msgs {
+		v := cosmos.Value(msg.Value)
+		switch msg.TypeUrl {
+		case cosmos.MsgSendType.TypeUrl:
+			transfers = appendCoins(transfers, str(v, "toAddress"), v, "amount")
+		case cosmos.MsgMultiSendType.TypeUrl:
+			outputs, _ := v.List("outputs")
+			for _, o := range outputs {
+				if out, ok := o.(map[string]interface{}); ok {
+					transfers = appendCoins(transfers, str(cosmos.Value(out), "address"), cosmos.Value(out), "coins")
+				}
+			}
+		case cosmos.MsgTransferType.TypeUrl:
+			if token, err := v.Object("token"); err == nil {
+				transfers = appendCoin(transfers, str(v, "receiver"), token)
+			}
+		case cosmos.MsgExecuteContractType.TypeUrl:
+			transfers = appendCoins(transfers, str(v, "contract"), v, "funds")
+		case cosmos.MsgExecType.TypeUrl:
+			list, _ := v.List("msgs")
+			transfers = append(transfers, Transfers(summary.Decode(list))...)
+		}
+	}
+
+	return transfers
+}
+
+// Grantees returns the addresses given authz or fee grants by the messages.
+func Grantees(msgs []cosmos.Msg) []string {
+	var grantees []string
+	for _, msg := range msgs {
+		v := cosmos.Value(msg.Value)
+		switch msg.TypeUrl {
+		case cosmos.MsgGrantType.TypeUrl, cosmos.MsgGrantAllowanceType.TypeUrl:
+			grantees = append(grantees, str(v, "grantee"))
+		case cosmos.MsgExecType.TypeUrl:
+			list, _ := v.List("msgs")
+			grantees = append(grantees, Grantees(summary.Decode(list))...)
+		}
+	}
+
+	return grantees
+}
+
+// Totals adds up transfers by denom.
+func Totals(transfers []Transfer) map[string]*big.Int {
+	totals := make(map[string]*big.Int)
+	for _, t := range transfers {
+		if totals[t.Denom] == nil {
+			totals[t.Denom] = new(big.Int)
+		}
+		totals[t.Denom].Add(totals[t.Denom], t.Amount)
+	}
+
+	return totals
+}
+
+// Account holds the details of the multisig account policies are evaluated with.
+type Account struct {
+	Threshold int
+	// Members are the addresses of the keys of the multisig
+	Members []string
+	// Outflow is the amount sent by other transactions in the last 24 hours, by denom
+	Outflow map[string]*big.Int
+	// Chain is used to display amounts
+	Chain summary.Chain
+}
+
+// Requirement is what a policy requires from a transaction.
+type Requirement struct {
+	PolicyID    int    `json:"policy_id"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Met         bool   `json:"met"`
+	// Blocking requirements can not be met by collecting more signatures
+	Blocking bool `json:"blocking"`
+}
+
+// Result is the evaluation of the policies of an account against a transaction.
+type Result struct {
+	Requirements       []Requirement `json:"requirements"`
+	RequiredSignatures int           `json:"required_signatures"`
+}
+
+// Violations returns the blocking requirements the transaction does not meet.
+func (r Result) Violations() []Requirement {
+	var violations []Requirement
+	for _, req := range r.Requirements {
+		if req.Blocking && !req.Met {
+			violations = append(violations, req)
+		}
+	}
+
+	return violations
+}
+
+// Err describes the violations of the policies, or is nil when there are none.
+func (r Result) Err() error {
+	violations := r.Violations()
+	if len(violations) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, len(violations))
+	for i, v := range violations {
+		descriptions[i] = v.Description
+	}
+
+	return fmt.Errorf("transaction violates the policies of the multisig account: %s", strings.Join(descriptions, "; "))
+}
+
+// Evaluate returns the requirements of the policies which apply to the messages,
+// given the number of signatures the transaction has.
+func Evaluate(policies []Policy, msgs []cosmos.Msg, signatures int, account Account) Result {
+	transfers := Transfers(msgs)
+	totals := Totals(transfers)
+
+	result := Result{
+		Requirements:       make([]Requirement, 0),
+		RequiredSignatures: account.Threshold,
+	}
+	for _, p := range policies {
+		req := Requirement{PolicyID: p.ID, Type: p.Type}
+		limit, _ := new(big.Int).SetString(p.Params.Amount, 10)
+
+		switch p.Type {
+		case LargeSend:
+			total := totals[p.Params.Denom]
+			if total == nil || limit == nil || total.Cmp(limit) <= 0 {
+				continue
+			}
+
+			required := p.Params.Signatures
+			if required == 0 || required > len(account.Members) {
+				required = len(account.Members)
+			}
+			if required > result.RequiredSignatures {
+				result.RequiredSignatures = required
+			}
+
+			req.Description = fmt.Sprintf("sends above %s require %d signatures", account.display(p.Params.Denom, limit), required)
+			req.Met = signatures >= required
+
+		case RecipientAllowlist:
+			if len(transfers) == 0 {
+				continue
+			}
+
+			var unknown []string
+			for _, t := range transfers {
+				if !contains(p.Params.Addresses, t.To) {
+					unknown = appendUnique(unknown, t.To)
+				}
+			}
+
+			req.Blocking = true
+			req.Met = len(unknown) == 0
+			req.Description = "recipients must be on the allowlist"
+			if !req.Met {
+				req.Description = fmt.Sprintf("%s: %s", req.Description, strings.Join(unknown, ", "))
+			}
+
+		case DailyOutflow:
+			total := totals[p.Params.Denom]
+			if total == nil || limit == nil {
+				continue
+			}
+
+			outflow := new(big.Int).Add(total, account.outflow(p.Params.Denom))
+			req.Blocking = true
+			req.Met = outflow.Cmp(limit) <= 0
+			req.Description = fmt.Sprintf("at most %s can be sent in 24 hours, %s with this transaction",
+				account.display(p.Params.Denom, limit), account.display(p.Params.Denom, outflow))
+
+		case AuthzGrantees:
+			grantees := Grantees(msgs)
+			if len(grantees) == 0 {
+				continue
+			}
+
+			var unknown []string
+			for _, grantee := range grantees {
+				if !contains(p.Params.Addresses, grantee) && !contains(account.Members, grantee) {
+					unknown = appendUnique(unknown, grantee)
+				}
+			}
+
+			req.Blocking = true
+			req.Met = len(unknown) == 0
+			req.Description = "grants can only be given to known addresses"
+			if !req.Met {
+				req.Description = fmt.Sprintf("%s: %s", req.Description, strings.Join(unknown, ", "))
+			}
+
+		default:
+			continue
+		}
+
+		result.Requirements = append(result.Requirements, req)
+	}
+
+	return result
+}
+
+func (a Account) outflow(denom string) *big.Int {
+	if n := a.Outflow[denom]; n != nil {
+		return n
+	}
+
+	return new(big.Int)
+}
+
+func (a Account) display(denom string, amount *big.Int) string {
+	return a.Chain.Amount(denom, amount.String()).Display
+}
+
+func appendCoins(transfers []Transfer, to string, v cosmos.Value, name string) []Transfer {
+	list, _ := v.List(name)
+	for _, item := range list {
+		if coin, ok := item.(map[string]interface{}); ok {
+			transfers = appendCoin(transfers, to, cosmos.Value(coin))
+		}
+	}
+
+	return transfers
+}
+
+func appendCoin(transfers []Transfer, to string, coin cosmos.Value) []Transfer {
+	amount, ok := new(big.Int).SetString(str(coin, "amount"), 10)
+	if !ok {
+		return transfers
+	}
+
+	return append(transfers, Transfer{To: to, Denom: str(coin, "denom"), Amount: amount})
+}
+
+// contains reports whether the address is in the list, whatever the prefix it is
+// encoded with.
+func contains(addresses []string, address string) bool {
+	for _, a := range addresses {
+		if a == address || cosmos.SameAccount(a, address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, item := range list {
+		if item == s {
+			return list
+		}
+	}
+
+	return append(list, s)
+}
+
+func str(v cosmos.Value, name string) string {
+	s, _ := v.String(name)
+	return s
+}
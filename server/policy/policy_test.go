@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/cosmos"
+	"github.com/vitwit/resolute/server/summary"
+)
+
+func testAddress(t *testing.T, b byte) string {
+	address, err := cosmos.EncodeAddress("cosmos", bytes.Repeat([]byte{b}, 20))
+	require.NoError(t, err)
+
+	return address
+}
+
+func decodeMsgs(t *testing.T, messages string) []cosmos.Msg {
+	var list []interface{}
+	require.NoError(t, json.Unmarshal([]byte(messages), &list))
+
+	return summary.Decode(list)
+}
+
+func TestEvaluate(t *testing.T) {
+	multisig := testAddress(t, 0x0f)
+	members := []string{testAddress(t, 0x01), testAddress(t, 0x02), testAddress(t, 0x03)}
+	friend := testAddress(t, 0x04)
+	stranger := testAddress(t, 0x05)
+
+	send := func(to, amount string) string {
+		return `{"typeUrl":"/cosmos.bank.v1beta1.MsgSend","value":{"fromAddress":"` + multisig + `","toAddress":"` + to +
+			`","amount":[{"denom":"uatom","amount":"` + amount + `"}]}}`
+	}
+	grant := func(grantee string) string {
+		return `{"typeUrl":"/cosmos.authz.v1beta1.MsgGrant","value":{"granter":"` + multisig + `","grantee":"` + grantee +
+			`","grant":{"authorization":{"typeUrl":"/cosmos.authz.v1beta1.GenericAuthorization","value":{"msg":"/cosmos.gov.v1beta1.MsgVote"}}}}}`
+	}
+
+	policies := []Policy{
+		{ID: 1, Type: LargeSend, Params: Params{Denom: "uatom", Amount: "10000000"}},
+		{ID: 2, Type: RecipientAllowlist, Params: Params{Addresses: []string{friend, members[0]}}},
+		{ID: 3, Type: DailyOutflow, Params: Params{Denom: "uatom", Amount: "50000000"}},
+		{ID: 4, Type: AuthzGrantees, Params: Params{Addresses: []string{friend}}},
+	}
+	account := Account{
+		Threshold: 2,
+		Members:   members,
+		Outflow:   map[string]*big.Int{"uatom": big.NewInt(30000000)},
+	}
+
+	testCases := []struct {
+		name          string
+		messages      string
+		signatures    int
+		expRequired   int
+		expPolicies   []int
+		expViolations []int
+	}{
+		{"small send to an allowed recipient", `[` + send(friend, "1000000") + `]`, 0, 2, []int{2, 3}, nil},
+		{"large send needs all signers", `[` + send(friend, "15000000") + `]`, 2, 3, []int{1, 2, 3}, nil},
+		{"recipient not on the allowlist", `[` + send(stranger, "1000000") + `]`, 0, 2, []int{2, 3}, []int{2}},
+		{"daily outflow exceeded", `[` + send(friend, "15000000") + `,` + send(friend, "6000000") + `]`, 0, 3, []int{1, 2, 3}, []int{3}},
+		{"send executed through authz", `[{"typeUrl":"/cosmos.authz.v1beta1.MsgExec","value":{"grantee":"` + multisig + `","msgs":[` + send(stranger, "1000000") + `]}}]`,
+			0, 2, []int{2, 3}, []int{2}},
+		{"grant to a member", `[` + grant(members[1]) + `]`, 0, 2, []int{4}, nil},
+		{"grant to an allowed address", `[` + grant(friend) + `]`, 0, 2, []int{4}, nil},
+		{"grant to an unknown address", `[` + grant(stranger) + `]`, 0, 2, []int{4}, []int{4}},
+		{"no policy applies", `[{"typeUrl":"/cosmos.gov.v1beta1.MsgVote","value":{"proposalId":"1","voter":"` + multisig + `","option":1}}]`, 0, 2, nil, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := Evaluate(policies, decodeMsgs(t, tc.messages), tc.signatures, account)
+			require.Equal(t, tc.expRequired, result.RequiredSignatures)
+
+			var ids []int
+			for _, req := range result.Requirements {
+				ids = append(ids, req.PolicyID)
+			}
+			require.Equal(t, tc.expPolicies, ids)
+
+			var violations []int
+			for _, v := range result.Violations() {
+				violations = append(violations, v.PolicyID)
+			}
+			require.Equal(t, tc.expViolations, violations)
+			require.Equal(t, len(tc.expViolations) > 0, result.Err() != nil)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		policyType string
+		params     Params
+		expErr     bool
+	}{
+		{"large send", LargeSend, Params{Denom: "uatom", Amount: "1000", Signatures: 3}, false},
+		{"daily outflow without denom", DailyOutflow, Params{Amount: "1000"}, true},
+		{"invalid amount", LargeSend, Params{Denom: "uatom", Amount: "1.5"}, true},
+		{"empty allowlist", RecipientAllowlist, Params{}, true},
+		{"invalid grantee", AuthzGrantees, Params{Addresses: []string{"cosmos1invalid"}}, true},
+		{"unknown type", "max_fee", Params{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.policyType, tc.params)
+			if tc.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
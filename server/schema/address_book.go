@@ -0,0 +1,16 @@
+package schema
+
+import "time"
+
+// AddressBookEntry is a labelled address of a multisig account.
+type AddressBookEntry struct {
+	ID              int       `pg:"id,pk" json:"id"`
+	MultisigAddress string    `pg:"multisig_address,use_zero" json:"multisig_address"`
+	Address         string    `pg:"address,use_zero" json:"address"`
+	Label           string    `pg:"label,use_zero" json:"label"`
+	ChainID         string    `pg:"chain_id,use_zero" json:"chain_id"`
+	Notes           string    `pg:"notes,use_zero" json:"notes"`
+	CreatedBy       string    `pg:"created_by,use_zero" json:"created_by"`
+	CreatedAt       time.Time `pg:"created_at,use_zero" json:"created_at"`
+	UpdatedAt       time.Time `pg:"updated_at,use_zero" json:"updated_at"`
+}
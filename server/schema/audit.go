@@ -0,0 +1,20 @@
+package schema
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent is an entry of the append-only log of actions on a multisig account.
+type AuditEvent struct {
+	ID              int64           `pg:"id,pk" json:"id"`
+	MultisigAddress string          `pg:"multisig_address,use_zero" json:"multisig_address"`
+	TxID            *int            `pg:"tx_id" json:"tx_id"`
+	Actor           string          `pg:"actor,use_zero" json:"actor"`
+	Action          string          `pg:"action,use_zero" json:"action"`
+	OldValue        json.RawMessage `pg:"old_value" json:"old_value"`
+	NewValue        json.RawMessage `pg:"new_value" json:"new_value"`
+	CreatedAt       time.Time       `pg:"created_at,use_zero" json:"created_at"`
+	PrevHash        string          `pg:"prev_hash,use_zero" json:"prev_hash"`
+	Hash            string          `pg:"hash,use_zero" json:"hash"`
+}
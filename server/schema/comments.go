@@ -0,0 +1,18 @@
+package schema
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Comment struct {
+	ID              int             `pg:"id,pk" json:"id"`
+	TxID            int             `pg:"tx_id" json:"tx_id"`
+	MultisigAddress string          `pg:"multisig_address" json:"multisig_address"`
+	Author          string          `pg:"author" json:"author"`
+	Body            string          `pg:"body" json:"body"`
+	Attachments     json.RawMessage `pg:"attachments" json:"attachments"`
+	Edits           json.RawMessage `pg:"edits" json:"edits"`
+	CreatedAt       time.Time       `pg:"created_at" json:"created_at"`
+	UpdatedAt       time.Time       `pg:"updated_at" json:"updated_at"`
+}
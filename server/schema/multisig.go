@@ -6,13 +6,16 @@ import (
 )
 
 type MultisigAccount struct {
-	Address    string     `pg:"address,pk" json:"address"`
-	Threshold  int        `pg:"threshold" json:"threshold"`
-	ChainID    string     `pg:"chain_id,use_zero" json:"chain_id"`
-	PubkeyType string     `pg:"pubkey_type,use_zero" json:"pubkey_type"`
-	CreatedAt  *time.Time `pg:"created_at" json:"created_at"`
-	CreatedBy  string     `pg:"created_by" json:"created_by"`
-	Name       string     `pg:"name,use_zero" json:"name"`
+	Address       string     `pg:"address,pk" json:"address"`
+	Threshold     int        `pg:"threshold" json:"threshold"`
+	ChainID       string     `pg:"chain_id,use_zero" json:"chain_id"`
+	PubkeyType    string     `pg:"pubkey_type,use_zero" json:"pubkey_type"`
+	CreatedAt     *time.Time `pg:"created_at" json:"created_at"`
+	CreatedBy     string     `pg:"created_by" json:"created_by"`
+	Name          string     `pg:"name,use_zero" json:"name"`
+	Visibility    string     `pg:"visibility,use_zero" json:"visibility"`
+	DeletedAt     *time.Time `pg:"deleted_at" json:"deleted_at,omitempty"`
+	RecipientMode string     `pg:"recipient_mode,use_zero" json:"recipient_mode"`
 }
 
 type Pubkey struct {
@@ -0,0 +1,32 @@
+package schema
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MultisigPolicy is an active spending policy of a multisig account.
+type MultisigPolicy struct {
+	ID              int             `pg:"id,pk" json:"id"`
+	MultisigAddress string          `pg:"multisig_address,use_zero" json:"multisig_address"`
+	Type            string          `pg:"type,use_zero" json:"type"`
+	Params          json.RawMessage `pg:"params" json:"params"`
+	CreatedBy       string          `pg:"created_by,use_zero" json:"created_by"`
+	ApprovedBy      string          `pg:"approved_by,use_zero" json:"approved_by"`
+	CreatedAt       time.Time       `pg:"created_at,use_zero" json:"created_at"`
+}
+
+// PolicyChange is a proposal to add or remove a policy of a multisig account.
+type PolicyChange struct {
+	ID              int             `pg:"id,pk" json:"id"`
+	MultisigAddress string          `pg:"multisig_address,use_zero" json:"multisig_address"`
+	Action          string          `pg:"action,use_zero" json:"action"`
+	PolicyID        *int            `pg:"policy_id" json:"policy_id"`
+	Type            string          `pg:"type,use_zero" json:"type"`
+	Params          json.RawMessage `pg:"params" json:"params"`
+	Status          string          `pg:"status,use_zero" json:"status"`
+	ProposedBy      string          `pg:"proposed_by,use_zero" json:"proposed_by"`
+	DecidedBy       *string         `pg:"decided_by" json:"decided_by"`
+	CreatedAt       time.Time       `pg:"created_at,use_zero" json:"created_at"`
+	DecidedAt       *time.Time      `pg:"decided_at" json:"decided_at"`
+}
@@ -0,0 +1,11 @@
+package schema
+
+import "time"
+
+type MultisigRole struct {
+	MultisigAddress string    `pg:"multisig_address,pk" json:"multisig_address"`
+	Address         string    `pg:"address,pk" json:"address"`
+	Role            string    `pg:"role,pk" json:"role"`
+	GrantedBy       string    `pg:"granted_by" json:"granted_by"`
+	CreatedAt       time.Time `pg:"created_at" json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package schema
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TxTemplate is a saved transaction of a multisig account, with parameters to fill in
+// when it is proposed.
+type TxTemplate struct {
+	ID              int             `pg:"id,pk" json:"id"`
+	MultisigAddress string          `pg:"multisig_address,use_zero" json:"multisig_address"`
+	Name            string          `pg:"name,use_zero" json:"name"`
+	Title           string          `pg:"title,use_zero" json:"title"`
+	Messages        json.RawMessage `pg:"messages" json:"messages"`
+	Fee             json.RawMessage `pg:"fee" json:"fee"`
+	Memo            string          `pg:"memo,use_zero" json:"memo"`
+	Params          json.RawMessage `pg:"params" json:"params"`
+	CreatedBy       string          `pg:"created_by,use_zero" json:"created_by"`
+	CreatedAt       time.Time       `pg:"created_at,use_zero" json:"created_at"`
+	UpdatedAt       time.Time       `pg:"updated_at,use_zero" json:"updated_at"`
+}
+
+// TxSchedule proposes transactions from a template on a cron schedule, on behalf of
+// the member who created it.
+type TxSchedule struct {
+	ID              int             `pg:"id,pk" json:"id"`
+	MultisigAddress string          `pg:"multisig_address,use_zero" json:"multisig_address"`
+	TemplateID      int             `pg:"template_id,use_zero" json:"template_id"`
+	Spec            string          `pg:"spec,use_zero" json:"spec"`
+	Params          json.RawMessage `pg:"params" json:"params"`
+	ExpiresIn       int64           `pg:"expires_in,use_zero" json:"expires_in"`
+	Active          bool            `pg:"active,use_zero" json:"active"`
+	NextRunAt       time.Time       `pg:"next_run_at,use_zero" json:"next_run_at"`
+	LastRunAt       *time.Time      `pg:"last_run_at" json:"last_run_at"`
+	LastTxID        *int            `pg:"last_tx_id" json:"last_tx_id"`
+	LastError       *string         `pg:"last_error" json:"last_error"`
+	CreatedBy       string          `pg:"created_by,use_zero" json:"created_by"`
+	CreatedAt       time.Time       `pg:"created_at,use_zero" json:"created_at"`
+}
@@ -3,21 +3,35 @@ package schema
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/vitwit/resolute/server/policy"
+	"github.com/vitwit/resolute/server/summary"
 )
 
 type Transaction struct {
-	ID              int              `pg:"id,pk" json:"id"`
-	MultisigAddress string           `pg:"multisig_address,use_zero" json:"multisig_address"`
-	Fee             *json.RawMessage `pg:"fee" json:"fee" sql:"-"`
-	Status          string           `pg:"status,use_zero" json:"status"`
-	Messages        json.RawMessage  `pg:"messages" json:"messages"`
-	Hash            *string          `pg:"hash" json:"hash"`
-	ErrMsg          *string          `pg:"err_msg" json:"err_msg"`
-	Memo            *string          `pg:"memo" json:"memo"`
-	Signatures      json.RawMessage  `pg:"signatures" json:"signatures"`
-	LastUpdated     time.Time        `pg:"last_updated,use_zero" json:"last_updated"`
-	CreatedAt       time.Time        `pg:"created_at,use_zero" json:"created_at"`
-	SignedAt        time.Time        `pg:"signed_at,use_zero" sql:"-"  json:"signed_at,omitempty"`
+	ID              int               `pg:"id,pk" json:"id"`
+	MultisigAddress string            `pg:"multisig_address,use_zero" json:"multisig_address"`
+	Fee             *json.RawMessage  `pg:"fee" json:"fee" sql:"-"`
+	Status          string            `pg:"status,use_zero" json:"status"`
+	Messages        json.RawMessage   `pg:"messages" json:"messages"`
+	Hash            *string           `pg:"hash" json:"hash"`
+	ErrMsg          *string           `pg:"err_msg" json:"err_msg"`
+	Memo            *string           `pg:"memo" json:"memo"`
+	Signatures      json.RawMessage   `pg:"signatures" json:"signatures"`
+	LastUpdated     time.Time         `pg:"last_updated,use_zero" json:"last_updated"`
+	CreatedAt       time.Time         `pg:"created_at,use_zero" json:"created_at"`
+	SignedAt        time.Time         `pg:"signed_at,use_zero" sql:"-"  json:"signed_at,omitempty"`
+	AccountNumber   *uint64           `pg:"account_number" json:"account_number"`
+	Sequence        *uint64           `pg:"sequence" json:"sequence"`
+	Warnings        json.RawMessage   `pg:"warnings" json:"warnings"`
+	CreatedBy       *string           `pg:"created_by" json:"created_by"`
+	Rejections      json.RawMessage   `pg:"rejections" json:"rejections"`
+	DeletedAt       *time.Time        `pg:"deleted_at" json:"deleted_at,omitempty"`
+	Version         int               `pg:"version" json:"version"`
+	ExpiresAt       *time.Time        `pg:"expires_at" json:"expires_at"`
+	TimeoutHeight   *uint64           `pg:"timeout_height" json:"timeout_height"`
+	Summaries       []summary.Summary `sql:"-" json:"summaries"`
+	Policy          *policy.Result    `sql:"-" json:"policy,omitempty"`
 }
 
 type TransactionCount struct {
@@ -26,19 +40,31 @@ type TransactionCount struct {
 }
 
 type AllTransactionResult struct {
-	ID              int              `pg:"id,pk" json:"id"`
-	Title           string           `pg:"title" json:"title,omitempty" sql:"-"`
-	MultisigAddress string           `pg:"multisig_address,use_zero" json:"multisig_address"`
-	Fee             *json.RawMessage `pg:"fee" json:"fee,omitempty" sql:"-"`
-	Status          string           `pg:"status,use_zero" json:"status"`
-	Messages        *json.RawMessage `pg:"messages" json:"messages"`
-	Hash            *string          `pg:"hash" json:"hash"`
-	ErrMsg          *string          `pg:"err_msg" json:"err_msg"`
-	Memo            *string          `pg:"memo" json:"memo"`
-	Signatures      *json.RawMessage `pg:"signatures" json:"signatures"`
-	LastUpdated     time.Time        `pg:"last_updated" sql:"-" json:"last_updated,omitempty"`
-	CreatedAt       time.Time        `pg:"created_at" sql:"-" json:"created_at,omitempty"`
-	Threshold       int              `pg:"threshold" json:"threshold"`
-	Pubkeys         json.RawMessage  `pg:"pubkeys" json:"pubkeys"`
-	SignedAt        time.Time        `pg:"signed_at,use_zero" sql:"-"  json:"signed_at,omitempty"`
+	ID              int               `pg:"id,pk" json:"id"`
+	Title           string            `pg:"title" json:"title,omitempty" sql:"-"`
+	MultisigAddress string            `pg:"multisig_address,use_zero" json:"multisig_address"`
+	Fee             *json.RawMessage  `pg:"fee" json:"fee,omitempty" sql:"-"`
+	Status          string            `pg:"status,use_zero" json:"status"`
+	Messages        *json.RawMessage  `pg:"messages" json:"messages"`
+	Hash            *string           `pg:"hash" json:"hash"`
+	ErrMsg          *string           `pg:"err_msg" json:"err_msg"`
+	Memo            *string           `pg:"memo" json:"memo"`
+	Signatures      *json.RawMessage  `pg:"signatures" json:"signatures"`
+	LastUpdated     time.Time         `pg:"last_updated" sql:"-" json:"last_updated,omitempty"`
+	CreatedAt       time.Time         `pg:"created_at" sql:"-" json:"created_at,omitempty"`
+	Threshold       int               `pg:"threshold" json:"threshold"`
+	Pubkeys         json.RawMessage   `pg:"pubkeys" json:"pubkeys"`
+	SignedAt        time.Time         `pg:"signed_at,use_zero" sql:"-"  json:"signed_at,omitempty"`
+	AccountNumber   *uint64           `pg:"account_number" json:"account_number"`
+	Sequence        *uint64           `pg:"sequence" json:"sequence"`
+	Warnings        json.RawMessage   `pg:"warnings" json:"warnings"`
+	CreatedBy       *string           `pg:"created_by" json:"created_by"`
+	Rejections      json.RawMessage   `pg:"rejections" json:"rejections"`
+	DeletedAt       *time.Time        `pg:"deleted_at" json:"deleted_at,omitempty"`
+	Version         int               `pg:"version" json:"version"`
+	ExpiresAt       *time.Time        `pg:"expires_at" json:"expires_at"`
+	TimeoutHeight   *uint64           `pg:"timeout_height" json:"timeout_height"`
+	Comments        int               `pg:"comments" sql:"-" json:"comments"`
+	Summaries       []summary.Summary `sql:"-" json:"summaries"`
+	Policy          *policy.Result    `sql:"-" json:"policy,omitempty"`
 }
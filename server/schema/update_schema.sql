@@ -25,3 +25,302 @@ BEGIN
         ADD COLUMN signed_at TIMESTAMP DEFAULT NULL;
     END IF;
 END $$;
+
+DO $$
+BEGIN
+    -- Create multisig_roles and grant the existing permissions if it doesn't exist
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.tables
+        WHERE table_name = 'multisig_roles'
+    ) THEN
+        CREATE TABLE multisig_roles (
+            multisig_address character varying(50) NOT NULL REFERENCES multisig_accounts(address),
+            address character varying(50) NOT NULL,
+            role character varying(20) NOT NULL,
+            granted_by character varying(50) NOT NULL,
+            created_at timestamp with time zone DEFAULT now() NOT NULL,
+            PRIMARY KEY (multisig_address, address, role)
+        );
+
+        INSERT INTO multisig_roles (multisig_address, address, role, granted_by, created_at)
+        SELECT address, created_by, 'admin', created_by, created_at FROM multisig_accounts;
+
+        INSERT INTO multisig_roles (multisig_address, address, role, granted_by, created_at)
+        SELECT p.multisig_address, p.address, r.role, m.created_by, m.created_at
+        FROM pubkeys p
+        JOIN multisig_accounts m ON p.multisig_address = m.address
+        CROSS JOIN (VALUES ('proposer'), ('signer')) AS r(role);
+    END IF;
+END $$;
+
+DO $$
+BEGIN
+    -- Check and add visibility if it doesn't exist
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_name = 'multisig_accounts' AND column_name = 'visibility'
+    ) THEN
+        -- accounts created before visibility was added stay readable by anyone
+        ALTER TABLE multisig_accounts
+        ADD COLUMN visibility VARCHAR(10) DEFAULT 'public' NOT NULL;
+
+        ALTER TABLE multisig_accounts
+        ALTER COLUMN visibility SET DEFAULT 'private';
+    END IF;
+END $$;
+
+-- Transactions submitted by the server stay BROADCASTING until they are included in a block
+ALTER TYPE tx_status ADD VALUE IF NOT EXISTS 'BROADCASTING';
+
+-- Pending transactions whose sequence was used by another transaction must be signed again
+ALTER TYPE tx_status ADD VALUE IF NOT EXISTS 'STALE';
+
+DO $$
+BEGIN
+    -- Check and add the account number and sequence a transaction is signed for if they don't exist
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_name = 'transactions' AND column_name = 'sequence'
+    ) THEN
+        ALTER TABLE transactions
+        ADD COLUMN account_number BIGINT DEFAULT NULL,
+        ADD COLUMN sequence BIGINT DEFAULT NULL;
+
+        CREATE INDEX transactions_multisig_sequence_idx ON transactions (multisig_address, sequence);
+    END IF;
+END $$;
+
+DO $$
+BEGIN
+    -- Check and add the warnings of the create time simulation if they don't exist
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_name = 'transactions' AND column_name = 'warnings'
+    ) THEN
+        ALTER TABLE transactions
+        ADD COLUMN warnings JSONB DEFAULT '[]'::jsonb NOT NULL;
+    END IF;
+END $$;
+
+-- Transactions which can no longer reach the threshold
+ALTER TYPE tx_status ADD VALUE IF NOT EXISTS 'REJECTED';
+
+-- Transactions withdrawn by their proposer
+ALTER TYPE tx_status ADD VALUE IF NOT EXISTS 'CANCELLED';
+
+DO $$
+BEGIN
+    -- Check and add the proposer and the rejections of transactions if they don't exist
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_name = 'transactions' AND column_name = 'rejections'
+    ) THEN
+        ALTER TABLE transactions
+        ADD COLUMN created_by VARCHAR(50) DEFAULT NULL,
+        ADD COLUMN rejections JSONB DEFAULT '[]'::jsonb NOT NULL;
+    END IF;
+END $$;
+
+-- Append-only log of the actions on multisig accounts. Events of an account are
+-- chained by hash, see the audit package.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    multisig_address character varying(50) NOT NULL,
+    tx_id integer DEFAULT NULL,
+    actor character varying(50) NOT NULL,
+    action character varying(50) NOT NULL,
+    old_value jsonb DEFAULT NULL,
+    new_value jsonb DEFAULT NULL,
+    created_at timestamp with time zone NOT NULL,
+    prev_hash character varying(64) NOT NULL,
+    hash character varying(64) NOT NULL UNIQUE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS audit_events_chain_idx ON audit_events (multisig_address, prev_hash);
+CREATE INDEX IF NOT EXISTS audit_events_tx_idx ON audit_events (multisig_address, tx_id);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_no_update ON audit_events;
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+DO $$
+BEGIN
+    -- Check and add the deletion time of multisig accounts and transactions if they don't exist.
+    -- Deleted rows are kept for the retention period, during which they can be restored.
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_name = 'multisig_accounts' AND column_name = 'deleted_at'
+    ) THEN
+        ALTER TABLE multisig_accounts
+        ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_name = 'transactions' AND column_name = 'deleted_at'
+    ) THEN
+        ALTER TABLE transactions
+        ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS multisig_accounts_deleted_at_idx ON multisig_accounts (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS transactions_deleted_at_idx ON transactions (deleted_at) WHERE deleted_at IS NOT NULL;
+
+DO $$
+BEGIN
+    -- Check and add the version of transactions if it doesn't exist. It is increased on
+    -- every change of the content or the signatures of a transaction.
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_name = 'transactions' AND column_name = 'version'
+    ) THEN
+        ALTER TABLE transactions
+        ADD COLUMN version INTEGER DEFAULT 1 NOT NULL;
+    END IF;
+END $$;
+
+-- Discussion of the members of a multisig account on its transactions
+CREATE TABLE IF NOT EXISTS tx_comments (
+    id SERIAL PRIMARY KEY,
+    tx_id integer NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    multisig_address character varying(50) NOT NULL,
+    author character varying(50) NOT NULL,
+    body text NOT NULL,
+    attachments jsonb DEFAULT '[]'::jsonb NOT NULL,
+    edits jsonb DEFAULT '[]'::jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS tx_comments_tx_idx ON tx_comments (tx_id);
+
+-- Transactions which were not broadcast before their expiry time
+ALTER TYPE tx_status ADD VALUE IF NOT EXISTS 'EXPIRED';
+
+DO $$
+BEGIN
+    -- Check and add the expiry time and the timeout height of transactions if they don't exist
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_name = 'transactions' AND column_name = 'expires_at'
+    ) THEN
+        ALTER TABLE transactions
+        ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+        ADD COLUMN timeout_height BIGINT DEFAULT NULL;
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS transactions_expires_at_idx ON transactions (expires_at) WHERE expires_at IS NOT NULL;
+
+-- Spending policies of multisig accounts, see the policy package
+CREATE TABLE IF NOT EXISTS multisig_policies (
+    id SERIAL PRIMARY KEY,
+    multisig_address character varying(50) NOT NULL REFERENCES multisig_accounts(address),
+    type character varying(50) NOT NULL,
+    params jsonb DEFAULT '{}'::jsonb NOT NULL,
+    created_by character varying(50) NOT NULL,
+    approved_by character varying(50) NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS multisig_policies_address_idx ON multisig_policies (multisig_address);
+
+-- Policies are added and removed once another admin approves the change
+CREATE TABLE IF NOT EXISTS policy_changes (
+    id SERIAL PRIMARY KEY,
+    multisig_address character varying(50) NOT NULL REFERENCES multisig_accounts(address),
+    action character varying(10) NOT NULL,
+    policy_id integer DEFAULT NULL,
+    type character varying(50) NOT NULL,
+    params jsonb DEFAULT '{}'::jsonb NOT NULL,
+    status character varying(10) NOT NULL,
+    proposed_by character varying(50) NOT NULL,
+    decided_by character varying(50) DEFAULT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    decided_at timestamp with time zone DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS policy_changes_address_idx ON policy_changes (multisig_address, status);
+
+-- Labelled addresses of multisig accounts, shown next to the addresses of transactions
+CREATE TABLE IF NOT EXISTS address_book (
+    id SERIAL PRIMARY KEY,
+    multisig_address character varying(50) NOT NULL REFERENCES multisig_accounts(address),
+    address character varying(100) NOT NULL,
+    label character varying(100) NOT NULL,
+    chain_id character varying(50) NOT NULL,
+    notes text DEFAULT '' NOT NULL,
+    created_by character varying(50) NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    UNIQUE (multisig_address, address)
+);
+
+DO $$
+BEGIN
+    -- Check and add the recipient mode if it doesn't exist
+    IF NOT EXISTS (
+        SELECT 1
+        FROM information_schema.columns
+        WHERE table_name = 'multisig_accounts' AND column_name = 'recipient_mode'
+    ) THEN
+        ALTER TABLE multisig_accounts
+        ADD COLUMN recipient_mode VARCHAR(10) DEFAULT 'off' NOT NULL;
+    END IF;
+END $$;
+
+-- Saved transactions of multisig accounts with {{name}} placeholders for their parameters
+CREATE TABLE IF NOT EXISTS tx_templates (
+    id SERIAL PRIMARY KEY,
+    multisig_address character varying(50) NOT NULL REFERENCES multisig_accounts(address),
+    name character varying(100) NOT NULL,
+    title text DEFAULT '' NOT NULL,
+    messages jsonb NOT NULL,
+    fee jsonb NOT NULL,
+    memo text DEFAULT '' NOT NULL,
+    params jsonb DEFAULT '[]'::jsonb NOT NULL,
+    created_by character varying(50) NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS tx_templates_address_idx ON tx_templates (multisig_address);
+
+-- Schedules proposing transactions from templates, see the cron package
+CREATE TABLE IF NOT EXISTS tx_schedules (
+    id SERIAL PRIMARY KEY,
+    multisig_address character varying(50) NOT NULL REFERENCES multisig_accounts(address),
+    template_id integer NOT NULL REFERENCES tx_templates(id) ON DELETE CASCADE,
+    spec character varying(100) NOT NULL,
+    params jsonb DEFAULT '{}'::jsonb NOT NULL,
+    expires_in bigint DEFAULT 0 NOT NULL,
+    active boolean DEFAULT true NOT NULL,
+    next_run_at timestamp with time zone NOT NULL,
+    last_run_at timestamp with time zone DEFAULT NULL,
+    last_tx_id integer DEFAULT NULL,
+    last_error text DEFAULT NULL,
+    created_by character varying(50) NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS tx_schedules_next_run_idx ON tx_schedules (next_run_at) WHERE active;
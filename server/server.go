@@ -71,32 +71,75 @@ func main() {
 	}
 
 	// Initialize handler
-	h := &handler.Handler{DB: db}
+	h := &handler.Handler{DB: db, RetentionPeriod: config.RETENTION_PERIOD}
 	m := &middle.Handler{DB: db}
 
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
-		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.DELETE},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.PATCH, echo.DELETE},
+		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
 	}))
 
 	// Routes
 	e.POST("/multisig", h.CreateMultisigAccount, m.AuthMiddleware)
-	e.GET("/multisig/accounts/:address", h.GetMultisigAccounts)
-	e.GET("/multisig/:address", h.GetMultisigAccount)
+	e.GET("/multisig/accounts/:address", h.GetMultisigAccounts, m.OptionalAuthMiddleware)
+	e.GET("/multisig/:address", h.GetMultisigAccount, m.CanReadMultisig)
 	e.DELETE("/multisig/:address", h.DeleteMultisigAccount, m.AuthMiddleware, m.IsMultisigAdmin)
-	e.POST("/multisig/:address/tx", h.CreateTransaction, m.AuthMiddleware, m.IsMultisigMember)
-	e.GET("/multisig/:address/tx/:id", h.GetTransaction)
-	e.POST("/multisig/:address/tx/:id", h.UpdateTransactionInfo, m.AuthMiddleware, m.IsMultisigMember)
-	e.DELETE("/multisig/:address/tx/:id", h.DeleteTransaction, m.AuthMiddleware, m.IsMultisigAdmin)
-	e.POST("/multisig/:address/sign-tx/:id", h.SignTransaction, m.AuthMiddleware, m.IsMultisigMember)
-	e.GET("/multisig/:address/txs", h.GetTransactions)
-	e.GET("/accounts/:address/all-txns", h.GetAllMultisigTxns)
+	e.POST("/multisig/:address/restore", h.RestoreMultisigAccount, m.AuthMiddleware, m.IsMultisigAdmin)
+	e.PUT("/multisig/:address/visibility", h.UpdateMultisigVisibility, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.PUT("/multisig/:address/recipient-mode", h.UpdateRecipientMode, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.POST("/multisig/:address/tx", h.CreateTransaction, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.POST("/multisig/:address/tx/simulate", h.SimulateTransaction, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.POST("/multisig/:address/tx/import", h.ImportTransactions, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.GET("/multisig/:address/tx/:id", h.GetTransaction, m.CanReadMultisig)
+	e.GET("/multisig/:address/tx/:id/tx-bytes", h.GetTxBytes, m.CanReadMultisig)
+	e.POST("/multisig/:address/tx/:id/broadcast", h.BroadcastTransaction, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer, model.RoleSigner), m.IsActiveMultisig)
+	e.POST("/multisig/:address/tx/:id/requeue", h.RequeueTransaction, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.POST("/multisig/:address/tx/:id/reject", h.RejectTransaction, m.AuthMiddleware, m.HasMultisigRole(model.RoleSigner), m.IsActiveMultisig)
+	e.POST("/multisig/:address/tx/:id/cancel", h.CancelTransaction, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.PATCH("/multisig/:address/tx/:id", h.EditTransaction, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.POST("/multisig/:address/tx/:id", h.UpdateTransactionInfo, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer, model.RoleSigner), m.IsActiveMultisig)
+	e.DELETE("/multisig/:address/tx/:id", h.DeleteTransaction, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.POST("/multisig/:address/tx/:id/restore", h.RestoreTransaction, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.POST("/multisig/:address/sign-tx/:id", h.SignTransaction, m.AuthMiddleware, m.HasMultisigRole(model.RoleSigner), m.IsActiveMultisig)
+	e.GET("/multisig/:address/tx/:id/comments", h.GetComments, m.AuthMiddleware, m.HasMultisigRole(model.Roles...))
+	e.POST("/multisig/:address/tx/:id/comments", h.CreateComment, m.AuthMiddleware, m.HasMultisigRole(model.Roles...), m.IsActiveMultisig)
+	e.PUT("/multisig/:address/tx/:id/comments/:commentId", h.UpdateComment, m.AuthMiddleware, m.HasMultisigRole(model.Roles...), m.IsActiveMultisig)
+	e.DELETE("/multisig/:address/tx/:id/comments/:commentId", h.DeleteComment, m.AuthMiddleware, m.HasMultisigRole(model.Roles...), m.IsActiveMultisig)
+	e.GET("/multisig/:address/txs", h.GetTransactions, m.CanReadMultisig)
+	e.GET("/multisig/:address/events", h.StreamEvents, m.TokenFromQuery, m.CanReadMultisig)
+	e.GET("/multisig/:address/roles", h.GetMultisigRoles, m.AuthMiddleware, m.HasMultisigRole(model.Roles...))
+	e.GET("/multisig/:address/audit", h.GetAuditEvents, m.AuthMiddleware, m.HasMultisigRole(model.Roles...))
+	e.POST("/multisig/:address/roles", h.GrantMultisigRole, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.DELETE("/multisig/:address/roles/:member/:role", h.RevokeMultisigRole, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.GET("/multisig/:address/policies", h.GetPolicies, m.AuthMiddleware, m.HasMultisigRole(model.Roles...))
+	e.POST("/multisig/:address/policies", h.ProposePolicyChange, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.POST("/multisig/:address/policies/changes/:changeId/approve", h.ApprovePolicyChange, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.POST("/multisig/:address/policies/changes/:changeId/reject", h.RejectPolicyChange, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.GET("/multisig/:address/address-book", h.GetAddressBook, m.AuthMiddleware, m.HasMultisigRole(model.Roles...))
+	e.POST("/multisig/:address/address-book", h.CreateAddressBookEntry, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.PUT("/multisig/:address/address-book/:entryId", h.UpdateAddressBookEntry, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.DELETE("/multisig/:address/address-book/:entryId", h.DeleteAddressBookEntry, m.AuthMiddleware, m.IsMultisigAdmin, m.IsActiveMultisig)
+	e.GET("/multisig/:address/templates", h.GetTemplates, m.AuthMiddleware, m.HasMultisigRole(model.Roles...))
+	e.POST("/multisig/:address/templates", h.CreateTemplate, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.PUT("/multisig/:address/templates/:templateId", h.UpdateTemplate, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.DELETE("/multisig/:address/templates/:templateId", h.DeleteTemplate, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.POST("/multisig/:address/templates/:templateId/propose", h.ProposeTemplate, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.GET("/multisig/:address/schedules", h.GetSchedules, m.AuthMiddleware, m.HasMultisigRole(model.Roles...))
+	e.POST("/multisig/:address/schedules", h.CreateSchedule, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.PATCH("/multisig/:address/schedules/:scheduleId", h.UpdateSchedule, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.DELETE("/multisig/:address/schedules/:scheduleId", h.DeleteSchedule, m.AuthMiddleware, m.HasMultisigRole(model.RoleProposer), m.IsActiveMultisig)
+	e.GET("/accounts/:address/all-txns", h.GetAllMultisigTxns, m.OptionalAuthMiddleware)
 	e.POST("/transactions", h.GetRecentTransactions)
 	e.GET("/txns/:chainId/:address", h.GetAllTransactions)
 	e.GET("/txns/:chainId/:address/:txhash", h.GetChainTxHash)
 	e.GET("/search/txns/:txhash", h.GetTxHash)
 
+	// auth
+	e.GET("/auth/challenge/:address", h.GetChallenge)
+	e.POST("/auth/refresh", h.RefreshSession)
+	e.POST("/auth/logout", h.Logout, m.AuthMiddleware)
+
 	// users
 	e.POST("/users/:address/signature", h.CreateUserSignature)
 	e.GET("/users/:address", h.GetUser)
@@ -123,7 +166,7 @@ func main() {
 	})
 
 	// Setup coingecko cron job
-	cronClient := cron.NewCron(config, db)
+	cronClient := cron.NewCron(config, db, h)
 	cronClient.Start()
 
 	// Start server
@@ -0,0 +1,495 @@
+// Package summary turns transaction messages into structured, human-readable summaries.
+package summary
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/vitwit/resolute/server/config"
+	"github.com/vitwit/resolute/server/cosmos"
+)
+
+// Chain holds the chain details used to render amounts and validators.
+type Chain struct {
+	Currencies []config.Currency
+	// Moniker returns the moniker of a validator operator address, or an empty
+	// string when it is unknown. It may be nil.
+	Moniker func(operator string) string
+	// Label returns the address book label of an address, or an empty string when it
+	// is unknown. It may be nil.
+	Label func(address string) string
+}
+
+// NewChain returns the summary details of a configured chain.
+func NewChain(chain *config.ChainConfig, moniker func(string) string) Chain {
+	c := Chain{Moniker: moniker}
+	if chain != nil {
+		c.Currencies = chain.Currencies
+	}
+
+	return c
+}
+
+// Amount is a coin in its base and display units.
+type Amount struct {
+	Denom   string `json:"denom"`
+	Amount  string `json:"amount"`
+	Display string `json:"display"`
+}
+
+// Summary describes a single message.
+type Summary struct {
+	Type       string    `json:"type"`
+	Action     string    `json:"action"`
+	Text       string    `json:"text"`
+	Amounts    []Amount  `json:"amounts,omitempty"`
+	From       string    `json:"from,omitempty"`
+	FromLabel  string    `json:"from_label,omitempty"`
+	To         string    `json:"to,omitempty"`
+	ToLabel    string    `json:"to_label,omitempty"`
+	Validator  string    `json:"validator,omitempty"`
+	ProposalID string    `json:"proposal_id,omitempty"`
+	Option     string    `json:"option,omitempty"`
+	Messages   []Summary `json:"messages,omitempty"`
+}
+
+// Decode parses messages in the cosmjs or REST API JSON form, skipping the ones
+// that can not be read.
+func Decode(messages []interface{}) []cosmos.Msg {
+	msgs := make([]cosmos.Msg, 0, len(messages))
+	for _, m := range messages {
+		msg, err := cosmos.ParseMsg(m)
+		if err != nil {
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+// DecodeJSON parses a JSON list of messages.
+func DecodeJSON(bz []byte) []cosmos.Msg {
+	var messages []interface{}
+	if err := json.Unmarshal(bz, &messages); err != nil {
+		return nil
+	}
+
+	return Decode(messages)
+}
+
+// Summarize returns the summaries of the messages.
+func Summarize(msgs []cosmos.Msg, chain Chain) []Summary {
+	summaries := make([]Summary, 0, len(msgs))
+	for _, msg := range msgs {
+		summaries = append(summaries, chain.Summarize(msg))
+	}
+
+	return summaries
+}
+
+// Summarize returns the summary of a message.
+func (c Chain) Summarize(msg cosmos.Msg) Summary {
+	v := cosmos.Value(msg.Value)
+	s := Summary{Type: msg.TypeUrl}
+
+	switch msg.TypeUrl {
+	case cosmos.MsgSendType.TypeUrl:
+		s.Action = "send"
+		s.From = str(v, "fromAddress")
+		s.To = str(v, "toAddress")
+		s.Amounts = c.coins(v, "amount")
+		s.Text = fmt.Sprintf("Send %s to %s", display(s.Amounts), c.address(s.To))
+
+	case cosmos.MsgMultiSendType.TypeUrl:
+		s.Action = "multi-send"
+		outputs, _ := v.List("outputs")
+		var coins []interface{}
+		for _, o := range outputs {
+			if out, ok := o.(map[string]interface{}); ok {
+				list, _ := cosmos.Value(out).List("coins")
+				coins = append(coins, list...)
+			}
+		}
+		s.Amounts = c.sum(coins)
+		s.Text = fmt.Sprintf("Send %s to %d recipients", display(s.Amounts), len(outputs))
+
+	case cosmos.MsgDelegateType.TypeUrl:
+		s.Action = "delegate"
+		s.From = str(v, "delegatorAddress")
+		s.Validator = str(v, "validatorAddress")
+		s.Amounts = c.coin(v, "amount")
+		s.Text = fmt.Sprintf("Delegate %s to %s", display(s.Amounts), c.validator(s.Validator))
+
+	case cosmos.MsgUndelegateType.TypeUrl:
+		s.Action = "undelegate"
+		s.From = str(v, "delegatorAddress")
+		s.Validator = str(v, "validatorAddress")
+		s.Amounts = c.coin(v, "amount")
+		s.Text = fmt.Sprintf("Undelegate %s from %s", display(s.Amounts), c.validator(s.Validator))
+
+	case cosmos.MsgBeginRedelegateType.TypeUrl:
+		s.Action = "redelegate"
+		s.From = str(v, "delegatorAddress")
+		s.Validator = str(v, "validatorSrcAddress")
+		s.To = str(v, "validatorDstAddress")
+		s.Amounts = c.coin(v, "amount")
+		s.Text = fmt.Sprintf("Redelegate %s from %s to %s", display(s.Amounts),
+			c.validator(s.Validator), c.validator(s.To))
+
+	case cosmos.MsgWithdrawDelegatorRewardType.TypeUrl:
+		s.Action = "withdraw-rewards"
+		s.From = str(v, "delegatorAddress")
+		s.Validator = str(v, "validatorAddress")
+		s.Text = fmt.Sprintf("Claim rewards from %s", c.validator(s.Validator))
+
+	case cosmos.MsgWithdrawValidatorCommissionType.TypeUrl:
+		s.Action = "withdraw-commission"
+		s.Validator = str(v, "validatorAddress")
+		s.Text = fmt.Sprintf("Claim commission of %s", c.validator(s.Validator))
+
+	case cosmos.MsgSetWithdrawAddressType.TypeUrl:
+		s.Action = "set-withdraw-address"
+		s.From = str(v, "delegatorAddress")
+		s.To = str(v, "withdrawAddress")
+		s.Text = fmt.Sprintf("Set rewards withdraw address to %s", c.address(s.To))
+
+	case cosmos.MsgFundCommunityPoolType.TypeUrl:
+		s.Action = "fund-community-pool"
+		s.From = str(v, "depositor")
+		s.Amounts = c.coins(v, "amount")
+		s.Text = fmt.Sprintf("Fund the community pool with %s", display(s.Amounts))
+
+	case cosmos.MsgVoteType.TypeUrl, cosmos.MsgVoteV1Type.TypeUrl:
+		s.Action = "vote"
+		s.From = str(v, "voter")
+		s.ProposalID = proposalID(v)
+		s.Option = voteOption(v)
+		s.Text = fmt.Sprintf("Vote %s on proposal #%s", s.Option, s.ProposalID)
+
+	case cosmos.MsgDepositType.TypeUrl, cosmos.MsgDepositV1Type.TypeUrl:
+		s.Action = "deposit"
+		s.From = str(v, "depositor")
+		s.ProposalID = proposalID(v)
+		s.Amounts = c.coins(v, "amount")
+		s.Text = fmt.Sprintf("Deposit %s on proposal #%s", display(s.Amounts), s.ProposalID)
+
+	case cosmos.MsgGrantType.TypeUrl:
+		s.Action = "grant"
+		s.From = str(v, "granter")
+		s.To = str(v, "grantee")
+		s.Text = fmt.Sprintf("Grant %s to %s", c.authorization(v, &s), c.address(s.To))
+
+	case cosmos.MsgRevokeType.TypeUrl:
+		s.Action = "revoke"
+		s.From = str(v, "granter")
+		s.To = str(v, "grantee")
+		s.Text = fmt.Sprintf("Revoke permission to %s from %s", typeName(str(v, "msgTypeUrl")), c.address(s.To))
+
+	case cosmos.MsgExecType.TypeUrl:
+		s.Action = "exec"
+		s.From = str(v, "grantee")
+		list, _ := v.List("msgs")
+		s.Messages = Summarize(Decode(list), c)
+		if len(s.Messages) == 1 {
+			s.Text = fmt.Sprintf("Execute on behalf of a granter: %s", s.Messages[0].Text)
+		} else {
+			s.Text = fmt.Sprintf("Execute %d messages on behalf of granters", len(list))
+		}
+
+	case cosmos.MsgGrantAllowanceType.TypeUrl:
+		s.Action = "grant-allowance"
+		s.From = str(v, "granter")
+		s.To = str(v, "grantee")
+		s.Text = fmt.Sprintf("Grant a fee allowance to %s", c.address(s.To))
+		if obj, err := v.Object("allowance"); err == nil {
+			if allowance, err := cosmos.ParseMsg(map[string]interface{}(obj)); err == nil {
+				s.Amounts = c.coins(cosmos.Value(allowance.Value), "spendLimit")
+				if len(s.Amounts) > 0 {
+					s.Text = fmt.Sprintf("Grant a fee allowance of up to %s to %s", display(s.Amounts), c.address(s.To))
+				}
+			}
+		}
+
+	case cosmos.MsgRevokeAllowanceType.TypeUrl:
+		s.Action = "revoke-allowance"
+		s.From = str(v, "granter")
+		s.To = str(v, "grantee")
+		s.Text = fmt.Sprintf("Revoke the fee allowance of %s", c.address(s.To))
+
+	case cosmos.MsgTransferType.TypeUrl:
+		s.Action = "ibc-transfer"
+		s.From = str(v, "sender")
+		s.To = str(v, "receiver")
+		s.Amounts = c.coin(v, "token")
+		s.Text = fmt.Sprintf("Transfer %s to %s via %s", display(s.Amounts), c.address(s.To), str(v, "sourceChannel"))
+
+	case cosmos.MsgExecuteContractType.TypeUrl:
+		s.Action = "execute-contract"
+		s.From = str(v, "sender")
+		s.To = str(v, "contract")
+		s.Amounts = c.coins(v, "funds")
+		s.Text = fmt.Sprintf("Execute contract %s", c.address(s.To))
+		if action := contractAction(v); action != "" {
+			s.Text += fmt.Sprintf(" (%s)", action)
+		}
+		if len(s.Amounts) > 0 {
+			s.Text += fmt.Sprintf(" with %s", display(s.Amounts))
+		}
+
+	default:
+		s.Action = "unknown"
+		s.Text = typeName(msg.TypeUrl)
+	}
+
+	s.FromLabel, s.ToLabel = c.label(s.From), c.label(s.To)
+
+	return s
+}
+
+// authorization describes the authorization of a grant.
+func (c Chain) authorization(v cosmos.Value, s *Summary) string {
+	grant, err := v.Object("grant")
+	if err != nil {
+		return "authorization"
+	}
+
+	obj, err := grant.Object("authorization")
+	if err != nil {
+		return "authorization"
+	}
+
+	auth, err := cosmos.ParseMsg(map[string]interface{}(obj))
+	if err != nil {
+		return "authorization"
+	}
+
+	switch auth.TypeUrl {
+	case cosmos.GenericAuthorizationType.TypeUrl:
+		return fmt.Sprintf("permission to %s", typeName(str(cosmos.Value(auth.Value), "msg")))
+	case cosmos.SendAuthorizationType.TypeUrl:
+		s.Amounts = c.coins(cosmos.Value(auth.Value), "spendLimit")
+		return fmt.Sprintf("permission to send up to %s", display(s.Amounts))
+	default:
+		return fmt.Sprintf("%s authorization", typeName(auth.TypeUrl))
+	}
+}
+
+func (c Chain) validator(operator string) string {
+	if c.Moniker != nil {
+		if moniker := c.Moniker(operator); moniker != "" {
+			return moniker
+		}
+	}
+
+	return ShortAddress(operator)
+}
+
+func (c Chain) label(address string) string {
+	if c.Label == nil || address == "" {
+		return ""
+	}
+
+	return c.Label(address)
+}
+
+// address returns the label of an address, or its abbreviation when it has none.
+func (c Chain) address(address string) string {
+	if label := c.label(address); label != "" {
+		return label
+	}
+
+	return ShortAddress(address)
+}
+
+func (c Chain) coin(v cosmos.Value, name string) []Amount {
+	obj, err := v.Object(name)
+	if err != nil {
+		return nil
+	}
+
+	return []Amount{c.Amount(str(obj, "denom"), str(obj, "amount"))}
+}
+
+func (c Chain) coins(v cosmos.Value, name string) []Amount {
+	list, _ := v.List(name)
+	return c.sum(list)
+}
+
+// sum adds up a list of coins by denom, keeping the order in which denoms appear.
+func (c Chain) sum(list []interface{}) []Amount {
+	var denoms []string
+	totals := make(map[string]*big.Int)
+	for _, item := range list {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		denom := str(cosmos.Value(obj), "denom")
+		n, ok := new(big.Int).SetString(str(cosmos.Value(obj), "amount"), 10)
+		if !ok {
+			continue
+		}
+
+		if totals[denom] == nil {
+			totals[denom] = new(big.Int)
+			denoms = append(denoms, denom)
+		}
+		totals[denom].Add(totals[denom], n)
+	}
+
+	amounts := make([]Amount, 0, len(denoms))
+	for _, denom := range denoms {
+		amounts = append(amounts, c.Amount(denom, totals[denom].String()))
+	}
+
+	return amounts
+}
+
+// Amount converts an amount in a base denom into its display form, such as
+// "1,200 ATOM" for 1200000000uatom.
+func (c Chain) Amount(denom string, amount string) Amount {
+	a := Amount{Denom: denom, Amount: amount}
+
+	n, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		a.Display = strings.TrimSpace(amount + " " + denom)
+		return a
+	}
+
+	for _, currency := range c.Currencies {
+		if currency.Denom == denom {
+			a.Display = formatDecimal(n, currency.Decimals) + " " + currency.DisplayDenom
+			return a
+		}
+	}
+
+	a.Display = formatDecimal(n, 0) + " " + denom
+	return a
+}
+
+// formatDecimal shifts the integer by the decimals and adds thousands separators.
+func formatDecimal(n *big.Int, decimals int) string {
+	s := new(big.Int).Abs(n).String()
+	if len(s) <= decimals {
+		s = strings.Repeat("0", decimals-len(s)+1) + s
+	}
+
+	whole, frac := s[:len(s)-decimals], strings.TrimRight(s[len(s)-decimals:], "0")
+
+	var b strings.Builder
+	if n.Sign() < 0 {
+		b.WriteByte('-')
+	}
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if frac != "" {
+		b.WriteByte('.')
+		b.WriteString(frac)
+	}
+
+	return b.String()
+}
+
+func display(amounts []Amount) string {
+	if len(amounts) == 0 {
+		return "nothing"
+	}
+
+	parts := make([]string, 0, len(amounts))
+	for _, a := range amounts {
+		parts = append(parts, a.Display)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// ShortAddress abbreviates a bech32 address, keeping its prefix.
+func ShortAddress(address string) string {
+	i := strings.LastIndex(address, "1")
+	if i < 0 || len(address)-i-1 <= 12 {
+		return address
+	}
+
+	return address[:i+5] + "…" + address[len(address)-4:]
+}
+
+// typeName returns the message name of a type url, such as MsgVote.
+func typeName(typeUrl string) string {
+	if typeUrl == "" {
+		return "unknown messages"
+	}
+
+	return typeUrl[strings.LastIndex(typeUrl, ".")+1:]
+}
+
+var voteOptionNames = map[int64]string{
+	1: "YES",
+	2: "ABSTAIN",
+	3: "NO",
+	4: "NO WITH VETO",
+}
+
+func voteOption(v cosmos.Value) string {
+	var n int64
+	switch option := v["option"].(type) {
+	case string:
+		if name := strings.TrimPrefix(option, "VOTE_OPTION_"); name != option {
+			return strings.ReplaceAll(name, "_", " ")
+		}
+		fmt.Sscan(option, &n)
+	case float64:
+		n = int64(option)
+	}
+
+	if name, ok := voteOptionNames[n]; ok {
+		return name
+	}
+
+	return "UNSPECIFIED"
+}
+
+// contractAction returns the name of the executed contract method, which is the
+// single top level key of the contract message.
+func contractAction(v cosmos.Value) string {
+	bz, err := cosmos.ContractMsg(v["msg"])
+	if err != nil {
+		return ""
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(bz, &obj); err != nil || len(obj) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys[0]
+}
+
+func proposalID(v cosmos.Value) string {
+	id, err := v.Uint("proposalId")
+	if err != nil {
+		return "?"
+	}
+
+	return fmt.Sprintf("%d", id)
+}
+
+// str returns a string field, or an empty string when it is not set.
+func str(v cosmos.Value, name string) string {
+	s, _ := v.String(name)
+	return s
+}
@@ -0,0 +1,135 @@
+package summary
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vitwit/resolute/server/config"
+)
+
+func TestSummarize(t *testing.T) {
+	chain := Chain{
+		Currencies: []config.Currency{
+			{Denom: "uatom", DisplayDenom: "ATOM", Decimals: 6},
+			{Denom: "aevmos", DisplayDenom: "EVMOS", Decimals: 18},
+		},
+		Moniker: func(operator string) string {
+			if operator == "cosmosvaloper1sjllsnramtg3ewxqwwrwjxfgc4n4ef9u2lcnj0" {
+				return "Vitwit"
+			}
+			return ""
+		},
+	}
+
+	testCases := []struct {
+		name    string
+		message string
+		expText string
+		expAmts []Amount
+	}{
+		{
+			"send",
+			`{"typeUrl":"/cosmos.bank.v1beta1.MsgSend","value":{"fromAddress":"cosmos1qyqszqgpqyqszqgpqyqszqgpqyqszqgpjnp7du","toAddress":"cosmos1qgpqyqszqgpqyqszqgpqyqszqgpqyqszrh8mx2","amount":[{"denom":"uatom","amount":"1200000000"}]}}`,
+			"Send 1,200 ATOM to cosmos1qgpq…8mx2",
+			[]Amount{{Denom: "uatom", Amount: "1200000000", Display: "1,200 ATOM"}},
+		},
+		{
+			"send on chain with an unknown denom",
+			`{"@type":"/cosmos.bank.v1beta1.MsgSend","from_address":"cosmos1qyqszqgpqyqszqgpqyqszqgpqyqszqgpjnp7du","to_address":"cosmos1qgpqyqszqgpqyqszqgpqyqszqgpqyqszrh8mx2","amount":[{"denom":"uatom","amount":"1500"},{"denom":"ibc/ABC","amount":"1234567"}]}`,
+			"Send 0.0015 ATOM, 1,234,567 ibc/ABC to cosmos1qgpq…8mx2",
+			[]Amount{
+				{Denom: "uatom", Amount: "1500", Display: "0.0015 ATOM"},
+				{Denom: "ibc/ABC", Amount: "1234567", Display: "1,234,567 ibc/ABC"},
+			},
+		},
+		{
+			"multi send",
+			`{"typeUrl":"/cosmos.bank.v1beta1.MsgMultiSend","value":{"inputs":[],"outputs":[{"address":"a","coins":[{"denom":"uatom","amount":"500000"}]},{"address":"b","coins":[{"denom":"uatom","amount":"1500000"}]}]}}`,
+			"Send 2 ATOM to 2 recipients",
+			[]Amount{{Denom: "uatom", Amount: "2000000", Display: "2 ATOM"}},
+		},
+		{
+			"delegate to a known validator",
+			`{"typeUrl":"/cosmos.staking.v1beta1.MsgDelegate","value":{"delegatorAddress":"cosmos1qyqszqgpqyqszqgpqyqszqgpqyqszqgpjnp7du","validatorAddress":"cosmosvaloper1sjllsnramtg3ewxqwwrwjxfgc4n4ef9u2lcnj0","amount":{"denom":"aevmos","amount":"50000000000000000000"}}}`,
+			"Delegate 50 EVMOS to Vitwit",
+			[]Amount{{Denom: "aevmos", Amount: "50000000000000000000", Display: "50 EVMOS"}},
+		},
+		{
+			"undelegate from an unknown validator",
+			`{"typeUrl":"/cosmos.staking.v1beta1.MsgUndelegate","value":{"delegatorAddress":"cosmos1qyqszqgpqyqszqgpqyqszqgpqyqszqgpjnp7du","validatorAddress":"cosmosvaloper1qyqszqgpqyqszqgpqyqszqgpqyqszqgp8ze2aw","amount":{"denom":"uatom","amount":"1"}}}`,
+			"Undelegate 0.000001 ATOM from cosmosvaloper1qyqs…e2aw",
+			[]Amount{{Denom: "uatom", Amount: "1", Display: "0.000001 ATOM"}},
+		},
+		{
+			"vote in cosmjs form",
+			`{"typeUrl":"/cosmos.gov.v1beta1.MsgVote","value":{"proposalId":845,"voter":"cosmos1qyqszqgpqyqszqgpqyqszqgpqyqszqgpjnp7du","option":1}}`,
+			"Vote YES on proposal #845",
+			nil,
+		},
+		{
+			"vote in rest form",
+			`{"@type":"/cosmos.gov.v1.MsgVote","proposal_id":"12","voter":"cosmos1qyqszqgpqyqszqgpqyqszqgpqyqszqgpjnp7du","option":"VOTE_OPTION_NO_WITH_VETO"}`,
+			"Vote NO WITH VETO on proposal #12",
+			nil,
+		},
+		{
+			"exec of a single message",
+			`{"typeUrl":"/cosmos.authz.v1beta1.MsgExec","value":{"grantee":"cosmos1qyqszqgpqyqszqgpqyqszqgpqyqszqgpjnp7du","msgs":[{"typeUrl":"/cosmos.gov.v1beta1.MsgVote","value":{"proposalId":"3","voter":"x","option":3}}]}}`,
+			"Execute on behalf of a granter: Vote NO on proposal #3",
+			nil,
+		},
+		{
+			"grant generic authorization",
+			`{"typeUrl":"/cosmos.authz.v1beta1.MsgGrant","value":{"granter":"a","grantee":"cosmos1qgpqyqszqgpqyqszqgpqyqszqgpqyqszrh8mx2","grant":{"authorization":{"typeUrl":"/cosmos.authz.v1beta1.GenericAuthorization","value":{"msg":"/cosmos.gov.v1beta1.MsgVote"}}}}}`,
+			"Grant permission to MsgVote to cosmos1qgpq…8mx2",
+			nil,
+		},
+		{
+			"execute contract",
+			`{"typeUrl":"/cosmwasm.wasm.v1.MsgExecuteContract","value":{"sender":"a","contract":"cosmos1qgpqyqszqgpqyqszqgpqyqszqgpqyqszrh8mx2","msg":{"swap":{}},"funds":[{"denom":"uatom","amount":"10000"}]}}`,
+			"Execute contract cosmos1qgpq…8mx2 (swap) with 0.01 ATOM",
+			[]Amount{{Denom: "uatom", Amount: "10000", Display: "0.01 ATOM"}},
+		},
+		{
+			"unknown message",
+			`{"@type":"/osmosis.gamm.v1beta1.MsgSwapExactAmountIn","sender":"a"}`,
+			"MsgSwapExactAmountIn",
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var message interface{}
+			require.NoError(t, json.Unmarshal([]byte(tc.message), &message))
+
+			msgs := Decode([]interface{}{message})
+			require.Len(t, msgs, 1)
+
+			s := chain.Summarize(msgs[0])
+			require.Equal(t, tc.expText, s.Text)
+			require.Equal(t, tc.expAmts, s.Amounts)
+		})
+	}
+}
+
+func TestSummarizeLabels(t *testing.T) {
+	chain := Chain{
+		Currencies: []config.Currency{{Denom: "uatom", DisplayDenom: "ATOM", Decimals: 6}},
+		Label: func(address string) string {
+			if address == "cosmos1qgpqyqszqgpqyqszqgpqyqszqgpqyqszrh8mx2" {
+				return "Payroll"
+			}
+			return ""
+		},
+	}
+
+	var message interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"typeUrl":"/cosmos.bank.v1beta1.MsgSend","value":{"fromAddress":"cosmos1qyqszqgpqyqszqgpqyqszqgpqyqszqgpjnp7du","toAddress":"cosmos1qgpqyqszqgpqyqszqgpqyqszqgpqyqszrh8mx2","amount":[{"denom":"uatom","amount":"5000000"}]}}`), &message))
+
+	s := chain.Summarize(Decode([]interface{}{message})[0])
+	require.Equal(t, "Send 5 ATOM to Payroll", s.Text)
+	require.Equal(t, "Payroll", s.ToLabel)
+	require.Empty(t, s.FromLabel)
+}
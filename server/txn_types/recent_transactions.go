@@ -1,6 +1,10 @@
 package txn_types
 
-import "time"
+import (
+	"time"
+
+	"github.com/vitwit/resolute/server/summary"
+)
 
 type TxResponse struct {
 	Code      int           `json:"code"`
@@ -72,17 +76,18 @@ type TransactionResponses struct {
 }
 
 type ParsedTxn struct {
-	Code      int           `json:"code"`
-	GasUsed   string        `json:"gas_used"`
-	GasWanted string        `json:"gas_wanted"`
-	Fee       Amount        `json:"fee"`
-	Height    string        `json:"height"`
-	RawLog    string        `json:"raw_log"`
-	Timestamp time.Time     `json:"timestamp"`
-	Txhash    string        `json:"txhash"`
-	Memo      string        `json:"memo"`
-	Messages  []interface{} `json:"messages"`
-	ChainId   string        `json:"chain_id"`
+	Code      int               `json:"code"`
+	GasUsed   string            `json:"gas_used"`
+	GasWanted string            `json:"gas_wanted"`
+	Fee       Amount            `json:"fee"`
+	Height    string            `json:"height"`
+	RawLog    string            `json:"raw_log"`
+	Timestamp time.Time         `json:"timestamp"`
+	Txhash    string            `json:"txhash"`
+	Memo      string            `json:"memo"`
+	Messages  []interface{}     `json:"messages"`
+	ChainId   string            `json:"chain_id"`
+	Summaries []summary.Summary `json:"summaries"`
 }
 
 type SingleTxnRes struct {
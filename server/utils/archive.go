@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ParseIncludeDeleted reports whether deleted items should be listed, as requested by
+// the include_deleted query parameter. They are left out by default.
+func ParseIncludeDeleted(c echo.Context) (bool, error) {
+	v := c.QueryParam("include_deleted")
+	if v == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(v)
+}
+
+// Restorable reports whether an item deleted at deletedAt is still within the
+// retention period.
+func Restorable(deletedAt time.Time, retention time.Duration) bool {
+	return time.Since(deletedAt) < retention
+}
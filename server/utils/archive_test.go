@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIncludeDeleted(t *testing.T) {
+	testCases := []struct {
+		name   string
+		query  string
+		expErr bool
+		exp    bool
+	}{
+		{"no param", "/", false, false},
+		{"included", "/?include_deleted=true", false, true},
+		{"excluded", "/?include_deleted=false", false, false},
+		{"invalid value", "/?include_deleted=maybe", true, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, tc.query, nil), httptest.NewRecorder())
+
+			include, err := ParseIncludeDeleted(c)
+			if tc.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, include)
+		})
+	}
+}
+
+func TestRestorable(t *testing.T) {
+	retention := 24 * time.Hour
+
+	require.True(t, Restorable(time.Now().Add(-time.Hour), retention))
+	require.False(t, Restorable(time.Now().Add(-25*time.Hour), retention))
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vitwit/resolute/server/cosmos"
+)
+
+// GetBearerToken returns the token of the Authorization header, or an empty string
+func GetBearerToken(c echo.Context) string {
+	auth := c.Request().Header.Get(echo.HeaderAuthorization)
+	if len(auth) <= len("Bearer ") || !strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return ""
+	}
+
+	return strings.TrimSpace(auth[len("Bearer "):])
+}
+
+// GetAuthAddress returns the address authenticated by the auth middleware
+func GetAuthAddress(c echo.Context) string {
+	address, _ := c.Get(AUTH_ADDRESS_KEY).(string)
+	return address
+}
+
+// GetAuthMemberAddress returns the authenticated address encoded with the chain prefix of
+// the multisig account, since users log in with a single address for all chains.
+func GetAuthMemberAddress(c echo.Context, multisigAddress string) (string, error) {
+	address := GetAuthAddress(c)
+	if address == "" {
+		return "", errors.New("no authenticated address")
+	}
+
+	prefix, _, err := cosmos.DecodeAddress(multisigAddress)
+	if err != nil {
+		return "", err
+	}
+
+	return cosmos.ConvertAddress(address, prefix)
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBearerToken(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		expToken string
+	}{
+		{"no header", "", ""},
+		{"bearer token", "Bearer abcd", "abcd"},
+		{"lower case scheme", "bearer abcd", "abcd"},
+		{"other scheme", "Basic abcd", ""},
+		{"empty token", "Bearer ", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tc.header)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			require.Equal(t, tc.expToken, GetBearerToken(c))
+		})
+	}
+}
@@ -1,3 +1,27 @@
 package utils
 
+import "time"
+
 const DEFAULT_PAGE_LIMIT = 30
+
+// CHALLENGE_EXPIRY is how long a login challenge stays valid
+const CHALLENGE_EXPIRY = 5 * time.Minute
+
+// SESSION_EXPIRY is how long an access token is accepted after login or refresh
+const SESSION_EXPIRY = 1 * time.Hour
+
+// REFRESH_EXPIRY is how long a refresh token can be exchanged for a new session
+const REFRESH_EXPIRY = 7 * 24 * time.Hour
+
+// AUTH_ADDRESS_KEY is the echo context key holding the authenticated address
+const AUTH_ADDRESS_KEY = "auth_address"
+
+// BROADCAST_TIMEOUT is how long a broadcasted transaction may stay unconfirmed before it is marked as failed
+const BROADCAST_TIMEOUT = 10 * time.Minute
+
+// GAS_ADJUSTMENT is the margin applied to simulated gas when suggesting a gas limit
+const GAS_ADJUSTMENT = 1.3
+
+// ACCESS_TOKEN_PARAM is the query parameter and cookie holding the access token of
+// requests that cannot set the Authorization header, such as EventSource streams
+const ACCESS_TOKEN_PARAM = "access_token"
@@ -40,8 +40,6 @@ func GetChainAPIs(chainId string) (*config.ChainConfig, error) {
 		}
 	}
 
-	fmt.Println("result============================", result.SourceEnd, result.ChainId)
-
 	if result == nil {
 		return nil, errors.New("chain id not found")
 	}